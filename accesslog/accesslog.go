@@ -0,0 +1,211 @@
+package accesslog
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "io"
+    "math/rand/v2"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/camry/g/v2/glog"
+
+    "github.com/camry/dove/v2"
+)
+
+// Format 定义访问日志输出格式。
+type Format int
+
+const (
+    FormatJSON   Format = iota // FormatJSON JSON 格式。
+    FormatLogfmt               // FormatLogfmt logfmt 格式。
+)
+
+// Option 定义一个访问日志选项类型。
+type Option func(o *option)
+
+// option 访问日志选项实体对象。
+type option struct {
+    logger     glog.Logger
+    writer     io.Writer
+    format     Format
+    sampleRate float64
+    excludes   []string
+}
+
+// Logger 配置访问日志记录器，默认使用 glog 全局日志记录器。
+func Logger(logger glog.Logger) Option {
+    return func(o *option) { o.logger = logger }
+}
+
+// Writer 配置访问日志专用输出，配置后不再写入 Logger。
+func Writer(w io.Writer) Option {
+    return func(o *option) { o.writer = w }
+}
+
+// WithFormat 配置专用输出的日志格式，默认为 JSON。
+func WithFormat(f Format) Option {
+    return func(o *option) { o.format = f }
+}
+
+// SampleRate 配置成功请求的采样率（0~1），失败请求始终记录。
+func SampleRate(rate float64) Option {
+    return func(o *option) { o.sampleRate = rate }
+}
+
+// Exclude 配置不记录的 HTTP 路径或 gRPC 方法，以 * 结尾时按前缀匹配。
+func Exclude(patterns ...string) Option {
+    return func(o *option) { o.excludes = append(o.excludes, patterns...) }
+}
+
+// Record 定义一条访问日志记录。
+type Record struct {
+    Transport string        // 传输协议：http 或 grpc。
+    Method    string        // HTTP 方法或 gRPC 调用类型。
+    Path      string        // HTTP 路径或 gRPC 完整方法名。
+    Status    string        // HTTP 状态码或 gRPC 状态码。
+    Latency   time.Duration // 处理耗时。
+    Bytes     int64         // 响应字节数。
+    Peer      string        // 对端地址。
+    RequestID string        // 请求 ID。
+    AppName   string        // 应用名称。
+    AppID     string        // 应用实例 ID。
+    Failed    bool          // 是否为失败请求。
+}
+
+// keyvals 返回记录的键值对。
+func (r *Record) keyvals() []any {
+    return []any{
+        "transport", r.Transport,
+        "method", r.Method,
+        "path", r.Path,
+        "status", r.Status,
+        "latency", r.Latency.Seconds(),
+        "bytes", r.Bytes,
+        "peer", r.Peer,
+        "request_id", r.RequestID,
+        "app_name", r.AppName,
+        "app_id", r.AppID,
+    }
+}
+
+// accessLogger 访问日志记录器。
+type accessLogger struct {
+    opt  option
+    mu   sync.Mutex
+    pool sync.Pool
+}
+
+func newAccessLogger(opts ...Option) *accessLogger {
+    o := option{
+        format:     FormatJSON,
+        sampleRate: 1,
+    }
+    for _, opt := range opts {
+        opt(&o)
+    }
+    if o.logger == nil {
+        o.logger = glog.GetLogger()
+    }
+    return &accessLogger{
+        opt:  o,
+        pool: sync.Pool{New: func() any { return new(bytes.Buffer) }},
+    }
+}
+
+// excluded 判断路径是否被排除。
+func (l *accessLogger) excluded(path string) bool {
+    for _, p := range l.opt.excludes {
+        if prefix, ok := strings.CutSuffix(p, "*"); ok {
+            if strings.HasPrefix(path, prefix) {
+                return true
+            }
+        } else if p == path {
+            return true
+        }
+    }
+    return false
+}
+
+// sampled 判断成功请求是否命中采样。
+func (l *accessLogger) sampled() bool {
+    if l.opt.sampleRate >= 1 {
+        return true
+    }
+    return rand.Float64() < l.opt.sampleRate
+}
+
+// log 输出访问日志。
+func (l *accessLogger) log(ctx context.Context, r *Record) {
+    if !r.Failed && !l.sampled() {
+        return
+    }
+    if app, ok := dove.FromContext(ctx); ok {
+        r.AppName, r.AppID = app.Name(), app.ID()
+    }
+    if l.opt.writer == nil {
+        level := glog.LevelInfo
+        if r.Failed {
+            level = glog.LevelWarn
+        }
+        _ = l.opt.logger.Log(level, r.keyvals()...)
+        return
+    }
+    buf := l.pool.Get().(*bytes.Buffer)
+    defer l.pool.Put(buf)
+    defer buf.Reset()
+    if l.opt.format == FormatLogfmt {
+        encodeLogfmt(buf, r.keyvals())
+    } else {
+        encodeJSON(buf, r.keyvals())
+    }
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    _, _ = l.opt.writer.Write(buf.Bytes())
+}
+
+// encodeJSON 按字段顺序编码为 JSON 行。
+func encodeJSON(buf *bytes.Buffer, kvs []any) {
+    buf.WriteByte('{')
+    for i := 0; i < len(kvs); i += 2 {
+        if i > 0 {
+            buf.WriteByte(',')
+        }
+        k, _ := json.Marshal(fmt.Sprint(kvs[i]))
+        v, err := json.Marshal(kvs[i+1])
+        if err != nil {
+            v, _ = json.Marshal(fmt.Sprint(kvs[i+1]))
+        }
+        buf.Write(k)
+        buf.WriteByte(':')
+        buf.Write(v)
+    }
+    buf.WriteString("}\n")
+}
+
+// encodeLogfmt 按字段顺序编码为 logfmt 行。
+func encodeLogfmt(buf *bytes.Buffer, kvs []any) {
+    for i := 0; i < len(kvs); i += 2 {
+        if i > 0 {
+            buf.WriteByte(' ')
+        }
+        buf.WriteString(fmt.Sprint(kvs[i]))
+        buf.WriteByte('=')
+        var v string
+        switch val := kvs[i+1].(type) {
+        case float64:
+            v = strconv.FormatFloat(val, 'f', -1, 64)
+        default:
+            v = fmt.Sprint(val)
+        }
+        if v == "" || strings.ContainsAny(v, " =\"\t\r\n") {
+            v = strconv.Quote(v)
+        }
+        buf.WriteString(v)
+    }
+    buf.WriteByte('\n')
+}
@@ -0,0 +1,102 @@
+package accesslog
+
+import (
+    "context"
+    "sync/atomic"
+    "time"
+
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/metadata"
+    "google.golang.org/grpc/peer"
+    "google.golang.org/grpc/status"
+    "google.golang.org/protobuf/proto"
+
+    "github.com/camry/dove/v2/requestid"
+)
+
+// UnaryServerInterceptor 返回记录 gRPC 一元调用访问日志的拦截器。
+func UnaryServerInterceptor(opts ...Option) grpc.UnaryServerInterceptor {
+    l := newAccessLogger(opts...)
+    return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+        if l.excluded(info.FullMethod) {
+            return handler(ctx, req)
+        }
+        start := time.Now()
+        ctx, id := withRequestID(ctx)
+        resp, err := handler(ctx, req)
+        var n int64
+        if m, ok := resp.(proto.Message); ok && err == nil {
+            n = int64(proto.Size(m))
+        }
+        l.log(ctx, newRecord(ctx, "unary", info.FullMethod, id, start, n, err))
+        return resp, err
+    }
+}
+
+// StreamServerInterceptor 返回记录 gRPC 流调用访问日志的拦截器。
+func StreamServerInterceptor(opts ...Option) grpc.StreamServerInterceptor {
+    l := newAccessLogger(opts...)
+    return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+        if l.excluded(info.FullMethod) {
+            return handler(srv, ss)
+        }
+        start := time.Now()
+        ctx, id := withRequestID(ss.Context())
+        ws := &wrappedStream{ServerStream: ss, ctx: ctx}
+        err := handler(srv, ws)
+        l.log(ctx, newRecord(ctx, "stream", info.FullMethod, id, start, ws.bytes.Load(), err))
+        return err
+    }
+}
+
+// withRequestID 从元数据中获取或生成请求 ID，并写入响应头与上下文。
+func withRequestID(ctx context.Context) (context.Context, string) {
+    var id string
+    if md, ok := metadata.FromIncomingContext(ctx); ok {
+        if v := md.Get(requestid.MetadataKey); len(v) > 0 {
+            id = v[0]
+        }
+    }
+    if id == "" {
+        id = requestid.New()
+    }
+    _ = grpc.SetHeader(ctx, metadata.Pairs(requestid.MetadataKey, id))
+    return requestid.NewContext(ctx, id), id
+}
+
+// newRecord 新建 gRPC 访问日志记录。
+func newRecord(ctx context.Context, kind, fullMethod, id string, start time.Time, n int64, err error) *Record {
+    r := &Record{
+        Transport: "grpc",
+        Method:    kind,
+        Path:      fullMethod,
+        Status:    status.Code(err).String(),
+        Latency:   time.Since(start),
+        Bytes:     n,
+        RequestID: id,
+        Failed:    err != nil,
+    }
+    if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+        r.Peer = p.Addr.String()
+    }
+    return r
+}
+
+// wrappedStream 记录流发送字节数并重写上下文。
+type wrappedStream struct {
+    grpc.ServerStream
+    ctx   context.Context
+    bytes atomic.Int64
+}
+
+func (w *wrappedStream) Context() context.Context {
+    return w.ctx
+}
+
+func (w *wrappedStream) SendMsg(m any) error {
+    err := w.ServerStream.SendMsg(m)
+    if pm, ok := m.(proto.Message); ok && err == nil {
+        w.bytes.Add(int64(proto.Size(pm)))
+    }
+    return err
+}
@@ -0,0 +1,91 @@
+package accesslog
+
+import (
+    "bufio"
+    "errors"
+    "net"
+    "net/http"
+    "strconv"
+    "time"
+
+    "github.com/camry/dove/v2/requestid"
+    "github.com/camry/dove/v2/server/ghttp"
+)
+
+// Filter 返回记录 HTTP 访问日志的过滤器。
+//
+// 请求未携带 X-Request-Id 时将生成新的请求 ID，并写入响应头与请求上下文。
+func Filter(opts ...Option) ghttp.FilterFunc {
+    l := newAccessLogger(opts...)
+    return func(next http.Handler) http.Handler {
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            if l.excluded(r.URL.Path) {
+                next.ServeHTTP(w, r)
+                return
+            }
+            start := time.Now()
+            id := r.Header.Get(requestid.Header)
+            if id == "" {
+                id = requestid.New()
+            }
+            w.Header().Set(requestid.Header, id)
+            rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+            r = r.WithContext(requestid.NewContext(r.Context(), id))
+            next.ServeHTTP(rw, r)
+            l.log(r.Context(), &Record{
+                Transport: "http",
+                Method:    r.Method,
+                Path:      r.URL.Path,
+                Status:    strconv.Itoa(rw.status),
+                Latency:   time.Since(start),
+                Bytes:     rw.bytes,
+                Peer:      r.RemoteAddr,
+                RequestID: id,
+                Failed:    rw.status >= http.StatusInternalServerError,
+            })
+        })
+    }
+}
+
+// responseWriter 记录响应状态码与字节数。
+type responseWriter struct {
+    http.ResponseWriter
+    status      int
+    bytes       int64
+    wroteHeader bool
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+    if !w.wroteHeader {
+        w.status = code
+        w.wroteHeader = true
+    }
+    w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+    w.wroteHeader = true
+    n, err := w.ResponseWriter.Write(b)
+    w.bytes += int64(n)
+    return n, err
+}
+
+// Flush 实现 http.Flusher。
+func (w *responseWriter) Flush() {
+    if f, ok := w.ResponseWriter.(http.Flusher); ok {
+        f.Flush()
+    }
+}
+
+// Hijack 实现 http.Hijacker。
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+    if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+        return h.Hijack()
+    }
+    return nil, nil, errors.New("accesslog: response writer does not implement http.Hijacker")
+}
+
+// Unwrap 供 http.ResponseController 使用。
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+    return w.ResponseWriter
+}
@@ -0,0 +1,104 @@
+package accesslog
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    "time"
+
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/credentials/insecure"
+    "google.golang.org/grpc/health/grpc_health_v1"
+
+    "github.com/camry/dove/v2"
+    "github.com/camry/dove/v2/requestid"
+    dgrpc "github.com/camry/dove/v2/server/grpc"
+)
+
+func TestFilter(t *testing.T) {
+    buf := &bytes.Buffer{}
+    h := Filter(Writer(buf), Exclude("/healthz", "/static/*"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if id, _ := requestid.FromContext(r.Context()); r.URL.Path == "/api" && id != "rid" {
+            t.Error("expect request id in context")
+        }
+        w.WriteHeader(http.StatusCreated)
+        _, _ = w.Write([]byte("hello"))
+    }))
+    app := dove.New(dove.Name("dove"), dove.ID("1"))
+    for _, path := range []string{"/healthz", "/static/a.js", "/api"} {
+        req := httptest.NewRequest(http.MethodPost, path, nil)
+        req = req.WithContext(dove.NewContext(req.Context(), app))
+        req.Header.Set(requestid.Header, "rid")
+        h.ServeHTTP(httptest.NewRecorder(), req)
+    }
+    lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+    if len(lines) != 1 {
+        t.Fatalf("expect 1 record, got %d: %s", len(lines), buf.String())
+    }
+    var rec map[string]any
+    if err := json.Unmarshal([]byte(lines[0]), &rec); err != nil {
+        t.Fatal(err)
+    }
+    want := map[string]any{
+        "transport": "http", "method": "POST", "path": "/api", "status": "201",
+        "bytes": float64(5), "request_id": "rid", "app_name": "dove", "app_id": "1",
+    }
+    for k, v := range want {
+        if rec[k] != v {
+            t.Errorf("expect %s=%v, got %v", k, v, rec[k])
+        }
+    }
+}
+
+func TestFilter_Logfmt(t *testing.T) {
+    buf := &bytes.Buffer{}
+    h := Filter(Writer(buf), WithFormat(FormatLogfmt), SampleRate(0))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path == "/fail" {
+            w.WriteHeader(http.StatusInternalServerError)
+        }
+    }))
+    h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ok", nil))
+    h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil))
+    out := buf.String()
+    if strings.Contains(out, "path=/ok") {
+        t.Errorf("successful request should be sampled out: %s", out)
+    }
+    if !strings.Contains(out, "path=/fail status=500") {
+        t.Errorf("failed request should always be logged: %s", out)
+    }
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+    buf := &bytes.Buffer{}
+    srv := dgrpc.NewServer(
+        dgrpc.Address("127.0.0.1:0"),
+        dgrpc.UnaryInterceptor(UnaryServerInterceptor(Writer(buf))),
+    )
+    if err := srv.Listen(context.Background()); err != nil {
+        t.Fatal(err)
+    }
+    go func() { _ = srv.Start(context.Background()) }()
+    defer srv.Stop(context.Background())
+
+    conn, err := grpc.NewClient(srv.ListenAddr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer conn.Close()
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+    if _, err = grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{}); err != nil {
+        t.Fatal(err)
+    }
+    var rec map[string]any
+    if err = json.Unmarshal(buf.Bytes(), &rec); err != nil {
+        t.Fatal(err)
+    }
+    if rec["path"] != "/grpc.health.v1.Health/Check" || rec["status"] != "OK" || rec["request_id"] == "" {
+        t.Errorf("unexpected record %v", rec)
+    }
+}
@@ -12,6 +12,8 @@ import (
     "github.com/camry/g/v2/glog"
     "github.com/google/uuid"
     "golang.org/x/sync/errgroup"
+
+    "github.com/camry/dove/v2/server"
 )
 
 // AppInfo 应用程序上下文值接口。
@@ -67,8 +69,13 @@ func (a *App) Run() (err error) {
     eg, ctx := errgroup.WithContext(sCtx)
     wg := sync.WaitGroup{}
 
+    // 启动前统一绑定监听地址。
+    if err = a.listen(sCtx); err != nil {
+        return err
+    }
     for _, fn := range a.opt.beforeStart {
         if err = fn(sCtx); err != nil {
+            a.release()
             return err
         }
     }
@@ -134,6 +141,34 @@ func (a *App) Stop() (err error) {
     return err
 }
 
+// listen 绑定所有实现 server.Listener 接口的服务器，任一失败时释放已绑定的监听器。
+func (a *App) listen(ctx context.Context) error {
+    for _, srv := range a.opt.servers {
+        if l, ok := srv.(server.Listener); ok {
+            if err := l.Listen(ctx); err != nil {
+                a.release()
+                return err
+            }
+        }
+    }
+    return nil
+}
+
+// release 释放已绑定但未启动的服务器资源。
+func (a *App) release() {
+    ctx := NewContext(a.opt.ctx, a)
+    if a.opt.stopTimeout > 0 {
+        var cancel context.CancelFunc
+        ctx, cancel = context.WithTimeout(ctx, a.opt.stopTimeout)
+        defer cancel()
+    }
+    for _, srv := range a.opt.servers {
+        if _, ok := srv.(server.Listener); ok {
+            _ = srv.Stop(ctx)
+        }
+    }
+}
+
 type appKey struct{}
 
 // NewContext 返回一个带有值的新上下文。
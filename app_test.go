@@ -2,12 +2,18 @@ package dove
 
 import (
     "context"
+    "errors"
+    "net"
+    "net/http"
     "reflect"
     "testing"
     "time"
 
     ggtcp "github.com/camry/g/v2/gnet/gtcp"
     ggudp "github.com/camry/g/v2/gnet/gudp"
+    ggrpc "google.golang.org/grpc"
+    "google.golang.org/grpc/credentials/insecure"
+    "google.golang.org/grpc/health/grpc_health_v1"
 
     "github.com/camry/dove/v2/server/gcron"
     "github.com/camry/dove/v2/server/ghttp"
@@ -53,6 +59,150 @@ func TestNew(t *testing.T) {
     }
 }
 
+func TestApp_ListenConflict(t *testing.T) {
+    lis, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer lis.Close()
+    free, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatal(err)
+    }
+    gsAddr := free.Addr().String()
+    _ = free.Close()
+    hs := ghttp.NewServer(ghttp.Address(lis.Addr().String()), ghttp.DrainDelay(2*time.Second))
+    gs := grpc.NewServer(grpc.Address(gsAddr), grpc.DrainDelay(2*time.Second))
+    started := false
+    app := New(
+        Server(gs, hs),
+        BeforeStart(func(_ context.Context) error {
+            started = true
+            return nil
+        }),
+    )
+    start := time.Now()
+    if err = app.Run(); err == nil {
+        t.Fatal("expect listen error, got nil")
+    }
+    // 未启动的服务器不应等待排空。
+    if d := time.Since(start); d > time.Second {
+        t.Fatalf("expect release without drain delay, took %v", d)
+    }
+    if started {
+        t.Fatal("BeforeStart should not run when listen failed")
+    }
+    if addr := gs.ListenAddr(); addr != nil {
+        t.Fatalf("expect listener cleared after stop, got %s", addr)
+    }
+    // 已绑定的监听器应被释放，且可重新监听。
+    if l, err := net.Listen("tcp", gsAddr); err != nil {
+        t.Fatalf("listener %s is leaked: %v", gsAddr, err)
+    } else {
+        _ = l.Close()
+    }
+    // 已停止的服务器不再绑定端口。
+    if err = gs.Listen(context.Background()); !errors.Is(err, ggrpc.ErrServerStopped) {
+        t.Fatalf("expect ErrServerStopped after stop, got %v", err)
+    }
+    if err = gs.Start(context.Background()); err != nil || gs.ListenAddr() != nil {
+        t.Fatalf("expect start after stop without binding, got %v %v", err, gs.ListenAddr())
+    }
+    if err = hs.Start(context.Background()); err != nil || hs.ListenAddr() != nil {
+        t.Fatalf("expect start after stop without binding, got %v %v", err, hs.ListenAddr())
+    }
+}
+
+func TestApp_Drain(t *testing.T) {
+    hs := ghttp.NewServer(
+        ghttp.Address("127.0.0.1:0"),
+        ghttp.DrainDelay(300*time.Millisecond),
+        ghttp.HealthPath("/healthz"),
+        ghttp.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})),
+    )
+    app := New(Server(hs))
+    errCh := make(chan error, 1)
+    go func() { errCh <- app.Run() }()
+    time.Sleep(100 * time.Millisecond)
+    url := "http://" + hs.ListenAddr().String()
+    resp, err := http.Get(url)
+    if err != nil {
+        t.Fatal(err)
+    }
+    _ = resp.Body.Close()
+    if resp.Close {
+        t.Fatal("expect keep-alive response before stop")
+    }
+    if resp, err = http.Get(url + "/healthz"); err != nil {
+        t.Fatal(err)
+    }
+    _ = resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        t.Fatalf("expect health 200 before stop, got %d", resp.StatusCode)
+    }
+    _ = app.Stop()
+    time.Sleep(100 * time.Millisecond)
+    if !hs.Draining() {
+        t.Fatal("expect server draining")
+    }
+    if resp, err = http.Get(url); err != nil {
+        t.Fatal(err)
+    }
+    _ = resp.Body.Close()
+    if !resp.Close {
+        t.Fatal("expect Connection: close while draining")
+    }
+    // 排空期间健康检查返回 503，负载均衡据此摘除流量。
+    if resp, err = http.Get(url + "/healthz"); err != nil {
+        t.Fatal(err)
+    }
+    _ = resp.Body.Close()
+    if resp.StatusCode != http.StatusServiceUnavailable {
+        t.Fatalf("expect health 503 while draining, got %d", resp.StatusCode)
+    }
+    if err = <-errCh; err != nil {
+        t.Fatal(err)
+    }
+}
+
+func TestApp_GRPCDrain(t *testing.T) {
+    gs := grpc.NewServer(grpc.Address("127.0.0.1:0"), grpc.DrainDelay(300*time.Millisecond))
+    app := New(Server(gs))
+    errCh := make(chan error, 1)
+    go func() { errCh <- app.Run() }()
+    time.Sleep(100 * time.Millisecond)
+    conn, err := ggrpc.NewClient(gs.ListenAddr().String(), ggrpc.WithTransportCredentials(insecure.NewCredentials()))
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer conn.Close()
+    client := grpc_health_v1.NewHealthClient(conn)
+    check := func() grpc_health_v1.HealthCheckResponse_ServingStatus {
+        ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+        defer cancel()
+        resp, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+        if err != nil {
+            t.Fatal(err)
+        }
+        return resp.GetStatus()
+    }
+    if st := check(); st != grpc_health_v1.HealthCheckResponse_SERVING {
+        t.Fatalf("expect SERVING before stop, got %v", st)
+    }
+    _ = app.Stop()
+    time.Sleep(100 * time.Millisecond)
+    if !gs.Draining() {
+        t.Fatal("expect server draining")
+    }
+    // 排空期间仍接受请求，健康检查返回 NOT_SERVING。
+    if st := check(); st != grpc_health_v1.HealthCheckResponse_NOT_SERVING {
+        t.Fatalf("expect NOT_SERVING while draining, got %v", st)
+    }
+    if err = <-errCh; err != nil {
+        t.Fatal(err)
+    }
+}
+
 func TestApp_ID(t *testing.T) {
     v := "123"
     o := New(ID(v))
@@ -0,0 +1,88 @@
+package auth
+
+import (
+    "context"
+    "crypto/tls"
+    "errors"
+    "strings"
+)
+
+var (
+    // ErrNoCredentials 请求未携带当前认证器支持的凭证。
+    ErrNoCredentials = errors.New("auth: no credentials")
+    // ErrInvalidCredentials 凭证无效。
+    ErrInvalidCredentials = errors.New("auth: invalid credentials")
+    // ErrPermissionDenied 认证主体无权访问。
+    ErrPermissionDenied = errors.New("auth: permission denied")
+)
+
+// Principal 认证主体。
+type Principal struct {
+    Subject string         // 主体标识。
+    Method  string         // 认证方式，如 jwt、apikey、mtls。
+    Claims  map[string]any // 附加声明。
+}
+
+// Request 认证请求，统一 gRPC 元数据、HTTP 请求头与 TLS 连接信息。
+type Request struct {
+    Operation string               // HTTP 路径或 gRPC 完整方法名。
+    Header    map[string][]string  // 请求头或元数据，键为小写。
+    TLS       *tls.ConnectionState // TLS 连接状态，非 TLS 连接为 nil。
+}
+
+// Get 返回请求头 key 的第一个值，key 不区分大小写。
+func (r *Request) Get(key string) string {
+    if v := r.Header[strings.ToLower(key)]; len(v) > 0 {
+        return v[0]
+    }
+    return ""
+}
+
+// Authenticator 定义认证器接口。
+type Authenticator interface {
+    // Authenticate 认证请求，请求未携带支持的凭证时返回 ErrNoCredentials。
+    Authenticate(ctx context.Context, r *Request) (*Principal, error)
+}
+
+// AuthenticatorFunc 函数形式的认证器。
+type AuthenticatorFunc func(ctx context.Context, r *Request) (*Principal, error)
+
+// Authenticate 实现 Authenticator 接口。
+func (f AuthenticatorFunc) Authenticate(ctx context.Context, r *Request) (*Principal, error) {
+    return f(ctx, r)
+}
+
+// Chain 组合多个认证器，依次尝试直到某个认证器识别到凭证。
+func Chain(authenticators ...Authenticator) Authenticator {
+    return AuthenticatorFunc(func(ctx context.Context, r *Request) (*Principal, error) {
+        for _, a := range authenticators {
+            p, err := a.Authenticate(ctx, r)
+            if errors.Is(err, ErrNoCredentials) {
+                continue
+            }
+            return p, err
+        }
+        return nil, ErrNoCredentials
+    })
+}
+
+type principalKey struct{}
+
+// NewContext 返回一个带有认证主体的新上下文。
+func NewContext(ctx context.Context, p *Principal) context.Context {
+    return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext 返回存储在 ctx 中的认证主体（如果有）。
+func FromContext(ctx context.Context) (p *Principal, ok bool) {
+    p, ok = ctx.Value(principalKey{}).(*Principal)
+    return
+}
+
+// Authenticated 要求上下文中存在认证主体，可用于 grpc.ReflectionAuthorizer。
+func Authenticated(ctx context.Context) error {
+    if _, ok := FromContext(ctx); !ok {
+        return ErrPermissionDenied
+    }
+    return nil
+}
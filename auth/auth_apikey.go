@@ -0,0 +1,38 @@
+package auth
+
+import (
+    "context"
+    "crypto/subtle"
+)
+
+// APIKeyHeader 默认 API Key 请求头。
+const APIKeyHeader = "x-api-key"
+
+// APIKey 静态 API Key 认证器。
+type APIKey struct {
+    header string
+    keys   map[string]string
+}
+
+// NewAPIKey 新建静态 API Key 认证器，keys 为 API Key 到主体标识的映射，header 默认为 x-api-key。
+func NewAPIKey(keys map[string]string, header ...string) *APIKey {
+    a := &APIKey{header: APIKeyHeader, keys: keys}
+    if len(header) > 0 {
+        a.header = header[0]
+    }
+    return a
+}
+
+// Authenticate 实现 Authenticator 接口。
+func (a *APIKey) Authenticate(_ context.Context, r *Request) (*Principal, error) {
+    key := r.Get(a.header)
+    if key == "" {
+        return nil, ErrNoCredentials
+    }
+    for k, subject := range a.keys {
+        if subtle.ConstantTimeCompare([]byte(k), []byte(key)) == 1 {
+            return &Principal{Subject: subject, Method: "apikey"}, nil
+        }
+    }
+    return nil, ErrInvalidCredentials
+}
@@ -0,0 +1,291 @@
+package auth
+
+import (
+    "context"
+    "crypto"
+    "crypto/hmac"
+    "crypto/rsa"
+    "crypto/sha256"
+    "crypto/sha512"
+    "encoding/base64"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "hash"
+    "math/big"
+    "os"
+    "slices"
+    "strings"
+    "sync"
+    "time"
+)
+
+// JWTOption 定义一个 JWT 认证器选项类型。
+type JWTOption func(a *JWT)
+
+// WithHMACKey 配置 HMAC 密钥，令牌头部的 kid 须与 kid 一致，kid 为空时仅匹配未指定 kid 的令牌。
+func WithHMACKey(kid string, secret []byte) JWTOption {
+    return func(a *JWT) { a.keys = append(a.keys, jwk{kid: kid, hmac: secret}) }
+}
+
+// WithRSAKey 配置 RSA 公钥，令牌头部的 kid 须与 kid 一致，kid 为空时仅匹配未指定 kid 的令牌。
+func WithRSAKey(kid string, key *rsa.PublicKey) JWTOption {
+    return func(a *JWT) { a.keys = append(a.keys, jwk{kid: kid, rsa: key}) }
+}
+
+// WithJWKSFile 配置本地 JWKS 文件，支持 RSA 与 oct 类型密钥，可通过 Reload 重新加载。
+func WithJWKSFile(path string) JWTOption {
+    return func(a *JWT) { a.jwksFile = path }
+}
+
+// WithIssuer 配置允许的签发者。
+func WithIssuer(issuers ...string) JWTOption {
+    return func(a *JWT) { a.issuers = append(a.issuers, issuers...) }
+}
+
+// WithAudience 配置允许的受众，令牌受众与任一值匹配即可。
+func WithAudience(audiences ...string) JWTOption {
+    return func(a *JWT) { a.audiences = append(a.audiences, audiences...) }
+}
+
+// WithLeeway 配置校验 exp 与 nbf 时允许的时钟偏差。
+func WithLeeway(d time.Duration) JWTOption {
+    return func(a *JWT) { a.leeway = d }
+}
+
+// WithTokenHeader 配置令牌请求头，默认为 authorization，值须以 Bearer 开头。
+func WithTokenHeader(header string) JWTOption {
+    return func(a *JWT) { a.header = header }
+}
+
+// jwk 单个验证密钥。
+type jwk struct {
+    kid  string
+    hmac []byte
+    rsa  *rsa.PublicKey
+}
+
+// JWT 支持 HS256/384/512 与 RS256/384/512 的 JWT 认证器。
+//
+// 令牌须携带 exp 声明，nbf、iss 与 aud 声明按配置校验。
+type JWT struct {
+    header    string
+    keys      []jwk
+    jwksFile  string
+    issuers   []string
+    audiences []string
+    leeway    time.Duration
+    now       func() time.Time
+
+    mu       sync.RWMutex
+    jwksKeys []jwk
+}
+
+// NewJWT 新建 JWT 认证器，配置了 JWKS 文件时立即加载。
+func NewJWT(opts ...JWTOption) (*JWT, error) {
+    a := &JWT{
+        header: "authorization",
+        now:    time.Now,
+    }
+    for _, opt := range opts {
+        opt(a)
+    }
+    if a.jwksFile != "" {
+        if err := a.Reload(); err != nil {
+            return nil, err
+        }
+    }
+    return a, nil
+}
+
+// Reload 重新加载 JWKS 文件。
+func (a *JWT) Reload() error {
+    data, err := os.ReadFile(a.jwksFile)
+    if err != nil {
+        return fmt.Errorf("auth: read JWKS %s failed: %w", a.jwksFile, err)
+    }
+    keys, err := parseJWKS(data)
+    if err != nil {
+        return err
+    }
+    a.mu.Lock()
+    a.jwksKeys = keys
+    a.mu.Unlock()
+    return nil
+}
+
+// Authenticate 实现 Authenticator 接口。
+func (a *JWT) Authenticate(_ context.Context, r *Request) (*Principal, error) {
+    token, ok := strings.CutPrefix(r.Get(a.header), "Bearer ")
+    if !ok || token == "" {
+        return nil, ErrNoCredentials
+    }
+    claims, err := a.verify(token)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+    }
+    sub, _ := claims["sub"].(string)
+    return &Principal{Subject: sub, Method: "jwt", Claims: claims}, nil
+}
+
+// verify 校验令牌签名与声明，返回声明集合。
+func (a *JWT) verify(token string) (map[string]any, error) {
+    parts := strings.Split(token, ".")
+    if len(parts) != 3 {
+        return nil, errors.New("malformed token")
+    }
+    var header struct {
+        Alg string `json:"alg"`
+        Kid string `json:"kid"`
+    }
+    if err := decodeSegment(parts[0], &header); err != nil {
+        return nil, err
+    }
+    sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+    if err != nil {
+        return nil, errors.New("malformed signature")
+    }
+    if err = a.verifySignature(header.Alg, header.Kid, parts[0]+"."+parts[1], sig); err != nil {
+        return nil, err
+    }
+    var claims map[string]any
+    if err = decodeSegment(parts[1], &claims); err != nil {
+        return nil, err
+    }
+    return claims, a.validate(claims)
+}
+
+// verifySignature 使用 kid 一致的密钥校验签名。
+func (a *JWT) verifySignature(alg, kid, signed string, sig []byte) error {
+    var ch crypto.Hash
+    switch alg[min(2, len(alg)):] {
+    case "256":
+        ch = crypto.SHA256
+    case "384":
+        ch = crypto.SHA384
+    case "512":
+        ch = crypto.SHA512
+    default:
+        return fmt.Errorf("unsupported algorithm %q", alg)
+    }
+    a.mu.RLock()
+    keys := append(slices.Clone(a.keys), a.jwksKeys...)
+    a.mu.RUnlock()
+    for _, k := range keys {
+        if k.kid != kid {
+            continue
+        }
+        switch {
+        case strings.HasPrefix(alg, "HS") && k.hmac != nil:
+            mac := hmac.New(hashFunc(ch), k.hmac)
+            mac.Write([]byte(signed))
+            if hmac.Equal(sig, mac.Sum(nil)) {
+                return nil
+            }
+        case strings.HasPrefix(alg, "RS") && k.rsa != nil:
+            h := ch.New()
+            h.Write([]byte(signed))
+            if rsa.VerifyPKCS1v15(k.rsa, ch, h.Sum(nil), sig) == nil {
+                return nil
+            }
+        }
+    }
+    return errors.New("signature verification failed")
+}
+
+// validate 校验时间、签发者与受众声明，缺少 exp 声明的令牌视为无效。
+func (a *JWT) validate(claims map[string]any) error {
+    now := a.now()
+    exp, ok := claims["exp"].(float64)
+    if !ok {
+        return errors.New("token has no expiry")
+    }
+    if now.After(time.Unix(int64(exp), 0).Add(a.leeway)) {
+        return errors.New("token is expired")
+    }
+    if nbf, ok := claims["nbf"].(float64); ok && now.Add(a.leeway).Before(time.Unix(int64(nbf), 0)) {
+        return errors.New("token is not valid yet")
+    }
+    if len(a.issuers) > 0 {
+        iss, _ := claims["iss"].(string)
+        if !slices.Contains(a.issuers, iss) {
+            return fmt.Errorf("issuer %q is not allowed", iss)
+        }
+    }
+    if len(a.audiences) > 0 {
+        var auds []string
+        switch v := claims["aud"].(type) {
+        case string:
+            auds = []string{v}
+        case []any:
+            for _, aud := range v {
+                if s, ok := aud.(string); ok {
+                    auds = append(auds, s)
+                }
+            }
+        }
+        if !slices.ContainsFunc(auds, func(aud string) bool { return slices.Contains(a.audiences, aud) }) {
+            return errors.New("audience is not allowed")
+        }
+    }
+    return nil
+}
+
+// hashFunc 返回 HMAC 使用的哈希构造函数。
+func hashFunc(h crypto.Hash) func() hash.Hash {
+    switch h {
+    case crypto.SHA384:
+        return sha512.New384
+    case crypto.SHA512:
+        return sha512.New
+    default:
+        return sha256.New
+    }
+}
+
+// decodeSegment 解码 base64url 编码的 JSON 段。
+func decodeSegment(seg string, v any) error {
+    data, err := base64.RawURLEncoding.DecodeString(seg)
+    if err != nil {
+        return errors.New("malformed token segment")
+    }
+    return json.Unmarshal(data, v)
+}
+
+// parseJWKS 解析 JWKS 文档。
+func parseJWKS(data []byte) ([]jwk, error) {
+    var set struct {
+        Keys []struct {
+            Kty string `json:"kty"`
+            Kid string `json:"kid"`
+            N   string `json:"n"`
+            E   string `json:"e"`
+            K   string `json:"k"`
+        } `json:"keys"`
+    }
+    if err := json.Unmarshal(data, &set); err != nil {
+        return nil, fmt.Errorf("auth: parse JWKS failed: %w", err)
+    }
+    keys := make([]jwk, 0, len(set.Keys))
+    for _, k := range set.Keys {
+        switch k.Kty {
+        case "RSA":
+            n, err1 := base64.RawURLEncoding.DecodeString(k.N)
+            e, err2 := base64.RawURLEncoding.DecodeString(k.E)
+            if err1 != nil || err2 != nil {
+                return nil, fmt.Errorf("auth: invalid RSA key %q in JWKS", k.Kid)
+            }
+            keys = append(keys, jwk{kid: k.Kid, rsa: &rsa.PublicKey{
+                N: new(big.Int).SetBytes(n),
+                E: int(new(big.Int).SetBytes(e).Int64()),
+            }})
+        case "oct":
+            secret, err := base64.RawURLEncoding.DecodeString(k.K)
+            if err != nil {
+                return nil, fmt.Errorf("auth: invalid oct key %q in JWKS", k.Kid)
+            }
+            keys = append(keys, jwk{kid: k.Kid, hmac: secret})
+        }
+    }
+    return keys, nil
+}
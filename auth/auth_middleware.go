@@ -0,0 +1,158 @@
+package auth
+
+import (
+    "context"
+    "errors"
+    "net/http"
+    "slices"
+    "strings"
+
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/credentials"
+    "google.golang.org/grpc/metadata"
+    "google.golang.org/grpc/peer"
+    "google.golang.org/grpc/status"
+
+    "github.com/camry/dove/v2/server/ghttp"
+)
+
+// Option 定义一个认证中间件选项类型。
+type Option func(o *option)
+
+// rule 按操作匹配的访问规则。
+type rule struct {
+    pattern  string
+    subjects []string
+}
+
+// option 认证中间件选项实体对象。
+type option struct {
+    public []string
+    rules  []rule
+}
+
+// Public 配置无需认证的操作（HTTP 路径或 gRPC 完整方法名），以 * 结尾时按前缀匹配。
+//
+// 公开操作仍会尝试认证，认证成功时上下文中同样携带认证主体。
+func Public(patterns ...string) Option {
+    return func(o *option) { o.public = append(o.public, patterns...) }
+}
+
+// Allow 配置操作的主体白名单，认证通过但主体不在白名单中的请求将被拒绝，以 * 结尾时按前缀匹配。
+func Allow(pattern string, subjects ...string) Option {
+    return func(o *option) { o.rules = append(o.rules, rule{pattern: pattern, subjects: subjects}) }
+}
+
+// match 匹配操作名称。
+func match(pattern, operation string) bool {
+    if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+        return strings.HasPrefix(operation, prefix)
+    }
+    return pattern == operation
+}
+
+// authenticate 执行认证与访问规则检查，返回携带认证主体的上下文。
+func (o *option) authenticate(ctx context.Context, a Authenticator, r *Request) (context.Context, error) {
+    isPublic := slices.ContainsFunc(o.public, func(p string) bool { return match(p, r.Operation) })
+    p, err := a.Authenticate(ctx, r)
+    if err != nil {
+        if isPublic {
+            return ctx, nil
+        }
+        return ctx, err
+    }
+    for _, rl := range o.rules {
+        if match(rl.pattern, r.Operation) && !slices.Contains(rl.subjects, p.Subject) {
+            return ctx, ErrPermissionDenied
+        }
+    }
+    return NewContext(ctx, p), nil
+}
+
+// Filter 返回 HTTP 认证过滤器，认证失败响应 401，无权访问响应 403。
+func Filter(a Authenticator, opts ...Option) ghttp.FilterFunc {
+    o := newOption(opts...)
+    return func(next http.Handler) http.Handler {
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            header := make(map[string][]string, len(r.Header))
+            for k, v := range r.Header {
+                header[strings.ToLower(k)] = v
+            }
+            ctx, err := o.authenticate(r.Context(), a, &Request{Operation: r.URL.Path, Header: header, TLS: r.TLS})
+            if err != nil {
+                code := http.StatusUnauthorized
+                if errors.Is(err, ErrPermissionDenied) {
+                    code = http.StatusForbidden
+                }
+                http.Error(w, http.StatusText(code), code)
+                return
+            }
+            next.ServeHTTP(w, r.WithContext(ctx))
+        })
+    }
+}
+
+// UnaryServerInterceptor 返回 gRPC 一元认证拦截器。
+func UnaryServerInterceptor(a Authenticator, opts ...Option) grpc.UnaryServerInterceptor {
+    o := newOption(opts...)
+    return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+        ctx, err := o.authenticate(ctx, a, grpcRequest(ctx, info.FullMethod))
+        if err != nil {
+            return nil, grpcError(err)
+        }
+        return handler(ctx, req)
+    }
+}
+
+// StreamServerInterceptor 返回 gRPC 流认证拦截器。
+func StreamServerInterceptor(a Authenticator, opts ...Option) grpc.StreamServerInterceptor {
+    o := newOption(opts...)
+    return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+        ctx, err := o.authenticate(ss.Context(), a, grpcRequest(ss.Context(), info.FullMethod))
+        if err != nil {
+            return grpcError(err)
+        }
+        return handler(srv, &wrappedStream{ServerStream: ss, ctx: ctx})
+    }
+}
+
+func newOption(opts ...Option) *option {
+    o := &option{}
+    for _, opt := range opts {
+        opt(o)
+    }
+    return o
+}
+
+// grpcRequest 从 gRPC 上下文构建认证请求。
+func grpcRequest(ctx context.Context, fullMethod string) *Request {
+    r := &Request{Operation: fullMethod}
+    if md, ok := metadata.FromIncomingContext(ctx); ok {
+        r.Header = md
+    }
+    if p, ok := peer.FromContext(ctx); ok {
+        if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+            r.TLS = &info.State
+        }
+    }
+    return r
+}
+
+// grpcError 将认证错误转换为 gRPC 状态错误。
+func grpcError(err error) error {
+    if errors.Is(err, ErrPermissionDenied) {
+        return status.Error(codes.PermissionDenied, err.Error())
+    }
+    return status.Error(codes.Unauthenticated, err.Error())
+}
+
+// wrappedStream 重写 gRPC 流上下文。
+type wrappedStream struct {
+    grpc.ServerStream
+    ctx context.Context
+}
+
+func (w *wrappedStream) Context() context.Context {
+    return w.ctx
+}
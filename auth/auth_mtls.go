@@ -0,0 +1,144 @@
+package auth
+
+import (
+    "context"
+    "crypto/tls"
+    "crypto/x509"
+    "slices"
+    "strings"
+)
+
+// MTLSOption 定义一个 mTLS 认证器选项类型。
+type MTLSOption func(a *MTLS)
+
+// AllowDNS 配置允许的 DNS SAN，支持 *.example.com 形式的通配符。
+func AllowDNS(names ...string) MTLSOption {
+    return func(a *MTLS) { a.dns = append(a.dns, names...) }
+}
+
+// AllowURI 配置允许的 URI SAN，如 spiffe://cluster/ns/default/sa/api。
+func AllowURI(uris ...string) MTLSOption {
+    return func(a *MTLS) { a.uris = append(a.uris, uris...) }
+}
+
+// AllowEmail 配置允许的 Email SAN。
+func AllowEmail(emails ...string) MTLSOption {
+    return func(a *MTLS) { a.emails = append(a.emails, emails...) }
+}
+
+// ClientCAs 配置校验客户端证书的 CA 证书池，每次认证时调用 pool 获取最新证书池。
+//
+// 用于 TLS 层未生成已校验证书链的场景，如 tlsutil.MutualTLSConfig 在 VerifyConnection 中校验客户端证书，
+// 此时传入 CAReloader.Pool 即可按同一 CA 证书包重新校验并取得证书链。
+func ClientCAs(pool func() *x509.CertPool) MTLSOption {
+    return func(a *MTLS) { a.pool = pool }
+}
+
+// MTLS 基于已校验客户端证书 SAN 的认证器。
+//
+// 优先使用 TLS 层已校验的证书链（如 tls.RequireAndVerifyClientCert），
+// 没有已校验证书链且配置了 ClientCAs 时按 CA 证书池校验客户端证书，未配置任何 SAN 时接受所有已校验证书。
+type MTLS struct {
+    dns    []string
+    uris   []string
+    emails []string
+    pool   func() *x509.CertPool
+}
+
+// NewMTLS 新建 mTLS 认证器。
+func NewMTLS(opts ...MTLSOption) *MTLS {
+    a := &MTLS{}
+    for _, opt := range opts {
+        opt(a)
+    }
+    return a
+}
+
+// Authenticate 实现 Authenticator 接口，主体标识依次取第一个 URI SAN、DNS SAN 或 CN。
+func (a *MTLS) Authenticate(_ context.Context, r *Request) (*Principal, error) {
+    chains, err := a.verifiedChains(r.TLS)
+    if err != nil {
+        return nil, err
+    }
+    cert := chains[0][0]
+    if !a.allowed(cert) {
+        return nil, ErrPermissionDenied
+    }
+    p := &Principal{
+        Subject: cert.Subject.CommonName,
+        Method:  "mtls",
+        Claims:  map[string]any{"dns": cert.DNSNames, "emails": cert.EmailAddresses},
+    }
+    uris := make([]string, len(cert.URIs))
+    for i, u := range cert.URIs {
+        uris[i] = u.String()
+    }
+    p.Claims["uris"] = uris
+    switch {
+    case len(uris) > 0:
+        p.Subject = uris[0]
+    case len(cert.DNSNames) > 0:
+        p.Subject = cert.DNSNames[0]
+    }
+    return p, nil
+}
+
+// verifiedChains 返回已校验的客户端证书链，TLS 层未校验时按 ClientCAs 校验对端证书。
+func (a *MTLS) verifiedChains(cs *tls.ConnectionState) ([][]*x509.Certificate, error) {
+    if cs == nil {
+        return nil, ErrNoCredentials
+    }
+    if len(cs.VerifiedChains) > 0 && len(cs.VerifiedChains[0]) > 0 {
+        return cs.VerifiedChains, nil
+    }
+    if a.pool == nil || len(cs.PeerCertificates) == 0 {
+        return nil, ErrNoCredentials
+    }
+    opts := x509.VerifyOptions{
+        Roots:         a.pool(),
+        Intermediates: x509.NewCertPool(),
+        KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+    }
+    for _, c := range cs.PeerCertificates[1:] {
+        opts.Intermediates.AddCert(c)
+    }
+    chains, err := cs.PeerCertificates[0].Verify(opts)
+    if err != nil {
+        return nil, ErrInvalidCredentials
+    }
+    return chains, nil
+}
+
+// allowed 判断证书 SAN 是否在允许列表中。
+func (a *MTLS) allowed(cert *x509.Certificate) bool {
+    if len(a.dns) == 0 && len(a.uris) == 0 && len(a.emails) == 0 {
+        return true
+    }
+    for _, u := range cert.URIs {
+        if slices.Contains(a.uris, u.String()) {
+            return true
+        }
+    }
+    for _, e := range cert.EmailAddresses {
+        if slices.Contains(a.emails, e) {
+            return true
+        }
+    }
+    for _, name := range cert.DNSNames {
+        for _, pattern := range a.dns {
+            if matchDNS(pattern, name) {
+                return true
+            }
+        }
+    }
+    return false
+}
+
+// matchDNS 匹配 DNS 名称，*.example.com 仅匹配一级子域名。
+func matchDNS(pattern, name string) bool {
+    if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+        prefix, rest, found := strings.Cut(name, ".")
+        return found && prefix != "" && strings.EqualFold(rest, suffix)
+    }
+    return strings.EqualFold(pattern, name)
+}
@@ -0,0 +1,273 @@
+package auth
+
+import (
+    "context"
+    "crypto"
+    "crypto/ecdsa"
+    "crypto/elliptic"
+    "crypto/hmac"
+    "crypto/rand"
+    "crypto/rsa"
+    "crypto/sha256"
+    "crypto/tls"
+    "crypto/x509"
+    "crypto/x509/pkix"
+    "encoding/base64"
+    "encoding/json"
+    "encoding/pem"
+    "errors"
+    "io"
+    "math/big"
+    "net"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/metadata"
+    "google.golang.org/grpc/status"
+
+    "github.com/camry/dove/v2/tlsutil"
+)
+
+func b64(v []byte) string { return base64.RawURLEncoding.EncodeToString(v) }
+
+// sign 生成测试令牌，key 为 []byte 时使用 HS256，为 *rsa.PrivateKey 时使用 RS256。
+func sign(t *testing.T, kid string, claims map[string]any, key any) string {
+    t.Helper()
+    alg := "HS256"
+    if _, ok := key.(*rsa.PrivateKey); ok {
+        alg = "RS256"
+    }
+    h, _ := json.Marshal(map[string]string{"alg": alg, "typ": "JWT", "kid": kid})
+    c, _ := json.Marshal(claims)
+    signed := b64(h) + "." + b64(c)
+    var sig []byte
+    switch k := key.(type) {
+    case []byte:
+        mac := hmac.New(sha256.New, k)
+        mac.Write([]byte(signed))
+        sig = mac.Sum(nil)
+    case *rsa.PrivateKey:
+        sum := sha256.Sum256([]byte(signed))
+        var err error
+        if sig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, sum[:]); err != nil {
+            t.Fatal(err)
+        }
+    }
+    return signed + "." + b64(sig)
+}
+
+func bearer(token string) *Request {
+    return &Request{Header: map[string][]string{"authorization": {"Bearer " + token}}}
+}
+
+func TestJWT(t *testing.T) {
+    rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+    if err != nil {
+        t.Fatal(err)
+    }
+    jwks, _ := json.Marshal(map[string]any{"keys": []map[string]string{
+        {"kty": "RSA", "kid": "rsa1", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+        {"kty": "oct", "kid": "hs1", "k": b64([]byte("secret"))},
+    }})
+    file := filepath.Join(t.TempDir(), "jwks.json")
+    if err = os.WriteFile(file, jwks, 0o600); err != nil {
+        t.Fatal(err)
+    }
+    a, err := NewJWT(WithJWKSFile(file), WithIssuer("dove"), WithAudience("api"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    exp := float64(time.Now().Add(time.Hour).Unix())
+    claims := map[string]any{"sub": "alice", "iss": "dove", "aud": []string{"api"}, "exp": exp}
+
+    for _, token := range []string{sign(t, "rsa1", claims, rsaKey), sign(t, "hs1", claims, []byte("secret"))} {
+        p, err := a.Authenticate(context.Background(), bearer(token))
+        if err != nil {
+            t.Fatal(err)
+        }
+        if p.Subject != "alice" || p.Method != "jwt" {
+            t.Fatalf("unexpected principal %+v", p)
+        }
+    }
+
+    invalid := map[string]string{
+        "wrong key":    sign(t, "hs1", claims, []byte("other")),
+        "expired":      sign(t, "hs1", map[string]any{"sub": "alice", "iss": "dove", "aud": "api", "exp": float64(time.Now().Add(-time.Hour).Unix())}, []byte("secret")),
+        "wrong issuer": sign(t, "hs1", map[string]any{"sub": "alice", "iss": "other", "aud": "api", "exp": exp}, []byte("secret")),
+        "malformed":    "abc",
+        "no exp":       sign(t, "hs1", map[string]any{"sub": "alice", "iss": "dove", "aud": "api"}, []byte("secret")),
+        "no kid":       sign(t, "", claims, []byte("secret")),
+        "unknown kid":  sign(t, "other", claims, rsaKey),
+    }
+    for name, token := range invalid {
+        if _, err = a.Authenticate(context.Background(), bearer(token)); !errors.Is(err, ErrInvalidCredentials) {
+            t.Errorf("%s: expect ErrInvalidCredentials, got %v", name, err)
+        }
+    }
+    if _, err = a.Authenticate(context.Background(), &Request{}); !errors.Is(err, ErrNoCredentials) {
+        t.Errorf("expect ErrNoCredentials, got %v", err)
+    }
+}
+
+func TestMTLS(t *testing.T) {
+    u, _ := url.Parse("spiffe://cluster/sa/api")
+    cert := &x509.Certificate{URIs: []*url.URL{u}, DNSNames: []string{"api.svc.local"}}
+    r := &Request{TLS: &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}}
+
+    p, err := NewMTLS(AllowDNS("*.svc.local")).Authenticate(context.Background(), r)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if p.Subject != "spiffe://cluster/sa/api" {
+        t.Fatalf("unexpected subject %s", p.Subject)
+    }
+    if _, err = NewMTLS(AllowURI("spiffe://cluster/sa/web")).Authenticate(context.Background(), r); !errors.Is(err, ErrPermissionDenied) {
+        t.Fatalf("expect ErrPermissionDenied, got %v", err)
+    }
+    if _, err = NewMTLS().Authenticate(context.Background(), &Request{}); !errors.Is(err, ErrNoCredentials) {
+        t.Fatalf("expect ErrNoCredentials, got %v", err)
+    }
+}
+
+// writeCert 生成 CA 签发的证书并写入 dir，parent 为 nil 时生成自签名 CA 证书。
+func writeCert(t *testing.T, dir, name string, tpl *x509.Certificate, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+    t.Helper()
+    key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    if err != nil {
+        t.Fatal(err)
+    }
+    tpl.NotBefore, tpl.NotAfter = time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+    if parent == nil {
+        tpl.IsCA, tpl.BasicConstraintsValid, tpl.KeyUsage = true, true, x509.KeyUsageCertSign
+        parent, parentKey = tpl, key
+    }
+    der, err := x509.CreateCertificate(rand.Reader, tpl, parent, &key.PublicKey, parentKey)
+    if err != nil {
+        t.Fatal(err)
+    }
+    keyDER, _ := x509.MarshalECPrivateKey(key)
+    _ = os.WriteFile(filepath.Join(dir, name+".crt"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+    _ = os.WriteFile(filepath.Join(dir, name+".key"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+    cert, _ := x509.ParseCertificate(der)
+    return cert, key
+}
+
+func TestMTLS_MutualTLSConfig(t *testing.T) {
+    dir := t.TempDir()
+    u, _ := url.Parse("spiffe://cluster/sa/api")
+    ca, caKey := writeCert(t, dir, "ca", &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "ca"}}, nil, nil)
+    writeCert(t, dir, "srv", &x509.Certificate{
+        SerialNumber: big.NewInt(2),
+        DNSNames:     []string{"localhost"},
+        ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+    }, ca, caKey)
+    writeCert(t, dir, "cli", &x509.Certificate{
+        SerialNumber: big.NewInt(3),
+        URIs:         []*url.URL{u},
+        ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+    }, ca, caKey)
+    srvCert, err := tlsutil.NewCertReloader(filepath.Join(dir, "srv.crt"), filepath.Join(dir, "srv.key"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    cliCert, err := tlsutil.NewCertReloader(filepath.Join(dir, "cli.crt"), filepath.Join(dir, "cli.key"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    caBundle, err := tlsutil.NewCAReloader(filepath.Join(dir, "ca.crt"))
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    lis, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatal(err)
+    }
+    hs := &http.Server{
+        TLSConfig: tlsutil.MutualTLSConfig(srvCert, caBundle),
+        Handler: Filter(NewMTLS(AllowURI(u.String()), ClientCAs(caBundle.Pool)))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            p, _ := FromContext(r.Context())
+            _, _ = w.Write([]byte(p.Subject))
+        })),
+    }
+    go func() { _ = hs.ServeTLS(lis, "", "") }()
+    defer hs.Close()
+
+    cc := tlsutil.ClientTLSConfig(cliCert, caBundle)
+    cc.ServerName = "localhost"
+    hc := &http.Client{Transport: &http.Transport{TLSClientConfig: cc}}
+    defer hc.CloseIdleConnections()
+    resp, err := hc.Get("https://" + lis.Addr().String())
+    if err != nil {
+        t.Fatal(err)
+    }
+    body, _ := io.ReadAll(resp.Body)
+    _ = resp.Body.Close()
+    if resp.StatusCode != http.StatusOK || string(body) != u.String() {
+        t.Fatalf("expect authenticated as %s, got %d %q", u, resp.StatusCode, body)
+    }
+}
+
+func TestFilter(t *testing.T) {
+    a := Chain(newHMACJWT(t), NewAPIKey(map[string]string{"k1": "svc-a", "k2": "svc-b"}))
+    h := Filter(a, Public("/public/*"), Allow("/admin", "svc-a"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if p, ok := FromContext(r.Context()); ok {
+            _, _ = w.Write([]byte(p.Subject))
+        }
+    }))
+    cases := []struct {
+        path, key string
+        code      int
+    }{
+        {"/public/a", "", http.StatusOK},
+        {"/api", "", http.StatusUnauthorized},
+        {"/api", "bad", http.StatusUnauthorized},
+        {"/api", "k2", http.StatusOK},
+        {"/admin", "k2", http.StatusForbidden},
+        {"/admin", "k1", http.StatusOK},
+    }
+    for _, c := range cases {
+        w := httptest.NewRecorder()
+        r := httptest.NewRequest(http.MethodGet, c.path, nil)
+        if c.key != "" {
+            r.Header.Set("X-Api-Key", c.key)
+        }
+        h.ServeHTTP(w, r)
+        if w.Code != c.code {
+            t.Errorf("%s with key %q: expect %d, got %d", c.path, c.key, c.code, w.Code)
+        }
+    }
+}
+
+// newHMACJWT 返回仅配置 HMAC 密钥的 JWT 认证器。
+func newHMACJWT(t *testing.T) *JWT {
+    a, err := NewJWT(WithHMACKey("", []byte("secret")))
+    if err != nil {
+        t.Fatal(err)
+    }
+    return a
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+    in := UnaryServerInterceptor(NewAPIKey(map[string]string{"k1": "svc-a"}))
+    info := &grpc.UnaryServerInfo{FullMethod: "/pkg.Svc/Call"}
+    handler := func(ctx context.Context, req any) (any, error) {
+        p, _ := FromContext(ctx)
+        return p.Subject, nil
+    }
+    if _, err := in(context.Background(), nil, info, handler); status.Code(err) != codes.Unauthenticated {
+        t.Fatalf("expect Unauthenticated, got %v", err)
+    }
+    ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(APIKeyHeader, "k1"))
+    resp, err := in(ctx, nil, info, handler)
+    if err != nil || resp != "svc-a" {
+        t.Fatalf("expect svc-a, got %v, %v", resp, err)
+    }
+}
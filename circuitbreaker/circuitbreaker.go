@@ -0,0 +1,298 @@
+package circuitbreaker
+
+import (
+    "errors"
+    "sync"
+    "time"
+)
+
+// ErrNotAllowed 熔断器打开时拒绝请求的错误。
+var ErrNotAllowed = errors.New("circuitbreaker: not allowed")
+
+// State 熔断器状态。
+type State int
+
+const (
+    StateClosed   State = iota // StateClosed 关闭状态，请求正常通过。
+    StateOpen                  // StateOpen 打开状态，请求被拒绝。
+    StateHalfOpen              // StateHalfOpen 半开状态，允许少量探测请求。
+)
+
+func (s State) String() string {
+    switch s {
+    case StateClosed:
+        return "closed"
+    case StateOpen:
+        return "open"
+    case StateHalfOpen:
+        return "half-open"
+    default:
+        return ""
+    }
+}
+
+// Option 定义一个熔断器选项类型。
+type Option func(o *option)
+
+// option 熔断器选项实体对象。
+type option struct {
+    window        time.Duration
+    buckets       int
+    minRequests   int
+    failureRatio  float64
+    openTimeout   time.Duration
+    halfOpenMax   int
+    onStateChange func(name string, from, to State)
+}
+
+// Window 配置统计窗口时长与分桶数。
+func Window(d time.Duration, buckets int) Option {
+    return func(o *option) { o.window, o.buckets = d, buckets }
+}
+
+// MinRequests 配置窗口内触发熔断的最小请求数。
+func MinRequests(n int) Option {
+    return func(o *option) { o.minRequests = n }
+}
+
+// FailureRatio 配置触发熔断的失败率（0~1）。
+func FailureRatio(r float64) Option {
+    return func(o *option) { o.failureRatio = r }
+}
+
+// OpenTimeout 配置打开状态持续时间，超时后进入半开状态。
+func OpenTimeout(d time.Duration) Option {
+    return func(o *option) { o.openTimeout = d }
+}
+
+// HalfOpenRequests 配置半开状态允许的探测请求数，全部成功后关闭熔断器。
+func HalfOpenRequests(n int) Option {
+    return func(o *option) { o.halfOpenMax = n }
+}
+
+// OnStateChange 配置状态变更回调。
+func OnStateChange(fn func(name string, from, to State)) Option {
+    return func(o *option) { o.onStateChange = fn }
+}
+
+func newOption(opts ...Option) option {
+    o := option{
+        window:       10 * time.Second,
+        buckets:      10,
+        minRequests:  20,
+        failureRatio: 0.5,
+        openTimeout:  5 * time.Second,
+        halfOpenMax:  1,
+    }
+    for _, opt := range opts {
+        opt(&o)
+    }
+    if o.buckets <= 0 {
+        o.buckets = 1
+    }
+    return o
+}
+
+// bucket 单个时间桶的统计。
+type bucket struct {
+    start    time.Time
+    success  int
+    failures int
+}
+
+// Breaker 基于滑动窗口失败率的熔断器。
+type Breaker struct {
+    name string
+    opt  option
+
+    mu       sync.Mutex
+    state    State
+    buckets  []bucket
+    openedAt time.Time
+    probeAt  time.Time // 半开状态本轮探测开始时间。
+    probes   int       // 半开状态已放行的探测请求数。
+    passed   int       // 半开状态已成功的探测请求数。
+}
+
+// New 新建熔断器。
+func New(name string, opts ...Option) *Breaker {
+    o := newOption(opts...)
+    return &Breaker{
+        name:    name,
+        opt:     o,
+        buckets: make([]bucket, o.buckets),
+    }
+}
+
+// Name 返回熔断器名称。
+func (b *Breaker) Name() string { return b.name }
+
+// State 返回熔断器当前状态。
+func (b *Breaker) State() State {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    b.refresh(time.Now())
+    return b.state
+}
+
+// Allow 判断请求是否允许通过，不允许时返回 ErrNotAllowed。
+//
+// 允许通过的请求必须在完成后调用 MarkSuccess 或 MarkFailed；
+// 半开状态的探测请求超过 OpenTimeout 仍未标记结果时视为丢失，重新放行探测请求。
+func (b *Breaker) Allow() error {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    b.refresh(time.Now())
+    switch b.state {
+    case StateOpen:
+        return ErrNotAllowed
+    case StateHalfOpen:
+        if b.probes >= b.opt.halfOpenMax {
+            return ErrNotAllowed
+        }
+        b.probes++
+    }
+    return nil
+}
+
+// MarkSuccess 记录一次成功请求。
+func (b *Breaker) MarkSuccess() {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    now := time.Now()
+    switch b.state {
+    case StateHalfOpen:
+        b.passed++
+        if b.passed >= b.opt.halfOpenMax {
+            b.setState(StateClosed, now)
+        }
+    case StateClosed:
+        b.current(now).success++
+    }
+}
+
+// MarkFailed 记录一次失败请求。
+func (b *Breaker) MarkFailed() {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    now := time.Now()
+    switch b.state {
+    case StateHalfOpen:
+        b.setState(StateOpen, now)
+    case StateClosed:
+        b.current(now).failures++
+        var total, failures int
+        for _, bk := range b.buckets {
+            if now.Sub(bk.start) < b.opt.window {
+                total += bk.success + bk.failures
+                failures += bk.failures
+            }
+        }
+        if total >= b.opt.minRequests && float64(failures) >= b.opt.failureRatio*float64(total) {
+            b.setState(StateOpen, now)
+        }
+    }
+}
+
+// Do 在熔断器保护下执行 fn，fn 返回非 nil 错误或 panic 时记为失败，panic 会继续向上抛出。
+func (b *Breaker) Do(fn func() error) error {
+    if err := b.Allow(); err != nil {
+        return err
+    }
+    defer b.markPanic()
+    if err := fn(); err != nil {
+        b.MarkFailed()
+        return err
+    }
+    b.MarkSuccess()
+    return nil
+}
+
+// markPanic 在 panic 时记录失败并继续抛出，需直接通过 defer 调用。
+func (b *Breaker) markPanic() {
+    if r := recover(); r != nil {
+        b.MarkFailed()
+        panic(r)
+    }
+}
+
+// current 返回当前时间所在的时间桶。
+func (b *Breaker) current(now time.Time) *bucket {
+    size := b.opt.window / time.Duration(len(b.buckets))
+    if size <= 0 {
+        size = 1
+    }
+    start := now.Truncate(size)
+    bk := &b.buckets[int(start.UnixNano()/int64(size))%len(b.buckets)]
+    if !bk.start.Equal(start) {
+        *bk = bucket{start: start}
+    }
+    return bk
+}
+
+// refresh 打开状态超时后切换为半开状态，半开状态探测超时后重新放行探测请求。
+func (b *Breaker) refresh(now time.Time) {
+    switch b.state {
+    case StateOpen:
+        if now.Sub(b.openedAt) >= b.opt.openTimeout {
+            b.setState(StateHalfOpen, now)
+        }
+    case StateHalfOpen:
+        if b.probes >= b.opt.halfOpenMax && now.Sub(b.probeAt) >= b.opt.openTimeout {
+            b.probes, b.passed, b.probeAt = 0, 0, now
+        }
+    }
+}
+
+// setState 切换状态并重置统计。
+func (b *Breaker) setState(to State, now time.Time) {
+    from := b.state
+    if from == to {
+        return
+    }
+    b.state = to
+    b.probes, b.passed = 0, 0
+    switch to {
+    case StateOpen:
+        b.openedAt = now
+    case StateHalfOpen:
+        b.probeAt = now
+    case StateClosed:
+        clear(b.buckets)
+    }
+    if b.opt.onStateChange != nil {
+        b.opt.onStateChange(b.name, from, to)
+    }
+}
+
+// Group 按名称管理一组熔断器，通常每个下游服务或方法一个。
+type Group struct {
+    opts     []Option
+    mu       sync.RWMutex
+    breakers map[string]*Breaker
+}
+
+// NewGroup 新建熔断器组，组内熔断器使用相同的选项。
+func NewGroup(opts ...Option) *Group {
+    return &Group{
+        opts:     opts,
+        breakers: make(map[string]*Breaker),
+    }
+}
+
+// Get 返回名称为 name 的熔断器，不存在时创建。
+func (g *Group) Get(name string) *Breaker {
+    g.mu.RLock()
+    b, ok := g.breakers[name]
+    g.mu.RUnlock()
+    if ok {
+        return b
+    }
+    g.mu.Lock()
+    defer g.mu.Unlock()
+    if b, ok = g.breakers[name]; !ok {
+        b = New(name, g.opts...)
+        g.breakers[name] = b
+    }
+    return b
+}
@@ -0,0 +1,153 @@
+package circuitbreaker
+
+import (
+    "bufio"
+    "context"
+    "errors"
+    "net"
+    "net/http"
+
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/status"
+
+    "github.com/camry/dove/v2/server/ghttp"
+)
+
+// FilterOption 定义一个 HTTP 熔断过滤器选项类型。
+type FilterOption func(o *filterOption)
+
+// filterOption HTTP 熔断过滤器选项实体对象。
+type filterOption struct {
+    key func(r *http.Request) string
+}
+
+// FilterKey 配置熔断器名称的计算函数，返回值的取值范围应有限，如路由模板或下游服务名，
+// 不应直接使用客户端可控的请求路径，否则熔断器数量将随请求无限增长。
+func FilterKey(fn func(r *http.Request) string) FilterOption {
+    return func(o *filterOption) { o.key = fn }
+}
+
+// routeKey 默认熔断器名称，使用 http.ServeMux 匹配的路由模板，未经路由匹配时所有请求共用一个熔断器。
+func routeKey(r *http.Request) string {
+    if r.Pattern != "" {
+        return r.Pattern
+    }
+    return "*"
+}
+
+// Filter 返回按路由熔断的 HTTP 过滤器，熔断时响应 503 Service Unavailable。
+//
+// 默认按 http.ServeMux 匹配的路由模板（Request.Pattern）区分熔断器，作为路由级处理器包装时每个路由一个熔断器，
+// 作为 ghttp.Filter 全局过滤器时路由尚未匹配，所有请求共用一个熔断器，可通过 FilterKey 自定义。
+// 响应状态码大于等于 500 或处理器 panic 时记为失败。
+func Filter(g *Group, opts ...FilterOption) ghttp.FilterFunc {
+    o := filterOption{key: routeKey}
+    for _, opt := range opts {
+        opt(&o)
+    }
+    return func(next http.Handler) http.Handler {
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            b := g.Get(o.key(r))
+            if err := b.Allow(); err != nil {
+                http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+                return
+            }
+            defer b.markPanic()
+            rw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+            next.ServeHTTP(rw, r)
+            if rw.status >= http.StatusInternalServerError {
+                b.MarkFailed()
+            } else {
+                b.MarkSuccess()
+            }
+        })
+    }
+}
+
+// statusWriter 记录响应状态码。
+type statusWriter struct {
+    http.ResponseWriter
+    status      int
+    wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+    if !w.wroteHeader {
+        w.status = code
+        w.wroteHeader = true
+    }
+    w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+    w.wroteHeader = true
+    return w.ResponseWriter.Write(b)
+}
+
+// Flush 实现 http.Flusher。
+func (w *statusWriter) Flush() {
+    if f, ok := w.ResponseWriter.(http.Flusher); ok {
+        f.Flush()
+    }
+}
+
+// Hijack 实现 http.Hijacker，供 WebSocket 等协议升级使用。
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+    if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+        return h.Hijack()
+    }
+    return nil, nil, errors.New("circuitbreaker: response writer does not implement http.Hijacker")
+}
+
+// Unwrap 供 http.ResponseController 使用。
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+    return w.ResponseWriter
+}
+
+// IsFailure 判断 gRPC 错误是否计为熔断失败，仅服务端故障类错误计入。
+func IsFailure(err error) bool {
+    switch status.Code(err) {
+    case codes.Unknown, codes.DeadlineExceeded, codes.ResourceExhausted,
+        codes.Internal, codes.Unavailable, codes.DataLoss:
+        return true
+    default:
+        return false
+    }
+}
+
+// UnaryServerInterceptor 返回按方法熔断的 gRPC 一元拦截器，熔断时返回 codes.Unavailable。
+func UnaryServerInterceptor(g *Group) grpc.UnaryServerInterceptor {
+    return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+        b := g.Get(info.FullMethod)
+        if err := b.Allow(); err != nil {
+            return nil, status.Error(codes.Unavailable, err.Error())
+        }
+        defer b.markPanic()
+        resp, err := handler(ctx, req)
+        if IsFailure(err) {
+            b.MarkFailed()
+        } else {
+            b.MarkSuccess()
+        }
+        return resp, err
+    }
+}
+
+// UnaryClientInterceptor 返回按方法熔断的 gRPC 一元客户端拦截器，熔断时返回 codes.Unavailable。
+func UnaryClientInterceptor(g *Group) grpc.UnaryClientInterceptor {
+    return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+        b := g.Get(method)
+        if err := b.Allow(); err != nil {
+            return status.Error(codes.Unavailable, err.Error())
+        }
+        defer b.markPanic()
+        err := invoker(ctx, method, req, reply, cc, opts...)
+        if IsFailure(err) {
+            b.MarkFailed()
+        } else {
+            b.MarkSuccess()
+        }
+        return err
+    }
+}
@@ -0,0 +1,130 @@
+package circuitbreaker
+
+import (
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+)
+
+func TestBreaker(t *testing.T) {
+    var changes []State
+    b := New("test",
+        MinRequests(4),
+        FailureRatio(0.5),
+        OpenTimeout(50*time.Millisecond),
+        OnStateChange(func(_ string, _, to State) { changes = append(changes, to) }),
+    )
+    fail := errors.New("fail")
+    for i := 0; i < 2; i++ {
+        _ = b.Do(func() error { return nil })
+        _ = b.Do(func() error { return fail })
+    }
+    if b.State() != StateOpen {
+        t.Fatalf("expect open, got %s", b.State())
+    }
+    if err := b.Do(func() error { return nil }); !errors.Is(err, ErrNotAllowed) {
+        t.Fatalf("expect ErrNotAllowed, got %v", err)
+    }
+    time.Sleep(60 * time.Millisecond)
+    if b.State() != StateHalfOpen {
+        t.Fatalf("expect half-open, got %s", b.State())
+    }
+    if err := b.Allow(); err != nil {
+        t.Fatal(err)
+    }
+    if err := b.Allow(); !errors.Is(err, ErrNotAllowed) {
+        t.Fatal("expect only one probe in half-open state")
+    }
+    b.MarkSuccess()
+    if b.State() != StateClosed {
+        t.Fatalf("expect closed, got %s", b.State())
+    }
+    want := []State{StateOpen, StateHalfOpen, StateClosed}
+    if len(changes) != len(want) {
+        t.Fatalf("expect %v, got %v", want, changes)
+    }
+    for i := range want {
+        if changes[i] != want[i] {
+            t.Fatalf("expect %v, got %v", want, changes)
+        }
+    }
+}
+
+func TestBreaker_LostProbe(t *testing.T) {
+    b := New("test", MinRequests(1), OpenTimeout(50*time.Millisecond))
+    b.MarkFailed()
+    time.Sleep(60 * time.Millisecond)
+    // 探测请求未标记结果。
+    if err := b.Allow(); err != nil {
+        t.Fatal(err)
+    }
+    if err := b.Allow(); !errors.Is(err, ErrNotAllowed) {
+        t.Fatal("expect probe in flight")
+    }
+    time.Sleep(60 * time.Millisecond)
+    // 探测超时后重新放行，panic 的探测请求记为失败并重新打开。
+    func() {
+        defer func() { _ = recover() }()
+        if err := b.Do(func() error { panic("boom") }); err != nil {
+            t.Errorf("expect probe allowed after open timeout, got %v", err)
+        }
+    }()
+    if b.State() != StateOpen {
+        t.Fatalf("expect open after panicked probe, got %s", b.State())
+    }
+}
+
+func TestGroup(t *testing.T) {
+    g := NewGroup(MinRequests(1))
+    if g.Get("a") != g.Get("a") {
+        t.Fatal("expect same breaker for same name")
+    }
+    if g.Get("a") == g.Get("b") {
+        t.Fatal("expect different breakers for different names")
+    }
+}
+
+func TestFilter_Hijack(t *testing.T) {
+    h := Filter(NewGroup())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        hj, ok := w.(http.Hijacker)
+        if !ok {
+            t.Error("expect http.Hijacker")
+            return
+        }
+        conn, rw, err := hj.Hijack()
+        if err != nil {
+            t.Error(err)
+            return
+        }
+        defer conn.Close()
+        _, _ = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n\r\n")
+        _ = rw.Flush()
+    }))
+    srv := httptest.NewServer(h)
+    defer srv.Close()
+    resp, err := http.Get(srv.URL)
+    if err != nil {
+        t.Fatal(err)
+    }
+    _ = resp.Body.Close()
+    if resp.StatusCode != http.StatusSwitchingProtocols {
+        t.Fatalf("expect 101, got %d", resp.StatusCode)
+    }
+}
+
+func TestFilter_Key(t *testing.T) {
+    g := NewGroup()
+    mux := http.NewServeMux()
+    mux.Handle("GET /users/{id}", Filter(g)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+    global := Filter(g)(mux)
+    for _, path := range []string{"/users/1", "/users/2", "/a", "/b"} {
+        global.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+    }
+    g.mu.RLock()
+    defer g.mu.RUnlock()
+    if len(g.breakers) != 2 || g.breakers["*"] == nil || g.breakers["GET /users/{id}"] == nil {
+        t.Fatalf("expect breakers keyed by route, got %v", g.breakers)
+    }
+}
@@ -0,0 +1,142 @@
+package errors
+
+import (
+    "errors"
+    "fmt"
+    "maps"
+    "net/http"
+
+    "google.golang.org/genproto/googleapis/rpc/errdetails"
+    "google.golang.org/grpc/status"
+)
+
+const (
+    // UnknownCode 未知错误的状态码。
+    UnknownCode = http.StatusInternalServerError
+    // UnknownReason 未知错误的原因。
+    UnknownReason = ""
+    // ClientClosed 客户端关闭请求的状态码。
+    ClientClosed = 499
+)
+
+// Error 结构化错误，可在 gRPC 状态与 HTTP 响应之间相互转换。
+type Error struct {
+    Code     int               `json:"code"`               // HTTP 状态码。
+    Reason   string            `json:"reason"`             // 错误原因，通常为大写下划线形式的业务错误码。
+    Message  string            `json:"message"`            // 错误信息。
+    Metadata map[string]string `json:"metadata,omitempty"` // 错误元数据。
+    cause    error
+}
+
+// New 新建结构化错误。
+func New(code int, reason, message string) *Error {
+    return &Error{Code: code, Reason: reason, Message: message}
+}
+
+// Newf 按 fmt.Sprintf 格式新建结构化错误。
+func Newf(code int, reason, format string, a ...any) *Error {
+    return New(code, reason, fmt.Sprintf(format, a...))
+}
+
+func (e *Error) Error() string {
+    if e.cause == nil {
+        return fmt.Sprintf("error: code = %d reason = %s message = %s metadata = %v", e.Code, e.Reason, e.Message, e.Metadata)
+    }
+    return fmt.Sprintf("error: code = %d reason = %s message = %s metadata = %v cause = %v", e.Code, e.Reason, e.Message, e.Metadata, e.cause)
+}
+
+// Unwrap 返回底层错误。
+func (e *Error) Unwrap() error {
+    return e.cause
+}
+
+// Is 按状态码与原因匹配错误。
+func (e *Error) Is(err error) bool {
+    var se *Error
+    if errors.As(err, &se) {
+        return se.Code == e.Code && se.Reason == e.Reason
+    }
+    return false
+}
+
+// WithCause 返回携带底层错误的副本。
+func (e *Error) WithCause(cause error) *Error {
+    err := e.clone()
+    err.cause = cause
+    return err
+}
+
+// WithMetadata 返回携带元数据的副本。
+func (e *Error) WithMetadata(md map[string]string) *Error {
+    err := e.clone()
+    err.Metadata = md
+    return err
+}
+
+// GRPCStatus 返回携带 errdetails.ErrorInfo 的 gRPC 状态。
+//
+// gRPC 服务端处理器直接返回 *Error 时将自动通过该方法转换。
+func (e *Error) GRPCStatus() *status.Status {
+    code := ToGRPCCode(e.Code)
+    // 由 gRPC 状态转换而来且状态码未变时沿用原 gRPC 状态码，避免 AlreadyExists 等多对一映射丢失。
+    if gs, ok := status.FromError(e.cause); ok && FromGRPCCode(gs.Code()) == e.Code {
+        code = gs.Code()
+    }
+    st := status.New(code, e.Message)
+    if ds, err := st.WithDetails(&errdetails.ErrorInfo{Reason: e.Reason, Metadata: e.Metadata}); err == nil {
+        return ds
+    }
+    return st
+}
+
+func (e *Error) clone() *Error {
+    return &Error{
+        Code:     e.Code,
+        Reason:   e.Reason,
+        Message:  e.Message,
+        Metadata: maps.Clone(e.Metadata),
+        cause:    e.cause,
+    }
+}
+
+// Code 返回错误的状态码，nil 返回 200。
+func Code(err error) int {
+    if err == nil {
+        return http.StatusOK
+    }
+    return FromError(err).Code
+}
+
+// Reason 返回错误的原因。
+func Reason(err error) string {
+    if err == nil {
+        return UnknownReason
+    }
+    return FromError(err).Reason
+}
+
+// FromError 将任意错误转换为 *Error。
+//
+// gRPC 状态错误按状态码映射并读取 errdetails.ErrorInfo，其他错误转换为未知错误，nil 返回 nil。
+func FromError(err error) *Error {
+    if err == nil {
+        return nil
+    }
+    var se *Error
+    if errors.As(err, &se) {
+        return se
+    }
+    gs, ok := status.FromError(err)
+    if !ok {
+        return New(UnknownCode, UnknownReason, err.Error()).WithCause(err)
+    }
+    ret := New(FromGRPCCode(gs.Code()), UnknownReason, gs.Message())
+    for _, detail := range gs.Details() {
+        if info, ok := detail.(*errdetails.ErrorInfo); ok {
+            ret.Reason = info.Reason
+            ret.Metadata = info.Metadata
+            break
+        }
+    }
+    return ret.WithCause(err)
+}
@@ -0,0 +1,166 @@
+package errors
+
+import (
+    "net/http"
+
+    "google.golang.org/grpc/codes"
+)
+
+// ToGRPCCode 将 HTTP 状态码转换为 gRPC 状态码。
+//
+// 映射是有损的：多个 gRPC 状态码对应同一 HTTP 状态码时仅还原为其中一个，
+// 如 409 还原为 Aborted，500 还原为 Internal，未列出的状态码还原为 Unknown。
+func ToGRPCCode(code int) codes.Code {
+    switch code {
+    case http.StatusOK:
+        return codes.OK
+    case http.StatusBadRequest:
+        return codes.InvalidArgument
+    case http.StatusUnauthorized:
+        return codes.Unauthenticated
+    case http.StatusForbidden:
+        return codes.PermissionDenied
+    case http.StatusNotFound:
+        return codes.NotFound
+    case http.StatusConflict:
+        return codes.Aborted
+    case http.StatusTooManyRequests:
+        return codes.ResourceExhausted
+    case http.StatusInternalServerError:
+        return codes.Internal
+    case http.StatusNotImplemented:
+        return codes.Unimplemented
+    case http.StatusServiceUnavailable:
+        return codes.Unavailable
+    case http.StatusGatewayTimeout:
+        return codes.DeadlineExceeded
+    case ClientClosed:
+        return codes.Canceled
+    }
+    return codes.Unknown
+}
+
+// FromGRPCCode 将 gRPC 状态码转换为 HTTP 状态码。
+//
+// 映射是有损的：AlreadyExists 与 Aborted 均为 409，Unknown、Internal 与 DataLoss 均为 500，
+// FailedPrecondition、OutOfRange 与 InvalidArgument 均为 400，经 ToGRPCCode 无法还原原状态码。
+// FromError 转换的 *Error 保留原 gRPC 状态作为底层错误，GRPCStatus 会优先沿用原状态码。
+func FromGRPCCode(code codes.Code) int {
+    switch code {
+    case codes.OK:
+        return http.StatusOK
+    case codes.Canceled:
+        return ClientClosed
+    case codes.Unknown:
+        return http.StatusInternalServerError
+    case codes.InvalidArgument:
+        return http.StatusBadRequest
+    case codes.DeadlineExceeded:
+        return http.StatusGatewayTimeout
+    case codes.NotFound:
+        return http.StatusNotFound
+    case codes.AlreadyExists:
+        return http.StatusConflict
+    case codes.PermissionDenied:
+        return http.StatusForbidden
+    case codes.Unauthenticated:
+        return http.StatusUnauthorized
+    case codes.ResourceExhausted:
+        return http.StatusTooManyRequests
+    case codes.FailedPrecondition:
+        return http.StatusBadRequest
+    case codes.Aborted:
+        return http.StatusConflict
+    case codes.OutOfRange:
+        return http.StatusBadRequest
+    case codes.Unimplemented:
+        return http.StatusNotImplemented
+    case codes.Internal:
+        return http.StatusInternalServerError
+    case codes.Unavailable:
+        return http.StatusServiceUnavailable
+    case codes.DataLoss:
+        return http.StatusInternalServerError
+    }
+    return http.StatusInternalServerError
+}
+
+// BadRequest 新建 400 错误。
+func BadRequest(reason, message string) *Error {
+    return New(http.StatusBadRequest, reason, message)
+}
+
+// IsBadRequest 判断是否为 400 错误。
+func IsBadRequest(err error) bool {
+    return Code(err) == http.StatusBadRequest
+}
+
+// Unauthorized 新建 401 错误。
+func Unauthorized(reason, message string) *Error {
+    return New(http.StatusUnauthorized, reason, message)
+}
+
+// IsUnauthorized 判断是否为 401 错误。
+func IsUnauthorized(err error) bool {
+    return Code(err) == http.StatusUnauthorized
+}
+
+// Forbidden 新建 403 错误。
+func Forbidden(reason, message string) *Error {
+    return New(http.StatusForbidden, reason, message)
+}
+
+// IsForbidden 判断是否为 403 错误。
+func IsForbidden(err error) bool {
+    return Code(err) == http.StatusForbidden
+}
+
+// NotFound 新建 404 错误。
+func NotFound(reason, message string) *Error {
+    return New(http.StatusNotFound, reason, message)
+}
+
+// IsNotFound 判断是否为 404 错误。
+func IsNotFound(err error) bool {
+    return Code(err) == http.StatusNotFound
+}
+
+// Conflict 新建 409 错误。
+func Conflict(reason, message string) *Error {
+    return New(http.StatusConflict, reason, message)
+}
+
+// IsConflict 判断是否为 409 错误。
+func IsConflict(err error) bool {
+    return Code(err) == http.StatusConflict
+}
+
+// InternalServer 新建 500 错误。
+func InternalServer(reason, message string) *Error {
+    return New(http.StatusInternalServerError, reason, message)
+}
+
+// IsInternalServer 判断是否为 500 错误。
+func IsInternalServer(err error) bool {
+    return Code(err) == http.StatusInternalServerError
+}
+
+// ServiceUnavailable 新建 503 错误。
+func ServiceUnavailable(reason, message string) *Error {
+    return New(http.StatusServiceUnavailable, reason, message)
+}
+
+// IsServiceUnavailable 判断是否为 503 错误。
+func IsServiceUnavailable(err error) bool {
+    return Code(err) == http.StatusServiceUnavailable
+}
+
+// GatewayTimeout 新建 504 错误。
+func GatewayTimeout(reason, message string) *Error {
+    return New(http.StatusGatewayTimeout, reason, message)
+}
+
+// IsGatewayTimeout 判断是否为 504 错误。
+func IsGatewayTimeout(err error) bool {
+    return Code(err) == http.StatusGatewayTimeout
+}
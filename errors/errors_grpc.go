@@ -0,0 +1,65 @@
+package errors
+
+import (
+    "context"
+    "fmt"
+
+    "github.com/camry/g/v2/glog"
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/status"
+)
+
+// toStatus 将错误转换为携带 errdetails.ErrorInfo 的 gRPC 状态错误，已是状态错误时原样返回。
+func toStatus(err error) error {
+    if err == nil {
+        return nil
+    }
+    if _, ok := err.(interface{ GRPCStatus() *status.Status }); ok {
+        return err
+    }
+    return FromError(err).GRPCStatus().Err()
+}
+
+// recoverError 将 panic 的值转换为错误。
+func recoverError(method string, rec any) error {
+    glog.Errorf("[gRPC] %s panic: %v", method, rec)
+    if err, ok := rec.(error); ok {
+        return toStatus(err)
+    }
+    return InternalServer(UnknownReason, fmt.Sprint(rec)).GRPCStatus().Err()
+}
+
+// UnaryServerInterceptor 返回 gRPC 一元错误拦截器，将处理器返回的错误与 panic 转换为结构化状态错误。
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+    return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+        defer func() {
+            if rec := recover(); rec != nil {
+                err = recoverError(info.FullMethod, rec)
+            }
+        }()
+        resp, err = handler(ctx, req)
+        return resp, toStatus(err)
+    }
+}
+
+// StreamServerInterceptor 返回 gRPC 流错误拦截器，将处理器返回的错误与 panic 转换为结构化状态错误。
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+    return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+        defer func() {
+            if rec := recover(); rec != nil {
+                err = recoverError(info.FullMethod, rec)
+            }
+        }()
+        return toStatus(handler(srv, ss))
+    }
+}
+
+// UnaryClientInterceptor 返回 gRPC 客户端一元错误拦截器，将调用返回的状态错误转换为 *Error。
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+    return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+        if err := invoker(ctx, method, req, reply, cc, opts...); err != nil {
+            return FromError(err)
+        }
+        return nil
+    }
+}
@@ -0,0 +1,78 @@
+package errors
+
+import (
+    "encoding/json"
+    "fmt"
+    "io"
+    "net/http"
+
+    "github.com/camry/g/v2/glog"
+
+    "github.com/camry/dove/v2/server/ghttp"
+)
+
+// HandlerFunc 返回错误的 HTTP 处理函数，返回的错误以 JSON 响应。
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+func (f HandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    if err := f(w, r); err != nil {
+        WriteHTTP(w, err)
+    }
+}
+
+// WriteHTTP 将错误转换为 *Error 并以 JSON 响应，响应状态码为错误状态码。
+func WriteHTTP(w http.ResponseWriter, err error) {
+    se := FromError(err)
+    if se == nil {
+        return
+    }
+    code := se.Code
+    if code < 100 || code > 599 {
+        code = UnknownCode
+    }
+    w.Header().Set("Content-Type", "application/json; charset=utf-8")
+    w.WriteHeader(code)
+    _ = json.NewEncoder(w).Encode(se)
+}
+
+// maxResponseSize 解码错误响应时读取的最大响应体字节数。
+const maxResponseSize = 64 << 10
+
+// FromResponse 将非 2xx 的 HTTP 响应解码为 *Error，2xx 响应返回 nil。
+//
+// 响应体不是结构化错误时使用响应状态码与响应体构造错误，最多读取 64KB 响应体。
+func FromResponse(resp *http.Response) *Error {
+    if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+        return nil
+    }
+    body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+    se := &Error{}
+    if err := json.Unmarshal(body, se); err == nil && se.Code != 0 {
+        return se
+    }
+    return New(resp.StatusCode, UnknownReason, string(body))
+}
+
+// Filter 返回 HTTP 错误过滤器，处理器 panic 时恢复并以结构化错误响应。
+//
+// panic 的值为 error 时按 FromError 转换，其他值响应 500。
+func Filter() ghttp.FilterFunc {
+    return func(next http.Handler) http.Handler {
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            defer func() {
+                if rec := recover(); rec != nil {
+                    if rec == http.ErrAbortHandler {
+                        panic(rec)
+                    }
+                    err, ok := rec.(error)
+                    if !ok {
+                        err = InternalServer(UnknownReason, fmt.Sprint(rec))
+                    }
+                    glog.Errorf("[HTTP] %s %s panic: %v", r.Method, r.URL.Path, rec)
+                    WriteHTTP(w, err)
+                }
+            }()
+            next.ServeHTTP(w, r)
+        })
+    }
+}
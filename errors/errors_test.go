@@ -0,0 +1,91 @@
+package errors
+
+import (
+    "context"
+    "errors"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/status"
+)
+
+func TestGRPCStatus(t *testing.T) {
+    e := NotFound("USER_NOT_FOUND", "user 1 not found").WithMetadata(map[string]string{"id": "1"})
+    gs, ok := status.FromError(e)
+    if !ok || gs.Code() != codes.NotFound || gs.Message() != "user 1 not found" {
+        t.Fatalf("unexpected status %v", gs)
+    }
+    se := FromError(gs.Err())
+    if se.Code != http.StatusNotFound || se.Reason != "USER_NOT_FOUND" || se.Metadata["id"] != "1" {
+        t.Fatalf("unexpected error %v", se)
+    }
+    if !errors.Is(se, NotFound("USER_NOT_FOUND", "")) || errors.Is(se, NotFound("OTHER", "")) {
+        t.Fatal("expect match by code and reason")
+    }
+    if FromError(status.Error(codes.Unavailable, "down")).Code != http.StatusServiceUnavailable {
+        t.Fatal("expect 503 for codes.Unavailable")
+    }
+    if Code(errors.New("boom")) != UnknownCode || Code(nil) != http.StatusOK {
+        t.Fatal("unexpected code for plain error")
+    }
+    for _, code := range []codes.Code{codes.AlreadyExists, codes.DataLoss, codes.FailedPrecondition} {
+        if got := status.Code(FromError(status.Error(code, "x"))); got != code {
+            t.Fatalf("expect %v round trip, got %v", code, got)
+        }
+    }
+    if msg := NotFound("USER_NOT_FOUND", "x").Error(); strings.Contains(msg, "cause") {
+        t.Fatalf("expect no cause in %q", msg)
+    }
+}
+
+func TestFromResponse_Limit(t *testing.T) {
+    resp := &http.Response{
+        StatusCode: http.StatusBadGateway,
+        Body:       io.NopCloser(strings.NewReader(strings.Repeat("x", maxResponseSize+1))),
+    }
+    if se := FromResponse(resp); se.Code != http.StatusBadGateway || len(se.Message) != maxResponseSize {
+        t.Fatalf("expect body truncated to %d bytes, got %d", maxResponseSize, len(se.Message))
+    }
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+    in := UnaryServerInterceptor()
+    info := &grpc.UnaryServerInfo{FullMethod: "/pkg.Svc/Get"}
+    _, err := in(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+        return nil, errors.New("boom")
+    })
+    if status.Code(err) != codes.Internal {
+        t.Fatalf("expect codes.Internal, got %v", err)
+    }
+    _, err = in(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+        panic(Forbidden("NO_ACCESS", "denied"))
+    })
+    if status.Code(err) != codes.PermissionDenied || Reason(err) != "NO_ACCESS" {
+        t.Fatalf("expect codes.PermissionDenied, got %v", err)
+    }
+}
+
+func TestHandlerFunc(t *testing.T) {
+    h := Filter()(HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+        if r.URL.Path == "/panic" {
+            panic("boom")
+        }
+        return Conflict("USER_EXISTS", "user exists")
+    }))
+    for path, code := range map[string]int{"/": http.StatusConflict, "/panic": http.StatusInternalServerError} {
+        rec := httptest.NewRecorder()
+        h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+        se := FromResponse(rec.Result())
+        if rec.Code != code || se.Code != code {
+            t.Fatalf("%s: expect %d, got %d %v", path, code, rec.Code, se)
+        }
+        if path == "/" && se.Reason != "USER_EXISTS" {
+            t.Fatalf("unexpected reason %q", se.Reason)
+        }
+    }
+}
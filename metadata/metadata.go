@@ -0,0 +1,114 @@
+package metadata
+
+import (
+    "context"
+    "slices"
+    "strings"
+)
+
+// Metadata 传输无关的元数据，键统一为小写。
+type Metadata map[string][]string
+
+// New 合并多个元数据为新元数据，键转换为小写。
+func New(mds ...map[string][]string) Metadata {
+    md := Metadata{}
+    for _, m := range mds {
+        for k, vs := range m {
+            for _, v := range vs {
+                md.Add(k, v)
+            }
+        }
+    }
+    return md
+}
+
+// Pairs 按键值对新建元数据，kv 数量为奇数时忽略最后一个。
+func Pairs(kv ...string) Metadata {
+    md := Metadata{}
+    for i := 0; i+1 < len(kv); i += 2 {
+        md.Add(kv[i], kv[i+1])
+    }
+    return md
+}
+
+// Get 返回键的第一个值。
+func (m Metadata) Get(key string) string {
+    if vs := m[strings.ToLower(key)]; len(vs) > 0 {
+        return vs[0]
+    }
+    return ""
+}
+
+// Values 返回键的全部值。
+func (m Metadata) Values(key string) []string {
+    return m[strings.ToLower(key)]
+}
+
+// Set 设置键的值，覆盖原有值。
+func (m Metadata) Set(key string, values ...string) {
+    if len(values) == 0 {
+        return
+    }
+    m[strings.ToLower(key)] = values
+}
+
+// Add 追加键的值。
+func (m Metadata) Add(key, value string) {
+    key = strings.ToLower(key)
+    m[key] = append(m[key], value)
+}
+
+// Range 按键遍历元数据，f 返回 false 时停止遍历。
+func (m Metadata) Range(f func(key string, values []string) bool) {
+    for k, vs := range m {
+        if !f(k, vs) {
+            return
+        }
+    }
+}
+
+// Clone 返回元数据的深拷贝。
+func (m Metadata) Clone() Metadata {
+    md := make(Metadata, len(m))
+    for k, vs := range m {
+        md[k] = slices.Clone(vs)
+    }
+    return md
+}
+
+type (
+    serverMetadataKey struct{}
+    clientMetadataKey struct{}
+)
+
+// NewServerContext 返回携带入站元数据的新上下文。
+func NewServerContext(ctx context.Context, md Metadata) context.Context {
+    return context.WithValue(ctx, serverMetadataKey{}, md)
+}
+
+// FromServerContext 返回上下文中的入站元数据。
+func FromServerContext(ctx context.Context) (Metadata, bool) {
+    md, ok := ctx.Value(serverMetadataKey{}).(Metadata)
+    return md, ok
+}
+
+// NewClientContext 返回携带出站元数据的新上下文。
+func NewClientContext(ctx context.Context, md Metadata) context.Context {
+    return context.WithValue(ctx, clientMetadataKey{}, md)
+}
+
+// FromClientContext 返回上下文中的出站元数据。
+func FromClientContext(ctx context.Context) (Metadata, bool) {
+    md, ok := ctx.Value(clientMetadataKey{}).(Metadata)
+    return md, ok
+}
+
+// AppendToClientContext 返回追加出站元数据键值对的新上下文，值追加到同名键已有值之后，不修改原上下文中的元数据。
+func AppendToClientContext(ctx context.Context, kv ...string) context.Context {
+    md, _ := FromClientContext(ctx)
+    md = md.Clone()
+    for i := 0; i+1 < len(kv); i += 2 {
+        md.Add(kv[i], kv[i+1])
+    }
+    return NewClientContext(ctx, md)
+}
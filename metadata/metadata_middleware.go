@@ -0,0 +1,62 @@
+package metadata
+
+import (
+    "context"
+    "net/http"
+
+    "google.golang.org/grpc"
+
+    "github.com/camry/dove/v2/server/ghttp"
+)
+
+// Filter 返回 HTTP 元数据过滤器，从请求头提取元数据到请求上下文。
+func Filter(opts ...Option) ghttp.FilterFunc {
+    p := NewPropagator(opts...)
+    return func(next http.Handler) http.Handler {
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            next.ServeHTTP(w, r.WithContext(p.ExtractHTTP(r.Context(), r.Header)))
+        })
+    }
+}
+
+// UnaryServerInterceptor 返回 gRPC 一元元数据拦截器，从入站元数据提取元数据到上下文。
+func UnaryServerInterceptor(opts ...Option) grpc.UnaryServerInterceptor {
+    p := NewPropagator(opts...)
+    return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+        return handler(p.ExtractGRPC(ctx), req)
+    }
+}
+
+// StreamServerInterceptor 返回 gRPC 流元数据拦截器，从入站元数据提取元数据到上下文。
+func StreamServerInterceptor(opts ...Option) grpc.StreamServerInterceptor {
+    p := NewPropagator(opts...)
+    return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+        return handler(srv, &wrappedStream{ServerStream: ss, ctx: p.ExtractGRPC(ss.Context())})
+    }
+}
+
+// wrappedStream 重写 gRPC 流上下文。
+type wrappedStream struct {
+    grpc.ServerStream
+    ctx context.Context
+}
+
+func (w *wrappedStream) Context() context.Context {
+    return w.ctx
+}
+
+// UnaryClientInterceptor 返回 gRPC 客户端一元元数据拦截器，将出站元数据注入调用。
+func UnaryClientInterceptor(opts ...Option) grpc.UnaryClientInterceptor {
+    p := NewPropagator(opts...)
+    return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+        return invoker(p.InjectGRPC(ctx), method, req, reply, cc, opts...)
+    }
+}
+
+// StreamClientInterceptor 返回 gRPC 客户端流元数据拦截器，将出站元数据注入调用。
+func StreamClientInterceptor(opts ...Option) grpc.StreamClientInterceptor {
+    p := NewPropagator(opts...)
+    return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+        return streamer(p.InjectGRPC(ctx), desc, cc, method, opts...)
+    }
+}
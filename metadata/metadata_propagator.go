@@ -0,0 +1,148 @@
+package metadata
+
+import (
+    "context"
+    "net/http"
+    "strings"
+
+    gmd "google.golang.org/grpc/metadata"
+)
+
+// Option 定义一个元数据传播选项类型。
+type Option func(o *Propagator)
+
+// Global 配置全局元数据键，覆盖默认值 x-md-global-*，以 * 结尾时按前缀匹配。
+//
+// 全局元数据从入站请求提取到上下文，并随出站调用逐级透传，适用于租户 ID 等链路级信息。
+func Global(patterns ...string) Option {
+    return func(p *Propagator) { p.global = patterns }
+}
+
+// Local 配置本地元数据键，覆盖默认值 x-md-local-*，以 * 结尾时按前缀匹配。
+//
+// 本地元数据仅从入站请求提取到上下文，不随出站调用透传。
+func Local(patterns ...string) Option {
+    return func(p *Propagator) { p.local = patterns }
+}
+
+// Constants 配置随每次出站调用发送的固定元数据。
+func Constants(md map[string]string) Option {
+    return func(p *Propagator) {
+        for k, v := range md {
+            p.constants.Set(k, v)
+        }
+    }
+}
+
+// Propagator 元数据传播器，负责入站提取与出站注入。
+type Propagator struct {
+    global    []string
+    local     []string
+    constants Metadata
+}
+
+// NewPropagator 新建元数据传播器。
+func NewPropagator(opts ...Option) *Propagator {
+    p := &Propagator{
+        global:    []string{"x-md-global-*"},
+        local:     []string{"x-md-local-*"},
+        constants: Metadata{},
+    }
+    for _, o := range opts {
+        o(p)
+    }
+    p.global = lower(p.global)
+    p.local = lower(p.local)
+    return p
+}
+
+// lower 返回键模式的小写副本，不修改调用方传入的切片。
+func lower(patterns []string) []string {
+    list := make([]string, len(patterns))
+    for i, p := range patterns {
+        list[i] = strings.ToLower(p)
+    }
+    return list
+}
+
+// match 匹配元数据键。
+func match(patterns []string, key string) bool {
+    for _, p := range patterns {
+        if prefix, ok := strings.CutSuffix(p, "*"); ok {
+            if strings.HasPrefix(key, prefix) {
+                return true
+            }
+        } else if p == key {
+            return true
+        }
+    }
+    return false
+}
+
+// extract 从入站键值中提取全局与本地元数据。
+func (p *Propagator) extract(ctx context.Context, src map[string][]string) context.Context {
+    md := Metadata{}
+    for k, vs := range src {
+        k = strings.ToLower(k)
+        if match(p.global, k) || match(p.local, k) {
+            md[k] = append(md[k], vs...)
+        }
+    }
+    return NewServerContext(ctx, md)
+}
+
+// outgoing 返回出站元数据，依次合并固定元数据、入站全局元数据与上下文出站元数据，后者优先。
+func (p *Propagator) outgoing(ctx context.Context) Metadata {
+    md := p.constants.Clone()
+    if in, ok := FromServerContext(ctx); ok {
+        for k, vs := range in {
+            if match(p.global, k) {
+                md[k] = vs
+            }
+        }
+    }
+    if out, ok := FromClientContext(ctx); ok {
+        for k, vs := range out {
+            md[k] = vs
+        }
+    }
+    return md
+}
+
+// ExtractGRPC 从 gRPC 入站元数据提取元数据到上下文。
+func (p *Propagator) ExtractGRPC(ctx context.Context) context.Context {
+    in, _ := gmd.FromIncomingContext(ctx)
+    return p.extract(ctx, in)
+}
+
+// ExtractHTTP 从 HTTP 请求头提取元数据到上下文。
+func (p *Propagator) ExtractHTTP(ctx context.Context, header http.Header) context.Context {
+    return p.extract(ctx, header)
+}
+
+// InjectGRPC 将出站元数据注入 gRPC 出站上下文，已存在的键不会被覆盖。
+func (p *Propagator) InjectGRPC(ctx context.Context) context.Context {
+    md := p.outgoing(ctx)
+    if len(md) == 0 {
+        return ctx
+    }
+    out, _ := gmd.FromOutgoingContext(ctx)
+    out = out.Copy()
+    for k, vs := range md {
+        if len(out.Get(k)) == 0 {
+            out.Set(k, vs...)
+        }
+    }
+    return gmd.NewOutgoingContext(ctx, out)
+}
+
+// InjectHTTP 将出站元数据注入 HTTP 请求头，已存在的键不会被覆盖。
+func (p *Propagator) InjectHTTP(ctx context.Context, header http.Header) {
+    for k, vs := range p.outgoing(ctx) {
+        if header.Get(k) == "" {
+            for _, v := range vs {
+                header.Add(k, v)
+            }
+        }
+    }
+}
@@ -0,0 +1,87 @@
+package metadata
+
+import (
+    "context"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+
+    "google.golang.org/grpc"
+    gmd "google.golang.org/grpc/metadata"
+)
+
+func TestPropagation(t *testing.T) {
+    opts := []Option{
+        Global("x-md-global-*", "X-Tenant-Id"),
+        Constants(map[string]string{"x-md-global-caller": "dove"}),
+    }
+    var ctx context.Context
+    h := Filter(opts...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        ctx = r.Context()
+    }))
+    req := httptest.NewRequest(http.MethodGet, "/", nil)
+    req.Header.Set("X-Md-Global-Uid", "1")
+    req.Header.Set("X-Md-Local-Trace", "local")
+    req.Header.Set("X-Tenant-Id", "t1")
+    req.Header.Set("X-Other", "other")
+    h.ServeHTTP(httptest.NewRecorder(), req)
+
+    md, ok := FromServerContext(ctx)
+    if !ok || md.Get("x-md-global-uid") != "1" || md.Get("x-md-local-trace") != "local" || md.Get("x-tenant-id") != "t1" {
+        t.Fatalf("unexpected server metadata %v", md)
+    }
+    if md.Get("x-other") != "" {
+        t.Fatalf("unexpected key x-other in %v", md)
+    }
+
+    ctx = AppendToClientContext(ctx, "x-md-global-uid", "2", "x-md-local-hop", "1")
+    var out gmd.MD
+    in := UnaryClientInterceptor(opts...)
+    _ = in(ctx, "/pkg.Svc/Get", nil, nil, nil, func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+        out, _ = gmd.FromOutgoingContext(ctx)
+        return nil
+    })
+    want := map[string]string{
+        "x-md-global-uid":    "2",
+        "x-md-global-caller": "dove",
+        "x-tenant-id":        "t1",
+        "x-md-local-hop":     "1",
+    }
+    for k, v := range want {
+        if got := out.Get(k); len(got) != 1 || got[0] != v {
+            t.Fatalf("expect %s=%s, got %v", k, v, out)
+        }
+    }
+    if len(out.Get("x-md-local-trace")) != 0 {
+        t.Fatalf("local metadata should not be propagated, got %v", out)
+    }
+
+    header := http.Header{}
+    NewPropagator(opts...).InjectHTTP(ctx, header)
+    if header.Get("X-Tenant-Id") != "t1" || header.Get("X-Md-Global-Uid") != "2" {
+        t.Fatalf("unexpected header %v", header)
+    }
+}
+
+func TestAppendToClientContext(t *testing.T) {
+    ctx := AppendToClientContext(context.Background(), "x-md-global-tag", "a")
+    ctx2 := AppendToClientContext(ctx, "X-Md-Global-Tag", "b", "x-md-global-uid", "1")
+    md, _ := FromClientContext(ctx2)
+    if got := md.Values("x-md-global-tag"); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+        t.Fatalf("expect values appended, got %v", got)
+    }
+    if md, _ = FromClientContext(ctx); len(md.Values("x-md-global-tag")) != 1 || md.Get("x-md-global-uid") != "" {
+        t.Fatalf("original context should not be modified, got %v", md)
+    }
+}
+
+func TestNewPropagator_Patterns(t *testing.T) {
+    global, local := []string{"X-Tenant-Id"}, []string{"X-Md-Local-*"}
+    p := NewPropagator(Global(global...), Local(local...))
+    if global[0] != "X-Tenant-Id" || local[0] != "X-Md-Local-*" {
+        t.Fatalf("caller patterns should not be modified, got %v %v", global, local)
+    }
+    if !match(p.global, "x-tenant-id") || !match(p.local, "x-md-local-trace") {
+        t.Fatalf("expect lowercase patterns, got %v %v", p.global, p.local)
+    }
+}
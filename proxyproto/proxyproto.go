@@ -0,0 +1,244 @@
+package proxyproto
+
+import (
+    "bufio"
+    "context"
+    "errors"
+    "fmt"
+    "net"
+    "net/netip"
+    "sync"
+    "time"
+)
+
+var (
+    // ErrNoHeader 可信来源的连接未发送 PROXY 协议头。
+    ErrNoHeader = errors.New("proxyproto: missing PROXY header")
+    // ErrInvalidHeader PROXY 协议头格式错误。
+    ErrInvalidHeader = errors.New("proxyproto: invalid PROXY header")
+    // ErrNoTrusted 未配置可信来源。
+    ErrNoTrusted = errors.New("proxyproto: no trusted CIDR configured")
+)
+
+// Option 定义一个 PROXY 协议选项类型。
+type Option func(o *option)
+
+// option PROXY 协议选项实体对象。
+type option struct {
+    trusted  []string
+    timeout  time.Duration
+    required bool
+}
+
+// Trusted 配置可信来源的 CIDR 列表，仅解析可信来源连接的协议头，其他连接原样透传。
+//
+// 必须至少配置一个，否则任意客户端均可伪造 RemoteAddr；单个 IP 视为 /32 或 /128。
+func Trusted(cidrs ...string) Option {
+    return func(o *option) { o.trusted = append(o.trusted, cidrs...) }
+}
+
+// ReadHeaderTimeout 配置读取协议头的超时时间，默认 5 秒。
+func ReadHeaderTimeout(d time.Duration) Option {
+    return func(o *option) { o.timeout = d }
+}
+
+// Required 配置可信来源的连接必须发送协议头，否则读取返回 ErrNoHeader。
+func Required() Option {
+    return func(o *option) { o.required = true }
+}
+
+// Listener 解析 PROXY 协议头的监听器。
+//
+// 协议头在连接首次读取或获取 RemoteAddr 时解析，不阻塞 Accept。
+type Listener struct {
+    net.Listener
+    trusted  []netip.Prefix
+    timeout  time.Duration
+    required bool
+}
+
+// NewListener 包装监听器，未配置可信来源时返回 ErrNoTrusted，CIDR 格式错误时返回错误。
+func NewListener(lis net.Listener, opts ...Option) (*Listener, error) {
+    o := option{timeout: 5 * time.Second}
+    for _, opt := range opts {
+        opt(&o)
+    }
+    l := &Listener{Listener: lis, timeout: o.timeout, required: o.required}
+    for _, cidr := range o.trusted {
+        prefix, err := netip.ParsePrefix(cidr)
+        if err != nil {
+            addr, aErr := netip.ParseAddr(cidr)
+            if aErr != nil {
+                return nil, fmt.Errorf("proxyproto: invalid trusted CIDR %q: %w", cidr, err)
+            }
+            prefix = netip.PrefixFrom(addr, addr.BitLen())
+        }
+        l.trusted = append(l.trusted, prefix.Masked())
+    }
+    if len(l.trusted) == 0 {
+        return nil, ErrNoTrusted
+    }
+    return l, nil
+}
+
+// Accept 接受新连接。
+func (l *Listener) Accept() (net.Conn, error) {
+    nc, err := l.Listener.Accept()
+    if err != nil {
+        return nil, err
+    }
+    return &Conn{Conn: nc, l: l, trusted: l.trust(nc.RemoteAddr())}, nil
+}
+
+// trust 返回来源地址是否可信。
+func (l *Listener) trust(addr net.Addr) bool {
+    var ip netip.Addr
+    switch a := addr.(type) {
+    case *net.TCPAddr:
+        ip, _ = netip.AddrFromSlice(a.IP)
+    default:
+        ap, err := netip.ParseAddrPort(addr.String())
+        if err != nil {
+            return false
+        }
+        ip = ap.Addr()
+    }
+    ip = ip.Unmap()
+    for _, prefix := range l.trusted {
+        if prefix.Contains(ip) {
+            return true
+        }
+    }
+    return false
+}
+
+// Conn 解析 PROXY 协议头的连接，RemoteAddr 返回协议头中的客户端地址。
+type Conn struct {
+    net.Conn
+    l       *Listener
+    trusted bool
+    reader  *bufio.Reader
+    once    sync.Once
+    header  *Header
+    err     error
+
+    mu           sync.Mutex
+    readDeadline time.Time // 调用方设置的读取截止时间。
+}
+
+// Header 返回连接的 PROXY 协议头，未发送协议头或来源不可信时返回 nil。
+func (c *Conn) Header() (*Header, error) {
+    c.once.Do(c.readHeader)
+    return c.header, c.err
+}
+
+// readHeader 在超时时间内读取协议头，随后恢复调用方设置的读取截止时间。
+func (c *Conn) readHeader() {
+    if !c.trusted {
+        return
+    }
+    c.reader = bufio.NewReader(c.Conn)
+    if c.l.timeout > 0 {
+        _ = c.Conn.SetReadDeadline(time.Now().Add(c.l.timeout))
+    }
+    c.header, c.err = Read(c.reader)
+    if c.err == nil && c.header == nil && c.l.required {
+        c.err = ErrNoHeader
+    }
+    if c.l.timeout > 0 {
+        c.mu.Lock()
+        _ = c.Conn.SetReadDeadline(c.readDeadline)
+        c.mu.Unlock()
+    }
+}
+
+func (c *Conn) Read(b []byte) (int, error) {
+    if _, err := c.Header(); err != nil {
+        return 0, err
+    }
+    if c.reader != nil {
+        return c.reader.Read(b)
+    }
+    return c.Conn.Read(b)
+}
+
+// RemoteAddr 返回客户端地址，没有协议头或协议头不含地址时返回对端地址。
+func (c *Conn) RemoteAddr() net.Addr {
+    if h, _ := c.Header(); h != nil && h.Source != nil {
+        return h.Source
+    }
+    return c.Conn.RemoteAddr()
+}
+
+// ProxyAddr 返回对端地址，即负载均衡器地址。
+func (c *Conn) ProxyAddr() net.Addr {
+    return c.Conn.RemoteAddr()
+}
+
+func (c *Conn) SetDeadline(t time.Time) error {
+    c.mu.Lock()
+    c.readDeadline = t
+    c.mu.Unlock()
+    return c.Conn.SetDeadline(t)
+}
+
+func (c *Conn) SetReadDeadline(t time.Time) error {
+    c.mu.Lock()
+    c.readDeadline = t
+    c.mu.Unlock()
+    return c.Conn.SetReadDeadline(t)
+}
+
+// Handshake 读取连接的 PROXY 协议头，非 PROXY 协议连接时直接返回 nil。
+func Handshake(nc net.Conn) error {
+    if c, ok := unwrap(nc); ok {
+        _, err := c.Header()
+        return err
+    }
+    return nil
+}
+
+// unwrap 逐层解包连接（如 *tls.Conn）直到 PROXY 协议连接。
+func unwrap(nc net.Conn) (*Conn, bool) {
+    for nc != nil {
+        switch c := nc.(type) {
+        case *Conn:
+            return c, true
+        case interface{ NetConn() net.Conn }:
+            nc = c.NetConn()
+        default:
+            return nil, false
+        }
+    }
+    return nil, false
+}
+
+// connKey 连接上下文键。
+type connKey struct{}
+
+// NewContext 将连接的 PROXY 协议信息放入上下文，非 PROXY 协议连接时返回原上下文。
+func NewContext(ctx context.Context, nc net.Conn) context.Context {
+    if c, ok := unwrap(nc); ok {
+        return context.WithValue(ctx, connKey{}, c)
+    }
+    return ctx
+}
+
+// FromContext 从上下文中获取连接的 PROXY 协议头。
+func FromContext(ctx context.Context) (*Header, bool) {
+    c, ok := ctx.Value(connKey{}).(*Conn)
+    if !ok {
+        return nil, false
+    }
+    h, err := c.Header()
+    return h, err == nil && h != nil
+}
+
+// ClientAddr 从上下文中获取客户端地址。
+func ClientAddr(ctx context.Context) (net.Addr, bool) {
+    c, ok := ctx.Value(connKey{}).(*Conn)
+    if !ok {
+        return nil, false
+    }
+    return c.RemoteAddr(), true
+}
@@ -0,0 +1,205 @@
+package proxyproto
+
+import (
+    "bufio"
+    "bytes"
+    "encoding/binary"
+    "errors"
+    "io"
+    "net"
+    "strconv"
+    "strings"
+)
+
+var (
+    sigV1 = []byte("PROXY ")
+    sigV2 = []byte("\r\n\r\n\x00\r\nQUIT\n")
+)
+
+// maxLineV1 v1 协议头最大长度（含 CRLF）。
+const maxLineV1 = 107
+
+// Command PROXY 协议命令。
+type Command byte
+
+const (
+    // Local 连接由代理自身发起（如健康检查），协议头不含客户端地址。
+    Local Command = 0x0
+    // Proxy 连接由代理转发，协议头包含客户端地址。
+    Proxy Command = 0x1
+)
+
+// TLV v2 协议头的扩展字段。
+type TLV struct {
+    Type  byte
+    Value []byte
+}
+
+// Header PROXY 协议头。
+type Header struct {
+    Version     int      // 协议版本，1 或 2。
+    Command     Command  // 协议命令。
+    Source      net.Addr // 客户端地址，未知时为 nil。
+    Destination net.Addr // 代理接收连接的地址，未知时为 nil。
+    TLVs        []TLV    // v2 扩展字段。
+}
+
+// Read 从 r 中读取 PROXY 协议头，数据不以协议签名开头时返回 nil 且不消耗数据。
+func Read(r *bufio.Reader) (*Header, error) {
+    ok, err := hasPrefix(r, sigV1)
+    if err != nil {
+        return nil, err
+    }
+    if ok {
+        return readV1(r)
+    }
+    if ok, err = hasPrefix(r, sigV2); err != nil {
+        return nil, err
+    }
+    if ok {
+        return readV2(r)
+    }
+    return nil, nil
+}
+
+// hasPrefix 逐字节比较 r 的前缀，不匹配时立即返回，避免等待更多数据。
+func hasPrefix(r *bufio.Reader, sig []byte) (bool, error) {
+    for i := range sig {
+        b, err := r.Peek(i + 1)
+        if err != nil {
+            if errors.Is(err, io.EOF) {
+                return false, nil
+            }
+            return false, err
+        }
+        if b[i] != sig[i] {
+            return false, nil
+        }
+    }
+    return true, nil
+}
+
+// readV1 读取文本格式的 v1 协议头。
+func readV1(r *bufio.Reader) (*Header, error) {
+    var line []byte
+    for {
+        b, err := r.ReadByte()
+        if err != nil {
+            return nil, unexpected(err)
+        }
+        line = append(line, b)
+        if b == '\n' {
+            break
+        }
+        if len(line) >= maxLineV1 {
+            return nil, ErrInvalidHeader
+        }
+    }
+    if !bytes.HasSuffix(line, []byte("\r\n")) {
+        return nil, ErrInvalidHeader
+    }
+    fields := strings.Split(string(line[:len(line)-2]), " ")
+    h := &Header{Version: 1, Command: Proxy}
+    if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+        return h, nil
+    }
+    if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+        return nil, ErrInvalidHeader
+    }
+    src, err := parseV1Addr(fields[1], fields[2], fields[4])
+    if err != nil {
+        return nil, err
+    }
+    dst, err := parseV1Addr(fields[1], fields[3], fields[5])
+    if err != nil {
+        return nil, err
+    }
+    h.Source, h.Destination = src, dst
+    return h, nil
+}
+
+// parseV1Addr 解析 v1 协议头中的地址与端口。
+func parseV1Addr(proto, host, port string) (*net.TCPAddr, error) {
+    ip := net.ParseIP(host)
+    if ip == nil || (proto == "TCP4") != (ip.To4() != nil) {
+        return nil, ErrInvalidHeader
+    }
+    p, err := strconv.ParseUint(port, 10, 16)
+    if err != nil {
+        return nil, ErrInvalidHeader
+    }
+    return &net.TCPAddr{IP: ip, Port: int(p)}, nil
+}
+
+// readV2 读取二进制格式的 v2 协议头。
+func readV2(r *bufio.Reader) (*Header, error) {
+    var fixed [16]byte
+    if _, err := io.ReadFull(r, fixed[:]); err != nil {
+        return nil, unexpected(err)
+    }
+    if fixed[12]>>4 != 2 || fixed[12]&0xf > byte(Proxy) {
+        return nil, ErrInvalidHeader
+    }
+    body := make([]byte, binary.BigEndian.Uint16(fixed[14:]))
+    if _, err := io.ReadFull(r, body); err != nil {
+        return nil, unexpected(err)
+    }
+    h := &Header{Version: 2, Command: Command(fixed[12] & 0xf)}
+    family, transport := fixed[13]>>4, fixed[13]&0xf
+    var n int
+    switch family {
+    case 0x1, 0x2:
+        size := 4
+        if family == 0x2 {
+            size = 16
+        }
+        n = 2*size + 4
+        if len(body) < n {
+            return nil, ErrInvalidHeader
+        }
+        src := net.IP(bytes.Clone(body[:size]))
+        dst := net.IP(bytes.Clone(body[size : 2*size]))
+        sport := int(binary.BigEndian.Uint16(body[2*size:]))
+        dport := int(binary.BigEndian.Uint16(body[2*size+2:]))
+        if transport == 0x2 {
+            h.Source, h.Destination = &net.UDPAddr{IP: src, Port: sport}, &net.UDPAddr{IP: dst, Port: dport}
+        } else {
+            h.Source, h.Destination = &net.TCPAddr{IP: src, Port: sport}, &net.TCPAddr{IP: dst, Port: dport}
+        }
+    case 0x3:
+        n = 216
+        if len(body) < n {
+            return nil, ErrInvalidHeader
+        }
+        h.Source = &net.UnixAddr{Name: string(bytes.TrimRight(body[:108], "\x00")), Net: "unix"}
+        h.Destination = &net.UnixAddr{Name: string(bytes.TrimRight(body[108:216], "\x00")), Net: "unix"}
+    default:
+        // 未知地址族忽略地址与扩展字段。
+        n = len(body)
+    }
+    tlvs := body[n:]
+    for len(tlvs) > 0 {
+        if len(tlvs) < 3 {
+            return nil, ErrInvalidHeader
+        }
+        size := int(binary.BigEndian.Uint16(tlvs[1:3]))
+        if len(tlvs) < 3+size {
+            return nil, ErrInvalidHeader
+        }
+        h.TLVs = append(h.TLVs, TLV{Type: tlvs[0], Value: tlvs[3 : 3+size]})
+        tlvs = tlvs[3+size:]
+    }
+    if h.Command == Local {
+        // LOCAL 命令的地址信息应被忽略。
+        h.Source, h.Destination = nil, nil
+    }
+    return h, nil
+}
+
+// unexpected 将读取协议头过程中的 EOF 转换为 ErrUnexpectedEOF。
+func unexpected(err error) error {
+    if err == io.EOF {
+        return io.ErrUnexpectedEOF
+    }
+    return err
+}
@@ -0,0 +1,137 @@
+package proxyproto
+
+import (
+    "bufio"
+    "context"
+    "encoding/binary"
+    "errors"
+    "io"
+    "net"
+    "strings"
+    "testing"
+    "time"
+)
+
+// v2Header 构造 TCP4 的 v2 协议头。
+func v2Header(src, dst string, sport, dport uint16, tlvs ...TLV) []byte {
+    b := append([]byte(nil), sigV2...)
+    b = append(b, 0x21, 0x11, 0, 0)
+    b = append(b, net.ParseIP(src).To4()...)
+    b = append(b, net.ParseIP(dst).To4()...)
+    b = binary.BigEndian.AppendUint16(b, sport)
+    b = binary.BigEndian.AppendUint16(b, dport)
+    for _, tlv := range tlvs {
+        b = append(b, tlv.Type)
+        b = binary.BigEndian.AppendUint16(b, uint16(len(tlv.Value)))
+        b = append(b, tlv.Value...)
+    }
+    binary.BigEndian.PutUint16(b[14:], uint16(len(b)-16))
+    return b
+}
+
+func TestRead(t *testing.T) {
+    tests := []struct {
+        name    string
+        in      string
+        version int
+        source  string
+        rest    string
+        err     error
+    }{
+        {name: "v1 tcp4", in: "PROXY TCP4 192.0.2.1 198.51.100.1 5678 80\r\nping", version: 1, source: "192.0.2.1:5678", rest: "ping"},
+        {name: "v1 tcp6", in: "PROXY TCP6 2001:db8::1 2001:db8::2 5678 80\r\nping", version: 1, source: "[2001:db8::1]:5678", rest: "ping"},
+        {name: "v1 unknown", in: "PROXY UNKNOWN\r\nping", version: 1, rest: "ping"},
+        {name: "v2 tcp4", in: string(v2Header("192.0.2.1", "198.51.100.1", 5678, 80, TLV{Type: 0x04, Value: []byte("x")})) + "ping", version: 2, source: "192.0.2.1:5678", rest: "ping"},
+        {name: "no header", in: "GET / HTTP/1.1\r\n", rest: "GET / HTTP/1.1\r\n"},
+        {name: "v1 invalid", in: "PROXY TCP4 192.0.2.1\r\n", err: ErrInvalidHeader},
+        {name: "v1 mismatched family", in: "PROXY TCP4 2001:db8::1 2001:db8::2 1 2\r\n", err: ErrInvalidHeader},
+        {name: "v1 too long", in: "PROXY " + strings.Repeat("x", 120), err: ErrInvalidHeader},
+        {name: "v2 truncated", in: string(v2Header("192.0.2.1", "198.51.100.1", 1, 2))[:20], err: io.ErrUnexpectedEOF},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            r := bufio.NewReader(strings.NewReader(tt.in))
+            h, err := Read(r)
+            if !errors.Is(err, tt.err) {
+                t.Fatalf("expect error %v, got %v", tt.err, err)
+            }
+            if tt.err != nil {
+                return
+            }
+            if tt.version == 0 {
+                if h != nil {
+                    t.Fatalf("expect no header, got %+v", h)
+                }
+            } else if h == nil || h.Version != tt.version {
+                t.Fatalf("expect version %d, got %+v", tt.version, h)
+            } else if tt.source == "" && h.Source != nil || tt.source != "" && (h.Source == nil || h.Source.String() != tt.source) {
+                t.Fatalf("expect source %q, got %v", tt.source, h.Source)
+            }
+            rest, _ := io.ReadAll(r)
+            if string(rest) != tt.rest {
+                t.Fatalf("expect rest %q, got %q", tt.rest, rest)
+            }
+        })
+    }
+}
+
+// serve 启动 PROXY 协议监听器，返回客户端写入数据后服务端观察到的地址与数据。
+func serve(t *testing.T, payload string, opts ...Option) (net.Addr, string, error) {
+    lis, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatal(err)
+    }
+    pl, err := NewListener(lis, opts...)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer pl.Close()
+    go func() {
+        c, err := net.Dial("tcp", lis.Addr().String())
+        if err != nil {
+            return
+        }
+        defer c.Close()
+        _, _ = c.Write([]byte(payload))
+        _, _ = c.Read(make([]byte, 1))
+    }()
+    nc, err := pl.Accept()
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer nc.Close()
+    ctx := NewContext(context.Background(), nc)
+    buf := make([]byte, 4)
+    _, err = io.ReadFull(nc, buf)
+    addr, _ := ClientAddr(ctx)
+    return addr, string(buf), err
+}
+
+func TestListener(t *testing.T) {
+    addr, data, err := serve(t, "PROXY TCP4 192.0.2.1 198.51.100.1 5678 80\r\nping", Trusted("127.0.0.0/8"))
+    if err != nil || data != "ping" || addr.String() != "192.0.2.1:5678" {
+        t.Fatalf("expect client address from header, got %v %q %v", addr, data, err)
+    }
+    // 不可信来源不解析协议头。
+    _, data, err = serve(t, "PROXY TCP4 192.0.2.1 198.51.100.1 5678 80\r\n", Trusted("10.0.0.0/8"))
+    if err != nil || data != "PROX" {
+        t.Fatalf("expect raw data from untrusted source, got %q %v", data, err)
+    }
+    // 可信来源未发送协议头时按配置拒绝。
+    addr, data, err = serve(t, "ping", Trusted("127.0.0.1"))
+    if err != nil || data != "ping" || !strings.HasPrefix(addr.String(), "127.0.0.1:") {
+        t.Fatalf("expect optional header, got %v %q %v", addr, data, err)
+    }
+    if _, _, err = serve(t, "ping", Trusted("127.0.0.1"), Required()); !errors.Is(err, ErrNoHeader) {
+        t.Fatalf("expect ErrNoHeader, got %v", err)
+    }
+    if _, _, err = serve(t, "PRO", Trusted("127.0.0.1"), ReadHeaderTimeout(50*time.Millisecond)); err == nil {
+        t.Fatal("expect read header timeout")
+    }
+    if _, err := NewListener(nil, Trusted("bad")); err == nil {
+        t.Fatal("expect invalid CIDR error")
+    }
+    if _, err := NewListener(nil); !errors.Is(err, ErrNoTrusted) {
+        t.Fatalf("expect ErrNoTrusted, got %v", err)
+    }
+}
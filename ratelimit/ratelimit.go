@@ -0,0 +1,235 @@
+package ratelimit
+
+import (
+    "sync"
+    "sync/atomic"
+    "time"
+)
+
+// Limiter 定义按键限流接口。
+type Limiter interface {
+    // Allow 判断键 key 当前是否允许通过。
+    Allow(key string) bool
+}
+
+// Refunder 定义可归还配额的限流器，Limiter 放行后请求被后续并发限流拒绝时归还配额。
+type Refunder interface {
+    // Refund 归还键 key 最近一次放行占用的配额。
+    Refund(key string)
+}
+
+// ConcurrencyLimiter 定义并发限流接口。
+type ConcurrencyLimiter interface {
+    // Acquire 申请一个并发配额，ok 为 true 时处理完成后必须调用 done。
+    Acquire() (done func(), ok bool)
+}
+
+// Stats 限流器状态快照，可用于上报监控指标。
+type Stats struct {
+    Allowed  uint64 // 累计放行数。
+    Rejected uint64 // 累计拒绝数。
+    Keys     int    // 当前跟踪的键数量。
+    Inflight int64  // 当前并发数。
+    Limit    int64  // 当前并发上限。
+}
+
+// counter 放行与拒绝计数器。
+type counter struct {
+    allowed  atomic.Uint64
+    rejected atomic.Uint64
+}
+
+func (c *counter) record(ok bool) bool {
+    if ok {
+        c.allowed.Add(1)
+    } else {
+        c.rejected.Add(1)
+    }
+    return ok
+}
+
+/**********************************/
+/********** Token Bucket **********/
+/**********************************/
+
+// bucket 单个键的令牌桶。
+type bucket struct {
+    tokens float64
+    last   time.Time
+}
+
+// TokenBucket 按键的令牌桶限流器。
+type TokenBucket struct {
+    counter
+    mu      sync.Mutex
+    rate    float64
+    burst   float64
+    ttl     time.Duration
+    sweep   time.Time
+    buckets map[string]*bucket
+}
+
+// NewTokenBucket 新建令牌桶限流器，rate 为每秒生成的令牌数，burst 为桶容量。
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+    ttl := time.Minute
+    if rate > 0 {
+        // 桶装满后不再需要保留状态。
+        if d := time.Duration(float64(burst) / rate * float64(time.Second)); d > ttl {
+            ttl = d
+        }
+    }
+    return &TokenBucket{
+        rate:    rate,
+        burst:   float64(burst),
+        ttl:     ttl,
+        sweep:   time.Now(),
+        buckets: make(map[string]*bucket),
+    }
+}
+
+// Allow 实现 Limiter 接口。
+func (l *TokenBucket) Allow(key string) bool {
+    now := time.Now()
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    l.evict(now)
+    b, ok := l.buckets[key]
+    if !ok {
+        b = &bucket{tokens: l.burst, last: now}
+        l.buckets[key] = b
+    }
+    b.tokens += now.Sub(b.last).Seconds() * l.rate
+    if b.tokens > l.burst {
+        b.tokens = l.burst
+    }
+    b.last = now
+    if b.tokens < 1 {
+        return l.record(false)
+    }
+    b.tokens--
+    return l.record(true)
+}
+
+// Refund 实现 Refunder 接口，归还一个令牌。
+func (l *TokenBucket) Refund(key string) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    if b, ok := l.buckets[key]; ok {
+        b.tokens = min(b.tokens+1, l.burst)
+    }
+}
+
+// evict 清理长时间未访问的键。
+func (l *TokenBucket) evict(now time.Time) {
+    if now.Sub(l.sweep) < l.ttl {
+        return
+    }
+    l.sweep = now
+    for k, b := range l.buckets {
+        if now.Sub(b.last) >= l.ttl {
+            delete(l.buckets, k)
+        }
+    }
+}
+
+// Stats 返回限流器状态快照。
+func (l *TokenBucket) Stats() Stats {
+    l.mu.Lock()
+    keys := len(l.buckets)
+    l.mu.Unlock()
+    return Stats{Allowed: l.allowed.Load(), Rejected: l.rejected.Load(), Keys: keys}
+}
+
+/**********************************/
+/********* Sliding Window *********/
+/**********************************/
+
+// window 单个键的滑动窗口计数。
+type window struct {
+    start time.Time
+    prev  int
+    curr  int
+}
+
+// SlidingWindow 按键的滑动窗口限流器。
+//
+// 采用滑动窗口计数算法，按上一窗口计数的剩余时间占比加权估算当前窗口内的请求数。
+type SlidingWindow struct {
+    counter
+    mu      sync.Mutex
+    limit   int
+    size    time.Duration
+    sweep   time.Time
+    windows map[string]*window
+}
+
+// NewSlidingWindow 新建滑动窗口限流器，每个键在 size 时间内最多放行 limit 个请求，size 不大于 0 时为 1 秒。
+func NewSlidingWindow(limit int, size time.Duration) *SlidingWindow {
+    if size <= 0 {
+        size = time.Second
+    }
+    return &SlidingWindow{
+        limit:   limit,
+        size:    size,
+        sweep:   time.Now(),
+        windows: make(map[string]*window),
+    }
+}
+
+// Allow 实现 Limiter 接口。
+func (l *SlidingWindow) Allow(key string) bool {
+    now := time.Now()
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    l.evict(now)
+    w, ok := l.windows[key]
+    if !ok {
+        w = &window{start: now.Truncate(l.size)}
+        l.windows[key] = w
+    }
+    if elapsed := now.Sub(w.start); elapsed >= l.size {
+        if elapsed >= 2*l.size {
+            w.prev = 0
+        } else {
+            w.prev = w.curr
+        }
+        w.curr = 0
+        w.start = now.Truncate(l.size)
+    }
+    weight := 1 - float64(now.Sub(w.start))/float64(l.size)
+    if float64(w.prev)*weight+float64(w.curr) >= float64(l.limit) {
+        return l.record(false)
+    }
+    w.curr++
+    return l.record(true)
+}
+
+// Refund 实现 Refunder 接口，归还当前窗口的一次计数。
+func (l *SlidingWindow) Refund(key string) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    if w, ok := l.windows[key]; ok && w.curr > 0 {
+        w.curr--
+    }
+}
+
+// evict 清理超过两个窗口未访问的键。
+func (l *SlidingWindow) evict(now time.Time) {
+    if now.Sub(l.sweep) < 2*l.size {
+        return
+    }
+    l.sweep = now
+    for k, w := range l.windows {
+        if now.Sub(w.start) >= 2*l.size {
+            delete(l.windows, k)
+        }
+    }
+}
+
+// Stats 返回限流器状态快照。
+func (l *SlidingWindow) Stats() Stats {
+    l.mu.Lock()
+    keys := len(l.windows)
+    l.mu.Unlock()
+    return Stats{Allowed: l.allowed.Load(), Rejected: l.rejected.Load(), Keys: keys}
+}
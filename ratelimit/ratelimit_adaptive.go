@@ -0,0 +1,124 @@
+package ratelimit
+
+import (
+    "math"
+    "sync"
+    "time"
+)
+
+// AdaptiveOption 定义一个自适应并发限流器选项类型。
+type AdaptiveOption func(l *Adaptive)
+
+// InitialLimit 配置初始并发上限。
+func InitialLimit(n int) AdaptiveOption {
+    return func(l *Adaptive) { l.limit = float64(n) }
+}
+
+// MinLimit 配置最小并发上限。
+func MinLimit(n int) AdaptiveOption {
+    return func(l *Adaptive) { l.minLimit = float64(n) }
+}
+
+// MaxLimit 配置最大并发上限。
+func MaxLimit(n int) AdaptiveOption {
+    return func(l *Adaptive) { l.maxLimit = float64(n) }
+}
+
+// Threshold 配置排队阈值，估算排队数小于 alpha 时增大上限，大于 beta 时减小上限。
+func Threshold(alpha, beta float64) AdaptiveOption {
+    return func(l *Adaptive) { l.alpha, l.beta = alpha, beta }
+}
+
+// ProbeInterval 配置最小延迟的重新探测间隔，用于适应下游基线延迟变化。
+func ProbeInterval(d time.Duration) AdaptiveOption {
+    return func(l *Adaptive) { l.probe = d }
+}
+
+// Adaptive 基于延迟的自适应并发限流器（Vegas 算法）。
+//
+// 以观测到的最小延迟作为无排队基线，估算排队数 queue = limit × (1 - minRTT/rtt)，
+// 排队较少时增大并发上限，排队较多时减小并发上限。
+type Adaptive struct {
+    counter
+    mu       sync.Mutex
+    limit    float64
+    minLimit float64
+    maxLimit float64
+    alpha    float64
+    beta     float64
+    probe    time.Duration
+    inflight int64
+    minRTT   time.Duration
+    probed   time.Time
+}
+
+// NewAdaptive 新建自适应并发限流器。
+func NewAdaptive(opts ...AdaptiveOption) *Adaptive {
+    l := &Adaptive{
+        limit:    20,
+        minLimit: 1,
+        maxLimit: 1000,
+        alpha:    3,
+        beta:     6,
+        probe:    time.Minute,
+        probed:   time.Now(),
+    }
+    for _, opt := range opts {
+        opt(l)
+    }
+    return l
+}
+
+// Acquire 实现 ConcurrencyLimiter 接口。
+func (l *Adaptive) Acquire() (func(), bool) {
+    l.mu.Lock()
+    if float64(l.inflight) >= math.Floor(l.limit) {
+        l.mu.Unlock()
+        return nil, l.record(false)
+    }
+    l.inflight++
+    l.mu.Unlock()
+    start := time.Now()
+    var once sync.Once
+    return func() {
+        once.Do(func() { l.update(time.Since(start)) })
+    }, l.record(true)
+}
+
+// update 根据本次请求延迟调整并发上限。
+func (l *Adaptive) update(rtt time.Duration) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    l.inflight--
+    if rtt <= 0 {
+        return
+    }
+    now := time.Now()
+    if l.probe > 0 && now.Sub(l.probed) >= l.probe {
+        l.probed = now
+        l.minRTT = 0
+    }
+    if l.minRTT == 0 || rtt < l.minRTT {
+        l.minRTT = rtt
+    }
+    queue := l.limit * (1 - float64(l.minRTT)/float64(rtt))
+    switch {
+    case queue < l.alpha:
+        l.limit += 1
+    case queue > l.beta:
+        l.limit -= 1
+    }
+    l.limit = math.Max(l.minLimit, math.Min(l.maxLimit, l.limit))
+}
+
+// Stats 返回限流器状态快照。
+func (l *Adaptive) Stats() Stats {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    return Stats{
+        Allowed:  l.allowed.Load(),
+        Rejected: l.rejected.Load(),
+        Inflight: l.inflight,
+        Limit:    int64(l.limit),
+    }
+}
@@ -0,0 +1,159 @@
+package ratelimit
+
+import (
+    "context"
+    "net"
+    "net/http"
+
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/metadata"
+    "google.golang.org/grpc/peer"
+    "google.golang.org/grpc/status"
+
+    "github.com/camry/dove/v2/server/ghttp"
+)
+
+// HTTPKeyFunc 定义 HTTP 请求限流键提取函数。
+type HTTPKeyFunc func(r *http.Request) string
+
+// GRPCKeyFunc 定义 gRPC 请求限流键提取函数。
+type GRPCKeyFunc func(ctx context.Context, fullMethod string) string
+
+// HTTPPeerIP 按对端 IP 提取限流键。
+func HTTPPeerIP(r *http.Request) string {
+    if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+        return host
+    }
+    return r.RemoteAddr
+}
+
+// HTTPHeader 按请求头 name 提取限流键。
+func HTTPHeader(name string) HTTPKeyFunc {
+    return func(r *http.Request) string { return r.Header.Get(name) }
+}
+
+// GRPCPeerIP 按对端 IP 提取限流键。
+func GRPCPeerIP(ctx context.Context, _ string) string {
+    p, ok := peer.FromContext(ctx)
+    if !ok || p.Addr == nil {
+        return ""
+    }
+    addr := p.Addr.String()
+    if host, _, err := net.SplitHostPort(addr); err == nil {
+        return host
+    }
+    return addr
+}
+
+// GRPCMetadata 按元数据 key 提取限流键。
+func GRPCMetadata(key string) GRPCKeyFunc {
+    return func(ctx context.Context, _ string) string {
+        if v := metadata.ValueFromIncomingContext(ctx, key); len(v) > 0 {
+            return v[0]
+        }
+        return ""
+    }
+}
+
+// Option 定义一个限流中间件选项类型。
+type Option func(o *option)
+
+// option 限流中间件选项实体对象。
+type option struct {
+    limiter     Limiter
+    concurrency ConcurrencyLimiter
+    httpKey     HTTPKeyFunc
+    grpcKey     GRPCKeyFunc
+}
+
+// WithLimiter 配置按键限流器。
+func WithLimiter(l Limiter) Option {
+    return func(o *option) { o.limiter = l }
+}
+
+// WithConcurrency 配置并发限流器。
+func WithConcurrency(l ConcurrencyLimiter) Option {
+    return func(o *option) { o.concurrency = l }
+}
+
+// HTTPKey 配置 HTTP 限流键提取函数，默认按对端 IP。
+func HTTPKey(fn HTTPKeyFunc) Option {
+    return func(o *option) { o.httpKey = fn }
+}
+
+// GRPCKey 配置 gRPC 限流键提取函数，默认按对端 IP。
+func GRPCKey(fn GRPCKeyFunc) Option {
+    return func(o *option) { o.grpcKey = fn }
+}
+
+func newOption(opts ...Option) option {
+    o := option{
+        httpKey: HTTPPeerIP,
+        grpcKey: GRPCPeerIP,
+    }
+    for _, opt := range opts {
+        opt(&o)
+    }
+    return o
+}
+
+// acquire 依次检查按键限流与并发限流。
+func (o *option) acquire(key string) (func(), bool) {
+    if o.limiter != nil && !o.limiter.Allow(key) {
+        return nil, false
+    }
+    if o.concurrency != nil {
+        done, ok := o.concurrency.Acquire()
+        if !ok {
+            // 被并发限流拒绝的请求不占用速率配额。
+            if r, ok := o.limiter.(Refunder); ok {
+                r.Refund(key)
+            }
+        }
+        return done, ok
+    }
+    return func() {}, true
+}
+
+// Filter 返回 HTTP 限流过滤器，超出限制时响应 429 Too Many Requests。
+func Filter(opts ...Option) ghttp.FilterFunc {
+    o := newOption(opts...)
+    return func(next http.Handler) http.Handler {
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            done, ok := o.acquire(o.httpKey(r))
+            if !ok {
+                http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+                return
+            }
+            defer done()
+            next.ServeHTTP(w, r)
+        })
+    }
+}
+
+// UnaryServerInterceptor 返回 gRPC 一元限流拦截器，超出限制时返回 codes.ResourceExhausted。
+func UnaryServerInterceptor(opts ...Option) grpc.UnaryServerInterceptor {
+    o := newOption(opts...)
+    return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+        done, ok := o.acquire(o.grpcKey(ctx, info.FullMethod))
+        if !ok {
+            return nil, status.Errorf(codes.ResourceExhausted, "%s is rejected by rate limiter", info.FullMethod)
+        }
+        defer done()
+        return handler(ctx, req)
+    }
+}
+
+// StreamServerInterceptor 返回 gRPC 流限流拦截器，超出限制时返回 codes.ResourceExhausted。
+func StreamServerInterceptor(opts ...Option) grpc.StreamServerInterceptor {
+    o := newOption(opts...)
+    return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+        done, ok := o.acquire(o.grpcKey(ss.Context(), info.FullMethod))
+        if !ok {
+            return status.Errorf(codes.ResourceExhausted, "%s is rejected by rate limiter", info.FullMethod)
+        }
+        defer done()
+        return handler(srv, ss)
+    }
+}
@@ -0,0 +1,138 @@
+package ratelimit
+
+import (
+    "context"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/status"
+)
+
+func TestTokenBucket(t *testing.T) {
+    l := NewTokenBucket(10, 2)
+    if !l.Allow("a") || !l.Allow("a") {
+        t.Fatal("expect burst requests allowed")
+    }
+    if l.Allow("a") {
+        t.Fatal("expect request rejected when bucket is empty")
+    }
+    if !l.Allow("b") {
+        t.Fatal("expect other key allowed")
+    }
+    time.Sleep(120 * time.Millisecond)
+    if !l.Allow("a") {
+        t.Fatal("expect token refilled")
+    }
+    if s := l.Stats(); s.Allowed != 4 || s.Rejected != 1 || s.Keys != 2 {
+        t.Fatalf("unexpected stats %+v", s)
+    }
+}
+
+func TestSlidingWindow(t *testing.T) {
+    l := NewSlidingWindow(3, time.Hour)
+    for i := 0; i < 3; i++ {
+        if !l.Allow("a") {
+            t.Fatalf("expect request %d allowed", i)
+        }
+    }
+    if l.Allow("a") {
+        t.Fatal("expect request rejected when window is full")
+    }
+    if !l.Allow("b") {
+        t.Fatal("expect other key allowed")
+    }
+}
+
+func TestSlidingWindow_InvalidSize(t *testing.T) {
+    l := NewSlidingWindow(1, 0)
+    if !l.Allow("a") {
+        t.Fatal("expect first request allowed")
+    }
+    if l.Allow("a") {
+        t.Fatal("expect request rejected with default window size")
+    }
+}
+
+func TestFilter_ConcurrencyRefund(t *testing.T) {
+    release := make(chan struct{})
+    entered := make(chan struct{})
+    h := Filter(WithLimiter(NewTokenBucket(0, 2)), WithConcurrency(NewAdaptive(InitialLimit(1), MinLimit(1), MaxLimit(1))))(
+        http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            if r.URL.Path == "/block" {
+                close(entered)
+                <-release
+            }
+        }))
+    serve := func(path string) int {
+        w := httptest.NewRecorder()
+        h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+        return w.Code
+    }
+    done := make(chan struct{})
+    go func() {
+        serve("/block")
+        close(done)
+    }()
+    <-entered
+    // 被并发限流拒绝的请求应归还速率配额。
+    if code := serve("/"); code != http.StatusTooManyRequests {
+        t.Fatalf("expect concurrency rejected, got %d", code)
+    }
+    close(release)
+    <-done
+    if code := serve("/"); code != http.StatusOK {
+        t.Fatalf("expect rate token refunded, got %d", code)
+    }
+}
+
+func TestAdaptive(t *testing.T) {
+    l := NewAdaptive(InitialLimit(2), MinLimit(1), MaxLimit(2))
+    d1, ok1 := l.Acquire()
+    d2, ok2 := l.Acquire()
+    if !ok1 || !ok2 {
+        t.Fatal("expect requests allowed under limit")
+    }
+    if _, ok := l.Acquire(); ok {
+        t.Fatal("expect request rejected over limit")
+    }
+    if s := l.Stats(); s.Inflight != 2 || s.Limit != 2 {
+        t.Fatalf("unexpected stats %+v", s)
+    }
+    d1()
+    d1()
+    d2()
+    if s := l.Stats(); s.Inflight != 0 {
+        t.Fatalf("expect inflight 0, got %d", s.Inflight)
+    }
+}
+
+func TestFilter(t *testing.T) {
+    h := Filter(WithLimiter(NewTokenBucket(0, 1)), HTTPKey(HTTPHeader("X-Tenant")))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+    got := make([]int, 0, 3)
+    for _, tenant := range []string{"a", "a", "b"} {
+        w := httptest.NewRecorder()
+        r := httptest.NewRequest(http.MethodGet, "/", nil)
+        r.Header.Set("X-Tenant", tenant)
+        h.ServeHTTP(w, r)
+        got = append(got, w.Code)
+    }
+    if got[0] != http.StatusOK || got[1] != http.StatusTooManyRequests || got[2] != http.StatusOK {
+        t.Fatalf("unexpected status codes %v", got)
+    }
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+    in := UnaryServerInterceptor(WithLimiter(NewTokenBucket(0, 1)))
+    info := &grpc.UnaryServerInfo{FullMethod: "/pkg.Svc/Call"}
+    handler := func(ctx context.Context, req any) (any, error) { return "ok", nil }
+    if _, err := in(context.Background(), nil, info, handler); err != nil {
+        t.Fatal(err)
+    }
+    if _, err := in(context.Background(), nil, info, handler); status.Code(err) != codes.ResourceExhausted {
+        t.Fatalf("expect ResourceExhausted, got %v", err)
+    }
+}
@@ -0,0 +1,62 @@
+package registry
+
+import (
+    "context"
+    "errors"
+    "net/url"
+)
+
+// ErrWatcherStopped 监听器已停止。
+var ErrWatcherStopped = errors.New("registry: watcher stopped")
+
+// ServiceInstance 服务实例。
+type ServiceInstance struct {
+    // ID 实例唯一标识。
+    ID string `json:"id"`
+    // Name 服务名称。
+    Name string `json:"name"`
+    // Version 服务版本。
+    Version string `json:"version"`
+    // Metadata 实例元数据。
+    Metadata map[string]string `json:"metadata"`
+    // Endpoints 实例访问地址，形如 grpc://127.0.0.1:9000、http://127.0.0.1:8000。
+    Endpoints []string `json:"endpoints"`
+}
+
+// Endpoint 返回实例指定协议的访问地址（host:port），不存在时返回空字符串。
+func (si *ServiceInstance) Endpoint(scheme string) string {
+    for _, e := range si.Endpoints {
+        u, err := url.Parse(e)
+        if err != nil {
+            continue
+        }
+        if u.Scheme == scheme {
+            return u.Host
+        }
+    }
+    return ""
+}
+
+// Registrar 服务注册接口。
+type Registrar interface {
+    // Register 注册服务实例。
+    Register(ctx context.Context, service *ServiceInstance) error
+    // Deregister 注销服务实例。
+    Deregister(ctx context.Context, service *ServiceInstance) error
+}
+
+// Discovery 服务发现接口。
+type Discovery interface {
+    // GetService 返回服务的全部实例。
+    GetService(ctx context.Context, name string) ([]*ServiceInstance, error)
+    // Watch 新建服务实例监听器。
+    Watch(ctx context.Context, name string) (Watcher, error)
+}
+
+// Watcher 服务实例监听器。
+type Watcher interface {
+    // Next 首次调用立即返回当前实例，之后阻塞直到实例变更、上下文结束或监听器停止。
+    Next() ([]*ServiceInstance, error)
+    // Stop 停止监听。
+    Stop() error
+}
@@ -0,0 +1,107 @@
+package registry
+
+import (
+    "context"
+    "slices"
+    "sync"
+)
+
+var (
+    _ Registrar = (*Memory)(nil)
+    _ Discovery = (*Memory)(nil)
+)
+
+// Memory 进程内服务注册中心，适用于静态配置与测试。
+type Memory struct {
+    mu        sync.RWMutex
+    instances map[string][]*ServiceInstance
+    watchers  map[string]map[*memoryWatcher]struct{}
+}
+
+// NewMemory 新建进程内服务注册中心。
+func NewMemory() *Memory {
+    return &Memory{
+        instances: make(map[string][]*ServiceInstance),
+        watchers:  make(map[string]map[*memoryWatcher]struct{}),
+    }
+}
+
+// Register 注册服务实例，相同 ID 的实例将被替换。
+func (m *Memory) Register(ctx context.Context, service *ServiceInstance) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    list := slices.DeleteFunc(slices.Clone(m.instances[service.Name]), func(si *ServiceInstance) bool {
+        return si.ID == service.ID
+    })
+    m.instances[service.Name] = append(list, service)
+    m.notify(service.Name)
+    return nil
+}
+
+// Deregister 注销服务实例。
+func (m *Memory) Deregister(ctx context.Context, service *ServiceInstance) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    m.instances[service.Name] = slices.DeleteFunc(slices.Clone(m.instances[service.Name]), func(si *ServiceInstance) bool {
+        return si.ID == service.ID
+    })
+    m.notify(service.Name)
+    return nil
+}
+
+// GetService 返回服务的全部实例。
+func (m *Memory) GetService(ctx context.Context, name string) ([]*ServiceInstance, error) {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+    return slices.Clone(m.instances[name]), nil
+}
+
+// Watch 新建服务实例监听器。
+func (m *Memory) Watch(ctx context.Context, name string) (Watcher, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    ctx, cancel := context.WithCancel(ctx)
+    w := &memoryWatcher{m: m, name: name, ctx: ctx, cancel: cancel, event: make(chan struct{}, 1)}
+    w.event <- struct{}{}
+    if m.watchers[name] == nil {
+        m.watchers[name] = make(map[*memoryWatcher]struct{})
+    }
+    m.watchers[name][w] = struct{}{}
+    return w, nil
+}
+
+// notify 通知服务的全部监听器，调用方需持有写锁。
+func (m *Memory) notify(name string) {
+    for w := range m.watchers[name] {
+        select {
+        case w.event <- struct{}{}:
+        default:
+        }
+    }
+}
+
+// memoryWatcher 进程内服务实例监听器。
+type memoryWatcher struct {
+    m      *Memory
+    name   string
+    ctx    context.Context
+    cancel context.CancelFunc
+    event  chan struct{}
+}
+
+func (w *memoryWatcher) Next() ([]*ServiceInstance, error) {
+    select {
+    case <-w.ctx.Done():
+        return nil, ErrWatcherStopped
+    case <-w.event:
+    }
+    return w.m.GetService(w.ctx, w.name)
+}
+
+func (w *memoryWatcher) Stop() error {
+    w.cancel()
+    w.m.mu.Lock()
+    delete(w.m.watchers[w.name], w)
+    w.m.mu.Unlock()
+    return nil
+}
@@ -0,0 +1,35 @@
+package requestid
+
+import (
+    "context"
+
+    "github.com/google/uuid"
+)
+
+const (
+    // Header HTTP 请求 ID 头。
+    Header = "X-Request-Id"
+    // MetadataKey gRPC 请求 ID 元数据键。
+    MetadataKey = "x-request-id"
+)
+
+type requestIDKey struct{}
+
+// New 生成新的请求 ID。
+func New() string {
+    if id, err := uuid.NewRandom(); err == nil {
+        return id.String()
+    }
+    return ""
+}
+
+// NewContext 返回一个带有请求 ID 的新上下文。
+func NewContext(ctx context.Context, id string) context.Context {
+    return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// FromContext 返回存储在 ctx 中的请求 ID（如果有）。
+func FromContext(ctx context.Context) (id string, ok bool) {
+    id, ok = ctx.Value(requestIDKey{}).(string)
+    return
+}
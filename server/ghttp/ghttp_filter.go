@@ -0,0 +1,16 @@
+package ghttp
+
+import "net/http"
+
+// FilterFunc 定义 HTTP 过滤器（中间件）类型。
+type FilterFunc func(http.Handler) http.Handler
+
+// FilterChain 将多个过滤器组合为一个，第一个过滤器位于最外层。
+func FilterChain(filters ...FilterFunc) FilterFunc {
+    return func(next http.Handler) http.Handler {
+        for i := len(filters) - 1; i >= 0; i-- {
+            next = filters[i](next)
+        }
+        return next
+    }
+}
@@ -4,15 +4,23 @@ import (
     "context"
     "crypto/tls"
     "errors"
+    "fmt"
     "net"
     "net/http"
+    "sync"
+    "sync/atomic"
+    "time"
 
     "github.com/camry/g/v2/glog"
 
+    "github.com/camry/dove/v2/proxyproto"
     "github.com/camry/dove/v2/server"
 )
 
-var _ server.Server = (*Server)(nil)
+var (
+    _ server.Server   = (*Server)(nil)
+    _ server.Listener = (*Server)(nil)
+)
 
 // ServerOption 定义一个 HTTP 服务选项类型。
 type ServerOption func(s *Server)
@@ -20,12 +28,22 @@ type ServerOption func(s *Server)
 // Server 定义 HTTP 服务包装器。
 type Server struct {
     *http.Server
-    err     error
+    mu      sync.Mutex
     network string
     address string
     tlsConf *tls.Config
     lis     net.Listener
+    serving bool
+    closed  bool
     handler http.Handler
+    filters []FilterFunc
+
+    drainDelay time.Duration
+    draining   atomic.Bool
+    healthPath string
+
+    proxyProtocol bool
+    proxyOpts     []proxyproto.Option
 }
 
 // Address 配置服务监听地址。
@@ -43,6 +61,36 @@ func Handler(handler http.Handler) ServerOption {
     return func(s *Server) { s.handler = handler }
 }
 
+// Filter 配置 HTTP 过滤器（中间件），可多次调用累加，按配置顺序由外向内执行。
+func Filter(filters ...FilterFunc) ServerOption {
+    return func(s *Server) { s.filters = append(s.filters, filters...) }
+}
+
+// DrainDelay 配置停止时的排空等待时间。
+//
+// 停止开始后服务器进入排空状态，响应携带 Connection: close 并关闭空闲连接，
+// 配置 HealthPath 时健康检查返回 503，等待 d 后（不超过停止超时）再执行 Shutdown。
+func DrainDelay(d time.Duration) ServerOption {
+    return func(s *Server) { s.drainDelay = d }
+}
+
+// HealthPath 配置健康检查路径，服务中返回 200，排空状态返回 503，
+// 配合 DrainDelay 使负载均衡在停止前摘除流量，该路径不经过 Filter 配置的过滤器。
+func HealthPath(path string) ServerOption {
+    return func(s *Server) { s.healthPath = path }
+}
+
+// ProxyProtocol 配置解析 PROXY 协议 v1/v2 头。
+//
+// 仅解析 proxyproto.Trusted 配置的可信来源的协议头，未配置时 Listen 返回 proxyproto.ErrNoTrusted。
+// 请求的 RemoteAddr 为协议头中的客户端地址，协议头可通过 proxyproto.FromContext 从请求上下文获取。
+func ProxyProtocol(opts ...proxyproto.Option) ServerOption {
+    return func(s *Server) {
+        s.proxyProtocol = true
+        s.proxyOpts = opts
+    }
+}
+
 // NewServer 新建 HTTP 服务器。
 func NewServer(opts ...ServerOption) *Server {
     srv := &Server{
@@ -52,28 +100,83 @@ func NewServer(opts ...ServerOption) *Server {
     for _, opt := range opts {
         opt(srv)
     }
+    handler := srv.handler
+    if handler == nil {
+        handler = http.DefaultServeMux
+    }
+    handler = FilterChain(srv.filters...)(handler)
+    if srv.healthPath != "" {
+        handler = srv.healthHandler(handler)
+    }
     srv.Server = &http.Server{
-        Handler:   srv.handler,
+        Handler:   handler,
         TLSConfig: srv.tlsConf,
     }
-    srv.err = srv.listen()
+    if srv.proxyProtocol {
+        srv.ConnContext = proxyproto.NewContext
+    }
     return srv
 }
 
+// Listen 绑定 HTTP 服务监听地址，已停止的服务器返回 http.ErrServerClosed。
+func (s *Server) Listen(ctx context.Context) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if s.closed {
+        return http.ErrServerClosed
+    }
+    if s.lis != nil {
+        return nil
+    }
+    lis, err := net.Listen(s.network, s.address)
+    if err != nil {
+        return fmt.Errorf("[HTTP] server listen on %s failed: %w", s.address, err)
+    }
+    if s.proxyProtocol {
+        pl, err := proxyproto.NewListener(lis, s.proxyOpts...)
+        if err != nil {
+            _ = lis.Close()
+            return fmt.Errorf("[HTTP] server listen on %s failed: %w", s.address, err)
+        }
+        lis = pl
+    }
+    s.lis = lis
+    return nil
+}
+
 // Start 启动 HTTP 服务。
 func (s *Server) Start(ctx context.Context) error {
-    if s.err != nil {
-        return s.err
+    s.mu.Lock()
+    closed := s.closed
+    s.mu.Unlock()
+    if closed {
+        // 启动前已停止时不再绑定端口。
+        return nil
     }
+    if err := s.Listen(ctx); err != nil {
+        if errors.Is(err, http.ErrServerClosed) {
+            return nil
+        }
+        return err
+    }
+    s.mu.Lock()
+    lis := s.lis
+    if lis == nil {
+        // 监听后启动前已被停止。
+        s.mu.Unlock()
+        return nil
+    }
+    s.serving = true
+    s.mu.Unlock()
     s.BaseContext = func(net.Listener) context.Context {
         return ctx
     }
-    glog.Infof("[HTTP] server listening on: %s", s.lis.Addr().String())
+    glog.Infof("[HTTP] server listening on: %s", lis.Addr().String())
     var err error
     if s.tlsConf != nil {
-        err = s.ServeTLS(s.lis, "", "")
+        err = s.ServeTLS(lis, "", "")
     } else {
-        err = s.Serve(s.lis)
+        err = s.Serve(lis)
     }
     if !errors.Is(err, http.ErrServerClosed) {
         return err
@@ -84,15 +187,61 @@ func (s *Server) Start(ctx context.Context) error {
 // Stop 停止 HTTP 服务。
 func (s *Server) Stop(ctx context.Context) error {
     glog.Info("[HTTP] server stopping")
-    return s.Shutdown(ctx)
+    s.draining.Store(true)
+    s.SetKeepAlivesEnabled(false)
+    s.mu.Lock()
+    s.closed = true
+    serving := s.serving
+    s.mu.Unlock()
+    // 未启动服务时没有需要排空的请求，例如启动失败时释放资源。
+    if serving && s.drainDelay > 0 {
+        timer := time.NewTimer(s.drainDelay)
+        select {
+        case <-ctx.Done():
+        case <-timer.C:
+        }
+        timer.Stop()
+    }
+    err := s.Shutdown(ctx)
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    // 已绑定但未启动服务的监听器需要手动释放。
+    if s.lis != nil && !s.serving {
+        if cErr := s.lis.Close(); cErr != nil && !errors.Is(cErr, net.ErrClosed) && err == nil {
+            err = cErr
+        }
+    }
+    s.lis = nil
+    s.serving = false
+    return err
 }
 
-// listen 网络监听。
-func (s *Server) listen() error {
-    lis, err := net.Listen(s.network, s.address)
-    if err != nil {
-        return err
+// Draining 返回服务器是否处于排空状态。
+func (s *Server) Draining() bool {
+    return s.draining.Load()
+}
+
+// healthHandler 响应健康检查路径，排空状态返回 503。
+func (s *Server) healthHandler(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path != s.healthPath {
+            next.ServeHTTP(w, r)
+            return
+        }
+        if s.Draining() {
+            w.WriteHeader(http.StatusServiceUnavailable)
+            return
+        }
+        w.WriteHeader(http.StatusOK)
+    })
+}
+
+// ListenAddr 返回实际监听地址，未监听时返回 nil。
+func (s *Server) ListenAddr() net.Addr {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if s.lis == nil {
+        return nil
     }
-    s.lis = lis
-    return nil
+    return s.lis.Addr()
 }
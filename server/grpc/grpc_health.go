@@ -0,0 +1,140 @@
+package grpc
+
+import (
+    "context"
+    "sync"
+    "time"
+
+    "github.com/camry/g/v2/glog"
+    "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// HealthChecker 定义服务健康检查函数，返回 nil 表示服务正常。
+type HealthChecker func(ctx context.Context) error
+
+// healthCheck 定时轮询的服务健康检查。
+type healthCheck struct {
+    service  string
+    interval time.Duration
+    checker  HealthChecker
+}
+
+// HealthCheck 配置服务健康检查，服务器启动后每隔 interval 执行一次 checker 并更新 service 的服务状态。
+func HealthCheck(service string, interval time.Duration, checker HealthChecker) ServerOption {
+    return func(s *Server) {
+        s.healthChecks = append(s.healthChecks, healthCheck{service: service, interval: interval, checker: checker})
+    }
+}
+
+// Health 配置是否注册健康检查服务，默认注册。
+func Health(enable bool) ServerOption {
+    return func(s *Server) { s.healthEnabled = enable }
+}
+
+// healthState 服务健康状态管理。
+type healthState struct {
+    mu       sync.Mutex
+    statuses map[string]grpc_health_v1.HealthCheckResponse_ServingStatus // 显式设置的服务状态。
+    cancel   context.CancelFunc
+    wg       sync.WaitGroup
+}
+
+// SetServingStatus 设置服务的健康状态，service 为空表示服务器整体状态。
+//
+// 服务器停止后状态固定为 NOT_SERVING，设置将在下次启动时生效。
+func (s *Server) SetServingStatus(service string, status grpc_health_v1.HealthCheckResponse_ServingStatus) {
+    s.healthState.mu.Lock()
+    defer s.healthState.mu.Unlock()
+    s.healthState.statuses[service] = status
+    s.health.SetServingStatus(service, status)
+}
+
+// ServingStatus 返回服务的健康状态。
+func (s *Server) ServingStatus(service string) grpc_health_v1.HealthCheckResponse_ServingStatus {
+    resp, err := s.health.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: service})
+    if err != nil {
+        return grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN
+    }
+    return resp.GetStatus()
+}
+
+// resumeHealth 恢复健康状态，为已注册的服务填充 SERVING 状态并启动健康检查。
+func (s *Server) resumeHealth(ctx context.Context) {
+    st := &s.healthState
+    st.mu.Lock()
+    defer st.mu.Unlock()
+    s.health.Resume()
+    for name := range s.GetServiceInfo() {
+        if _, ok := st.statuses[name]; !ok && !internalService(name) {
+            s.health.SetServingStatus(name, grpc_health_v1.HealthCheckResponse_SERVING)
+        }
+    }
+    for name, status := range st.statuses {
+        s.health.SetServingStatus(name, status)
+    }
+    if len(s.healthChecks) == 0 {
+        return
+    }
+    ctx, st.cancel = context.WithCancel(ctx)
+    for _, hc := range s.healthChecks {
+        st.wg.Add(1)
+        go func() {
+            defer st.wg.Done()
+            s.runHealthCheck(ctx, hc)
+        }()
+    }
+}
+
+// shutdownHealth 停止健康检查，并将所有服务状态置为 NOT_SERVING。
+func (s *Server) shutdownHealth() {
+    st := &s.healthState
+    st.mu.Lock()
+    cancel := st.cancel
+    st.cancel = nil
+    st.mu.Unlock()
+    if cancel != nil {
+        cancel()
+        st.wg.Wait()
+    }
+    s.health.Shutdown()
+}
+
+// runHealthCheck 定时执行健康检查。
+func (s *Server) runHealthCheck(ctx context.Context, hc healthCheck) {
+    interval := hc.interval
+    if interval <= 0 {
+        interval = 10 * time.Second
+    }
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+    for {
+        cctx, cancel := context.WithTimeout(ctx, interval)
+        err := hc.checker(cctx)
+        cancel()
+        if ctx.Err() != nil {
+            return
+        }
+        status := grpc_health_v1.HealthCheckResponse_SERVING
+        if err != nil {
+            status = grpc_health_v1.HealthCheckResponse_NOT_SERVING
+            glog.Warnf("[gRPC] health check %s failed: %v", hc.service, err)
+        }
+        s.health.SetServingStatus(hc.service, status)
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        }
+    }
+}
+
+// internalService 判断是否为内部注册的服务。
+func internalService(name string) bool {
+    switch name {
+    case grpc_health_v1.Health_ServiceDesc.ServiceName,
+        "grpc.reflection.v1.ServerReflection",
+        "grpc.reflection.v1alpha.ServerReflection":
+        return true
+    }
+    return false
+}
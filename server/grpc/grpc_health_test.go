@@ -0,0 +1,47 @@
+package grpc
+
+import (
+    "context"
+    "errors"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestHealth(t *testing.T) {
+    var healthy atomic.Bool
+    s := NewServer(
+        Address("127.0.0.1:0"),
+        HealthCheck("pkg.Billing", 10*time.Millisecond, func(ctx context.Context) error {
+            if healthy.Load() {
+                return nil
+            }
+            return errors.New("database is down")
+        }),
+    )
+    s.SetServingStatus("pkg.Manual", grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+    go func() { _ = s.Start(context.Background()) }()
+    time.Sleep(50 * time.Millisecond)
+
+    if got := s.ServingStatus(""); got != grpc_health_v1.HealthCheckResponse_SERVING {
+        t.Errorf("expect overall SERVING, got %v", got)
+    }
+    if got := s.ServingStatus("pkg.Manual"); got != grpc_health_v1.HealthCheckResponse_NOT_SERVING {
+        t.Errorf("expect pkg.Manual NOT_SERVING, got %v", got)
+    }
+    if got := s.ServingStatus("pkg.Billing"); got != grpc_health_v1.HealthCheckResponse_NOT_SERVING {
+        t.Errorf("expect pkg.Billing NOT_SERVING, got %v", got)
+    }
+    healthy.Store(true)
+    time.Sleep(50 * time.Millisecond)
+    if got := s.ServingStatus("pkg.Billing"); got != grpc_health_v1.HealthCheckResponse_SERVING {
+        t.Errorf("expect pkg.Billing SERVING, got %v", got)
+    }
+
+    _ = s.Stop(context.Background())
+    if got := s.ServingStatus("pkg.Billing"); got != grpc_health_v1.HealthCheckResponse_NOT_SERVING {
+        t.Errorf("expect pkg.Billing NOT_SERVING after stop, got %v", got)
+    }
+}
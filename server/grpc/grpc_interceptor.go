@@ -1,20 +1,80 @@
 package grpc
 
 import (
+    "cmp"
     "context"
+    "errors"
+    "slices"
+    "strings"
+    "time"
 
     "google.golang.org/grpc"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/health/grpc_health_v1"
+    v1reflectiongrpc "google.golang.org/grpc/reflection/grpc_reflection_v1"
+    v1alphareflectiongrpc "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+    "google.golang.org/grpc/status"
 
     ic "github.com/camry/dove/v2/internal/context"
 )
 
-// unaryServerInterceptor 默认 gRPC 一元拦截器。
+const (
+    // PriorityDefault 默认拦截器优先级，优先级更小的拦截器在默认拦截器之前执行。
+    PriorityDefault = 0
+    // PriorityValidator 请求校验拦截器优先级，位于同优先级的用户拦截器（如认证）之后。
+    PriorityValidator = 100
+)
+
+// unaryInterceptor 带优先级的一元拦截器。
+type unaryInterceptor struct {
+    priority int
+    in       grpc.UnaryServerInterceptor
+}
+
+// streamInterceptor 带优先级的流拦截器。
+type streamInterceptor struct {
+    priority int
+    in       grpc.StreamServerInterceptor
+}
+
+// unaryChain 返回按优先级排序的一元拦截器链，默认拦截器位于同优先级拦截器之前。
+func (s *Server) unaryChain() []grpc.UnaryServerInterceptor {
+    entries := make([]unaryInterceptor, 0, len(s.unaryInterceptors)+1)
+    if s.defaultUnary != nil {
+        entries = append(entries, unaryInterceptor{priority: PriorityDefault, in: s.defaultUnary})
+    }
+    entries = append(entries, s.unaryInterceptors...)
+    slices.SortStableFunc(entries, func(a, b unaryInterceptor) int { return cmp.Compare(a.priority, b.priority) })
+    chain := make([]grpc.UnaryServerInterceptor, len(entries))
+    for i, e := range entries {
+        chain[i] = e.in
+    }
+    return chain
+}
+
+// streamChain 返回按优先级排序的流拦截器链，默认拦截器位于同优先级拦截器之前。
+func (s *Server) streamChain() []grpc.StreamServerInterceptor {
+    entries := make([]streamInterceptor, 0, len(s.streamInterceptors)+1)
+    if s.defaultStream != nil {
+        entries = append(entries, streamInterceptor{priority: PriorityDefault, in: s.defaultStream})
+    }
+    entries = append(entries, s.streamInterceptors...)
+    slices.SortStableFunc(entries, func(a, b streamInterceptor) int { return cmp.Compare(a.priority, b.priority) })
+    chain := make([]grpc.StreamServerInterceptor, len(entries))
+    for i, e := range entries {
+        chain[i] = e.in
+    }
+    return chain
+}
+
+// unaryServerInterceptor 默认 gRPC 一元拦截器，合并 baseCtx、提取元数据并应用超时。
 func (s *Server) defaultUnaryServerInterceptor() grpc.UnaryServerInterceptor {
     return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
         ctx, cancel := ic.Merge(ctx, s.baseCtx)
         defer cancel()
-        if s.timeout > 0 {
-            ctx, cancel = context.WithTimeout(ctx, s.timeout)
+        ctx = s.metadata.ExtractGRPC(ctx)
+        if timeout := s.methodTimeout(info.FullMethod, s.timeout); timeout > 0 {
+            ctx, cancel = context.WithTimeout(ctx, timeout)
             defer cancel()
         }
         h := func(ctx context.Context, req interface{}) (interface{}, error) {
@@ -25,21 +85,72 @@ func (s *Server) defaultUnaryServerInterceptor() grpc.UnaryServerInterceptor {
     }
 }
 
-// streamServerInterceptor 默认 gRPC 流拦截器。
+// errStreamIdle 流空闲超时错误。
+var errStreamIdle = errors.New("stream idle timeout")
+
+// longLivedServices 内置的长连接流服务，不应用流超时与空闲超时。
+var longLivedServices = map[string]bool{
+    grpc_health_v1.Health_ServiceDesc.ServiceName:                  true,
+    v1reflectiongrpc.ServerReflection_ServiceDesc.ServiceName:      true,
+    v1alphareflectiongrpc.ServerReflection_ServiceDesc.ServiceName: true,
+}
+
+// streamServerInterceptor 默认 gRPC 流拦截器，合并 baseCtx、提取元数据并应用超时。
+//
+// 流超时仅在配置 MethodTimeout、ServiceTimeout 或 StreamTimeout 时生效，全局 Timeout 只作用于一元调用。
 func (s *Server) defaultStreamServerInterceptor() grpc.StreamServerInterceptor {
     return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
         ctx, cancel := ic.Merge(ss.Context(), s.baseCtx)
         defer cancel()
-        ws := NewWrappedStream(ctx, ss)
-        err := handler(srv, ws)
-        return err
+        ctx = s.metadata.ExtractGRPC(ctx)
+        if longLivedServices[serviceName(info.FullMethod)] {
+            return handler(srv, NewWrappedStream(ctx, ss))
+        }
+        ws := &wrappedStream{ServerStream: ss, ctx: ctx}
+        if timeout := s.methodTimeout(info.FullMethod, s.streamTimeout); timeout > 0 {
+            ws.ctx, cancel = context.WithTimeout(ws.ctx, timeout)
+            defer cancel()
+            ws.interruptible = true
+        }
+        if s.streamIdleTimeout > 0 {
+            var cancelCause context.CancelCauseFunc
+            ws.ctx, cancelCause = context.WithCancelCause(ws.ctx)
+            defer cancelCause(nil)
+            ws.idleTimeout = s.streamIdleTimeout
+            ws.idle = time.AfterFunc(s.streamIdleTimeout, func() { cancelCause(errStreamIdle) })
+            defer ws.idle.Stop()
+            ws.interruptible = true
+        }
+        return handler(srv, ws)
     }
 }
 
+// methodTimeout 返回方法的超时时间，依次匹配方法、服务配置，均未配置时返回 fallback。
+func (s *Server) methodTimeout(fullMethod string, fallback time.Duration) time.Duration {
+    if d, ok := s.methodTimeouts[fullMethod]; ok {
+        return d
+    }
+    if d, ok := s.serviceTimeouts[serviceName(fullMethod)]; ok {
+        return d
+    }
+    return fallback
+}
+
+// serviceName 返回完整方法名中的服务名，如 /pkg.Svc/Method 返回 pkg.Svc。
+func serviceName(fullMethod string) string {
+    if i := strings.LastIndexByte(fullMethod, '/'); i > 0 {
+        return fullMethod[1:i]
+    }
+    return ""
+}
+
 // wrappedStream 重写 gRPC 流上下文。
 type wrappedStream struct {
     grpc.ServerStream
-    ctx context.Context
+    ctx           context.Context
+    idle          *time.Timer
+    idleTimeout   time.Duration
+    interruptible bool // 配置了流超时，阻塞中的 RecvMsg 需在上下文结束时返回。
 }
 
 func NewWrappedStream(ctx context.Context, stream grpc.ServerStream) grpc.ServerStream {
@@ -52,3 +163,55 @@ func NewWrappedStream(ctx context.Context, stream grpc.ServerStream) grpc.Server
 func (w *wrappedStream) Context() context.Context {
     return w.ctx
 }
+
+// SendMsg 上下文结束后返回对应状态错误，并刷新空闲计时。
+func (w *wrappedStream) SendMsg(m any) error {
+    if err := w.err(); err != nil {
+        return err
+    }
+    w.touch()
+    err := w.ServerStream.SendMsg(m)
+    w.touch()
+    return err
+}
+
+// RecvMsg 上下文结束后返回对应状态错误，并刷新空闲计时。
+//
+// 配置了流超时时，上下文结束会使阻塞中的 RecvMsg 立即返回状态错误，处理器随之返回后 gRPC 结束传输流，
+// 底层读取随即以错误结束；此后的 RecvMsg 不再访问底层流，避免并发读取。
+func (w *wrappedStream) RecvMsg(m any) error {
+    if err := w.err(); err != nil {
+        return err
+    }
+    w.touch()
+    defer w.touch()
+    if !w.interruptible {
+        return w.ServerStream.RecvMsg(m)
+    }
+    done := make(chan error, 1)
+    go func() { done <- w.ServerStream.RecvMsg(m) }()
+    select {
+    case err := <-done:
+        return err
+    case <-w.ctx.Done():
+        return w.err()
+    }
+}
+
+// touch 刷新空闲计时。
+func (w *wrappedStream) touch() {
+    if w.idle != nil && w.ctx.Err() == nil {
+        w.idle.Reset(w.idleTimeout)
+    }
+}
+
+// err 将上下文错误转换为 gRPC 状态错误。
+func (w *wrappedStream) err() error {
+    if w.ctx.Err() == nil {
+        return nil
+    }
+    if errors.Is(context.Cause(w.ctx), errStreamIdle) {
+        return status.Error(codes.DeadlineExceeded, errStreamIdle.Error())
+    }
+    return status.FromContextError(w.ctx.Err()).Err()
+}
@@ -0,0 +1,205 @@
+package grpc
+
+import (
+    "context"
+    "reflect"
+    "testing"
+    "time"
+
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/credentials/insecure"
+    "google.golang.org/grpc/health/grpc_health_v1"
+    "google.golang.org/grpc/status"
+    "google.golang.org/protobuf/types/known/emptypb"
+)
+
+func recordUnary(name string, got *[]string) grpc.UnaryServerInterceptor {
+    return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+        *got = append(*got, name)
+        return handler(ctx, req)
+    }
+}
+
+// invokeUnary 按顺序执行一元拦截器链。
+func invokeUnary(chain []grpc.UnaryServerInterceptor) {
+    var next grpc.UnaryHandler = func(ctx context.Context, req any) (any, error) { return nil, nil }
+    for i := len(chain) - 1; i >= 0; i-- {
+        in, h := chain[i], next
+        next = func(ctx context.Context, req any) (any, error) {
+            return in(ctx, req, &grpc.UnaryServerInfo{}, h)
+        }
+    }
+    _, _ = next(context.Background(), nil)
+}
+
+func TestUnaryInterceptorOrder(t *testing.T) {
+    var got []string
+    s := &Server{}
+    for _, o := range []ServerOption{
+        UnaryInterceptor(recordUnary("a", &got)),
+        UnaryInterceptor(recordUnary("b", &got)),
+        OrderedUnaryInterceptor(-10, recordUnary("first", &got)),
+        OrderedUnaryInterceptor(10, recordUnary("last", &got)),
+        DefaultUnaryInterceptor(recordUnary("default", &got)),
+    } {
+        o(s)
+    }
+    invokeUnary(s.unaryChain())
+    want := []string{"first", "default", "a", "b", "last"}
+    if !reflect.DeepEqual(want, got) {
+        t.Fatalf("expect %v, got %v", want, got)
+    }
+}
+
+func TestDisableDefaultInterceptor(t *testing.T) {
+    s := NewServer(DisableDefaultInterceptor(), StreamInterceptor(func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+        return handler(srv, ss)
+    }))
+    if n := len(s.unaryChain()); n != 0 {
+        t.Fatalf("expect no unary interceptor, got %d", n)
+    }
+    if n := len(s.streamChain()); n != 1 {
+        t.Fatalf("expect 1 stream interceptor, got %d", n)
+    }
+}
+
+func TestMethodTimeout(t *testing.T) {
+    s := NewServer(
+        Timeout(time.Second),
+        ServiceTimeout("pkg.Svc", 5*time.Second),
+        MethodTimeout("/pkg.Svc/Export", 30*time.Second),
+        MethodTimeout("pkg.Svc/Watch", 0),
+    )
+    cases := map[string]time.Duration{
+        "/pkg.Svc/Export": 30 * time.Second,
+        "/pkg.Svc/Watch":  0,
+        "/pkg.Svc/Get":    5 * time.Second,
+        "/pkg.Other/Get":  time.Second,
+    }
+    for method, want := range cases {
+        if got := s.methodTimeout(method, s.timeout); got != want {
+            t.Errorf("%s: expect %v, got %v", method, want, got)
+        }
+    }
+}
+
+// idleStreamDesc 测试用双向流服务，处理器阻塞在 RecvMsg 等待客户端消息。
+var idleStreamDesc = grpc.ServiceDesc{
+    ServiceName: "test.Idle",
+    HandlerType: (*any)(nil),
+    Streams: []grpc.StreamDesc{{
+        StreamName:    "Watch",
+        ServerStreams: true,
+        ClientStreams: true,
+        Handler: func(srv any, stream grpc.ServerStream) error {
+            err := stream.RecvMsg(new(emptypb.Empty))
+            srv.(chan error) <- err
+            return err
+        },
+    }},
+}
+
+func TestStreamTimeout(t *testing.T) {
+    cases := []struct {
+        name string
+        opt  ServerOption
+    }{
+        {"method", MethodTimeout("/test.Idle/Watch", 100*time.Millisecond)},
+        {"idle", StreamIdleTimeout(100 * time.Millisecond)},
+        {"stream", StreamTimeout(100 * time.Millisecond)},
+    }
+    for _, c := range cases {
+        t.Run(c.name, func(t *testing.T) {
+            returned := make(chan error, 1)
+            s := NewServer(Address("127.0.0.1:0"), Timeout(0), c.opt)
+            s.RegisterService(&idleStreamDesc, returned)
+            if err := s.Listen(context.Background()); err != nil {
+                t.Fatal(err)
+            }
+            go func() { _ = s.Start(context.Background()) }()
+            defer s.Stop(context.Background())
+
+            conn, err := grpc.NewClient(s.ListenAddr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+            if err != nil {
+                t.Fatal(err)
+            }
+            defer conn.Close()
+            ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+            defer cancel()
+            stream, err := conn.NewStream(ctx, &idleStreamDesc.Streams[0], "/test.Idle/Watch")
+            if err != nil {
+                t.Fatal(err)
+            }
+            // 客户端保持空闲，服务端阻塞中的 RecvMsg 应被超时中断。
+            start := time.Now()
+            if err := stream.RecvMsg(new(emptypb.Empty)); status.Code(err) != codes.DeadlineExceeded {
+                t.Fatalf("expect DeadlineExceeded, got %v", err)
+            }
+            if d := time.Since(start); d > 2*time.Second {
+                t.Fatalf("expect timeout applied, took %v", d)
+            }
+            select {
+            case <-returned:
+            case <-time.After(2 * time.Second):
+                t.Fatal("expect handler RecvMsg unblocked")
+            }
+        })
+    }
+}
+
+func TestStreamTimeout_Exempt(t *testing.T) {
+    returned := make(chan error, 1)
+    // 全局 Timeout 只作用于一元调用，健康检查 Watch 不受流超时影响。
+    s := NewServer(Address("127.0.0.1:0"), Timeout(50*time.Millisecond),
+        ServiceTimeout(grpc_health_v1.Health_ServiceDesc.ServiceName, 50*time.Millisecond))
+    s.RegisterService(&idleStreamDesc, returned)
+    if err := s.Listen(context.Background()); err != nil {
+        t.Fatal(err)
+    }
+    go func() { _ = s.Start(context.Background()) }()
+    defer s.Stop(context.Background())
+
+    conn, err := grpc.NewClient(s.ListenAddr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer conn.Close()
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+
+    watch, err := grpc_health_v1.NewHealthClient(conn).Watch(ctx, &grpc_health_v1.HealthCheckRequest{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if _, err = watch.Recv(); err != nil {
+        t.Fatal(err)
+    }
+    watchErr := make(chan error, 1)
+    go func() {
+        _, err := watch.Recv()
+        watchErr <- err
+    }()
+
+    stream, err := conn.NewStream(ctx, &idleStreamDesc.Streams[0], "/test.Idle/Watch")
+    if err != nil {
+        t.Fatal(err)
+    }
+    time.Sleep(200 * time.Millisecond)
+    if err = stream.SendMsg(new(emptypb.Empty)); err != nil {
+        t.Fatal(err)
+    }
+    select {
+    case err = <-returned:
+        if err != nil {
+            t.Fatalf("expect stream without timeout, got %v", err)
+        }
+    case <-time.After(2 * time.Second):
+        t.Fatal("expect handler received message")
+    }
+    select {
+    case err = <-watchErr:
+        t.Fatalf("expect health watch kept open, got %v", err)
+    default:
+    }
+}
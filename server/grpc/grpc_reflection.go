@@ -0,0 +1,108 @@
+package grpc
+
+import (
+    "context"
+    "net"
+
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/health/grpc_health_v1"
+    "google.golang.org/grpc/peer"
+    "google.golang.org/grpc/reflection"
+    v1reflectiongrpc "google.golang.org/grpc/reflection/grpc_reflection_v1"
+    v1alphareflectiongrpc "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+    "google.golang.org/grpc/status"
+)
+
+// ReflectionVersion 定义 gRPC 反射服务版本。
+type ReflectionVersion int
+
+const (
+    ReflectionV1      ReflectionVersion = iota // ReflectionV1 grpc.reflection.v1 反射服务。
+    ReflectionV1Alpha                          // ReflectionV1Alpha grpc.reflection.v1alpha 反射服务。
+)
+
+// Reflection 配置启用的反射服务版本，默认同时启用 v1 与 v1alpha，不传参数时禁用反射服务。
+func Reflection(versions ...ReflectionVersion) ServerOption {
+    return func(s *Server) { s.reflectionVersions = versions }
+}
+
+// ReflectionAuthorizer 配置反射服务访问控制，fn 返回非 nil 错误时拒绝访问。
+func ReflectionAuthorizer(fn func(ctx context.Context) error) ServerOption {
+    return func(s *Server) { s.reflectionAuthorizer = fn }
+}
+
+// LoopbackOnly 仅允许回环地址的对端访问，可用于 ReflectionAuthorizer。
+func LoopbackOnly(ctx context.Context) error {
+    if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+        if addr, ok := p.Addr.(*net.TCPAddr); ok && addr.IP.IsLoopback() {
+            return nil
+        }
+        if _, ok := p.Addr.(*net.UnixAddr); ok {
+            return nil
+        }
+    }
+    return status.Error(codes.PermissionDenied, "reflection is only available on loopback")
+}
+
+// registerInternal 注册内部健康检查与反射服务。
+func (s *Server) registerInternal() {
+    if s.healthEnabled {
+        grpc_health_v1.RegisterHealthServer(s.Server, s.health)
+    }
+    opts := reflection.ServerOptions{Services: s.Server}
+    for _, v := range s.reflectionVersions {
+        switch v {
+        case ReflectionV1:
+            var svr v1reflectiongrpc.ServerReflectionServer = reflection.NewServerV1(opts)
+            if s.reflectionAuthorizer != nil {
+                svr = &authorizedReflectionV1{ServerReflectionServer: svr, authorize: s.reflectionAuthorizer}
+            }
+            v1reflectiongrpc.RegisterServerReflectionServer(s.Server, svr)
+        case ReflectionV1Alpha:
+            var svr v1alphareflectiongrpc.ServerReflectionServer = reflection.NewServer(opts)
+            if s.reflectionAuthorizer != nil {
+                svr = &authorizedReflectionV1Alpha{ServerReflectionServer: svr, authorize: s.reflectionAuthorizer}
+            }
+            v1alphareflectiongrpc.RegisterServerReflectionServer(s.Server, svr)
+        }
+    }
+}
+
+// authorizedReflectionV1 带访问控制的 v1 反射服务。
+type authorizedReflectionV1 struct {
+    v1reflectiongrpc.ServerReflectionServer
+    authorize func(ctx context.Context) error
+}
+
+func (r *authorizedReflectionV1) ServerReflectionInfo(stream v1reflectiongrpc.ServerReflection_ServerReflectionInfoServer) error {
+    if err := authorizeReflection(stream, r.authorize); err != nil {
+        return err
+    }
+    return r.ServerReflectionServer.ServerReflectionInfo(stream)
+}
+
+// authorizedReflectionV1Alpha 带访问控制的 v1alpha 反射服务。
+type authorizedReflectionV1Alpha struct {
+    v1alphareflectiongrpc.ServerReflectionServer
+    authorize func(ctx context.Context) error
+}
+
+func (r *authorizedReflectionV1Alpha) ServerReflectionInfo(stream v1alphareflectiongrpc.ServerReflection_ServerReflectionInfoServer) error {
+    if err := authorizeReflection(stream, r.authorize); err != nil {
+        return err
+    }
+    return r.ServerReflectionServer.ServerReflectionInfo(stream)
+}
+
+// authorizeReflection 执行反射服务访问控制，非状态错误转换为 PermissionDenied。
+func authorizeReflection(stream grpc.ServerStream, authorize func(ctx context.Context) error) error {
+    err := authorize(stream.Context())
+    if err == nil {
+        return nil
+    }
+    if _, ok := status.FromError(err); ok {
+        return err
+    }
+    return status.Error(codes.PermissionDenied, err.Error())
+}
@@ -0,0 +1,69 @@
+package grpc
+
+import (
+    "context"
+    "errors"
+    "testing"
+    "time"
+
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/credentials/insecure"
+    v1reflectiongrpc "google.golang.org/grpc/reflection/grpc_reflection_v1"
+    "google.golang.org/grpc/status"
+)
+
+func TestReflectionRegistration(t *testing.T) {
+    cases := []struct {
+        opts []ServerOption
+        want map[string]bool
+    }{
+        {nil, map[string]bool{
+            "grpc.health.v1.Health": true, "grpc.reflection.v1.ServerReflection": true, "grpc.reflection.v1alpha.ServerReflection": true,
+        }},
+        {[]ServerOption{Reflection(), Health(false)}, map[string]bool{}},
+        {[]ServerOption{Reflection(ReflectionV1)}, map[string]bool{
+            "grpc.health.v1.Health": true, "grpc.reflection.v1.ServerReflection": true,
+        }},
+    }
+    for i, c := range cases {
+        info := NewServer(c.opts...).GetServiceInfo()
+        if len(info) != len(c.want) {
+            t.Errorf("case %d: expect %d services, got %v", i, len(c.want), info)
+        }
+        for name := range c.want {
+            if _, ok := info[name]; !ok {
+                t.Errorf("case %d: expect service %s registered", i, name)
+            }
+        }
+    }
+}
+
+func TestReflectionAuthorizer(t *testing.T) {
+    s := NewServer(Address("127.0.0.1:0"), ReflectionAuthorizer(func(ctx context.Context) error {
+        return errors.New("denied")
+    }))
+    if err := s.Listen(context.Background()); err != nil {
+        t.Fatal(err)
+    }
+    go func() { _ = s.Start(context.Background()) }()
+    defer s.Stop(context.Background())
+
+    conn, err := grpc.NewClient(s.ListenAddr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer conn.Close()
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+    stream, err := v1reflectiongrpc.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if _, err = stream.Recv(); status.Code(err) != codes.PermissionDenied {
+        t.Fatalf("expect PermissionDenied, got %v", err)
+    }
+    if err = LoopbackOnly(context.Background()); status.Code(err) != codes.PermissionDenied {
+        t.Fatalf("expect PermissionDenied without peer, got %v", err)
+    }
+}
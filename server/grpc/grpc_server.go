@@ -3,7 +3,12 @@ package grpc
 import (
     "context"
     "crypto/tls"
+    "errors"
+    "fmt"
     "net"
+    "strings"
+    "sync"
+    "sync/atomic"
     "time"
 
     "github.com/camry/g/v2/glog"
@@ -11,12 +16,16 @@ import (
     "google.golang.org/grpc/credentials"
     "google.golang.org/grpc/health"
     "google.golang.org/grpc/health/grpc_health_v1"
-    "google.golang.org/grpc/reflection"
 
+    "github.com/camry/dove/v2/metadata"
     "github.com/camry/dove/v2/server"
+    "github.com/camry/dove/v2/validate"
 )
 
-var _ server.Server = (*Server)(nil)
+var (
+    _ server.Server   = (*Server)(nil)
+    _ server.Listener = (*Server)(nil)
+)
 
 type ServerOption func(s *Server)
 
@@ -25,24 +34,108 @@ func Address(address string) ServerOption {
     return func(s *Server) { s.address = address }
 }
 
-// Timeout 配置服务超时时间（单位：秒）。
+// Timeout 配置一元调用超时时间（单位：秒），客户端携带更短的截止时间时以客户端为准。
 func Timeout(timeout time.Duration) ServerOption {
     return func(s *Server) { s.timeout = timeout }
 }
 
+// StreamTimeout 配置流调用的默认超时时间，默认 0 表示不限制，MethodTimeout 与 ServiceTimeout 优先。
+//
+// 健康检查与反射等内置长连接流服务不受流超时与空闲超时影响。
+func StreamTimeout(timeout time.Duration) ServerOption {
+    return func(s *Server) { s.streamTimeout = timeout }
+}
+
+// MethodTimeout 配置方法级超时时间，fullMethod 形如 /pkg.Svc/Method，优先于服务级与全局超时，0 表示不限制。
+func MethodTimeout(fullMethod string, timeout time.Duration) ServerOption {
+    return func(s *Server) {
+        if !strings.HasPrefix(fullMethod, "/") {
+            fullMethod = "/" + fullMethod
+        }
+        s.methodTimeouts[fullMethod] = timeout
+    }
+}
+
+// ServiceTimeout 配置服务级超时时间，service 形如 pkg.Svc，优先于全局超时，0 表示不限制。
+func ServiceTimeout(service string, timeout time.Duration) ServerOption {
+    return func(s *Server) { s.serviceTimeouts[service] = timeout }
+}
+
+// StreamIdleTimeout 配置流空闲超时时间，超过该时间未收发消息时取消流上下文。
+func StreamIdleTimeout(timeout time.Duration) ServerOption {
+    return func(s *Server) { s.streamIdleTimeout = timeout }
+}
+
 // TLSConfig 配置 TLS。
 func TLSConfig(c *tls.Config) ServerOption {
     return func(s *Server) { s.tlsConf = c }
 }
 
-// UnaryInterceptor 配置一元拦截器。
+// UnaryInterceptor 配置一元拦截器，可多次调用累加，优先级为 PriorityDefault，位于默认拦截器之后。
 func UnaryInterceptor(in ...grpc.UnaryServerInterceptor) ServerOption {
-    return func(s *Server) { s.unaryInterceptors = in }
+    return OrderedUnaryInterceptor(PriorityDefault, in...)
 }
 
-// StreamInterceptor 配置流拦截器。
+// OrderedUnaryInterceptor 按优先级配置一元拦截器，priority 越小越先执行，同优先级按配置顺序执行。
+func OrderedUnaryInterceptor(priority int, in ...grpc.UnaryServerInterceptor) ServerOption {
+    return func(s *Server) {
+        for _, i := range in {
+            s.unaryInterceptors = append(s.unaryInterceptors, unaryInterceptor{priority: priority, in: i})
+        }
+    }
+}
+
+// StreamInterceptor 配置流拦截器，可多次调用累加，优先级为 PriorityDefault，位于默认拦截器之后。
 func StreamInterceptor(in ...grpc.StreamServerInterceptor) ServerOption {
-    return func(s *Server) { s.streamInterceptors = in }
+    return OrderedStreamInterceptor(PriorityDefault, in...)
+}
+
+// OrderedStreamInterceptor 按优先级配置流拦截器，priority 越小越先执行，同优先级按配置顺序执行。
+func OrderedStreamInterceptor(priority int, in ...grpc.StreamServerInterceptor) ServerOption {
+    return func(s *Server) {
+        for _, i := range in {
+            s.streamInterceptors = append(s.streamInterceptors, streamInterceptor{priority: priority, in: i})
+        }
+    }
+}
+
+// Validator 启用请求消息校验拦截器，请求消息实现 Validate 或 ValidateAll 时执行校验，
+// 失败时返回 codes.InvalidArgument，优先级为 PriorityValidator。
+func Validator() ServerOption {
+    return func(s *Server) {
+        OrderedUnaryInterceptor(PriorityValidator, validate.UnaryServerInterceptor())(s)
+        OrderedStreamInterceptor(PriorityValidator, validate.StreamServerInterceptor())(s)
+    }
+}
+
+// MetadataOptions 配置默认拦截器的元数据提取选项，默认提取 x-md-global-* 与 x-md-local-* 元数据到上下文。
+func MetadataOptions(opts ...metadata.Option) ServerOption {
+    return func(s *Server) { s.metadata = metadata.NewPropagator(opts...) }
+}
+
+// DefaultUnaryInterceptor 替换合并 baseCtx 与应用超时的默认一元拦截器，传入 nil 时禁用。
+func DefaultUnaryInterceptor(in grpc.UnaryServerInterceptor) ServerOption {
+    return func(s *Server) { s.defaultUnary, s.replaceUnary = in, true }
+}
+
+// DefaultStreamInterceptor 替换合并 baseCtx 的默认流拦截器，传入 nil 时禁用。
+func DefaultStreamInterceptor(in grpc.StreamServerInterceptor) ServerOption {
+    return func(s *Server) { s.defaultStream, s.replaceStream = in, true }
+}
+
+// DisableDefaultInterceptor 禁用默认一元与流拦截器。
+func DisableDefaultInterceptor() ServerOption {
+    return func(s *Server) {
+        s.defaultUnary, s.replaceUnary = nil, true
+        s.defaultStream, s.replaceStream = nil, true
+    }
+}
+
+// DrainDelay 配置停止时的排空等待时间。
+//
+// 停止开始后健康检查状态切换为 NOT_SERVING，等待 d 后（不超过停止超时）再执行 GracefulStop 发送 GOAWAY。
+func DrainDelay(d time.Duration) ServerOption {
+    return func(s *Server) { s.drainDelay = d }
 }
 
 // Options 配置 gRPC 选项。
@@ -53,16 +146,35 @@ func Options(grpcOpts ...grpc.ServerOption) ServerOption {
 type Server struct {
     *grpc.Server
     baseCtx            context.Context
-    err                error
+    mu                 sync.Mutex
     network            string
     address            string
     timeout            time.Duration
+    methodTimeouts     map[string]time.Duration
+    serviceTimeouts    map[string]time.Duration
+    streamTimeout      time.Duration
+    streamIdleTimeout  time.Duration
     tlsConf            *tls.Config
     lis                net.Listener
+    serving            bool
+    closed             bool
+    drainDelay         time.Duration
+    draining           atomic.Bool
     grpcOpts           []grpc.ServerOption
-    unaryInterceptors  []grpc.UnaryServerInterceptor
-    streamInterceptors []grpc.StreamServerInterceptor
+    unaryInterceptors  []unaryInterceptor
+    streamInterceptors []streamInterceptor
+    defaultUnary       grpc.UnaryServerInterceptor
+    defaultStream      grpc.StreamServerInterceptor
+    replaceUnary       bool
+    replaceStream      bool
+    metadata           *metadata.Propagator
     health             *health.Server
+    healthState        healthState
+    healthChecks       []healthCheck
+    healthEnabled      bool
+
+    reflectionVersions   []ReflectionVersion
+    reflectionAuthorizer func(ctx context.Context) error
 }
 
 // NewServer 新建 gRPC 服务器。
@@ -73,25 +185,28 @@ func NewServer(opts ...ServerOption) *Server {
         address: ":0",
         timeout: 1 * time.Second,
         health:  health.NewServer(),
+
+        healthEnabled:      true,
+        metadata:           metadata.NewPropagator(),
+        reflectionVersions: []ReflectionVersion{ReflectionV1, ReflectionV1Alpha},
+        methodTimeouts:     make(map[string]time.Duration),
+        serviceTimeouts:    make(map[string]time.Duration),
+        healthState: healthState{
+            statuses: make(map[string]grpc_health_v1.HealthCheckResponse_ServingStatus),
+        },
     }
     for _, o := range opts {
         o(srv)
     }
-    unaryInterceptors := []grpc.UnaryServerInterceptor{
-        srv.defaultUnaryServerInterceptor(),
-    }
-    streamInterceptors := []grpc.StreamServerInterceptor{
-        srv.defaultStreamServerInterceptor(),
-    }
-    if len(srv.unaryInterceptors) > 0 {
-        unaryInterceptors = append(unaryInterceptors, srv.unaryInterceptors...)
+    if !srv.replaceUnary {
+        srv.defaultUnary = srv.defaultUnaryServerInterceptor()
     }
-    if len(srv.streamInterceptors) > 0 {
-        streamInterceptors = append(streamInterceptors, srv.streamInterceptors...)
+    if !srv.replaceStream {
+        srv.defaultStream = srv.defaultStreamServerInterceptor()
     }
     grpcOpts := []grpc.ServerOption{
-        grpc.ChainUnaryInterceptor(unaryInterceptors...),
-        grpc.ChainStreamInterceptor(streamInterceptors...),
+        grpc.ChainUnaryInterceptor(srv.unaryChain()...),
+        grpc.ChainStreamInterceptor(srv.streamChain()...),
     }
     if srv.tlsConf != nil {
         grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(srv.tlsConf)))
@@ -100,38 +215,118 @@ func NewServer(opts ...ServerOption) *Server {
         grpcOpts = append(grpcOpts, srv.grpcOpts...)
     }
     srv.Server = grpc.NewServer(grpcOpts...)
-    srv.err = srv.listen()
     // 内部注册
-    grpc_health_v1.RegisterHealthServer(srv.Server, srv.health)
-    reflection.Register(srv.Server)
+    srv.registerInternal()
     return srv
 }
 
+// Listen 绑定 gRPC 服务监听地址，已停止的服务器返回 grpc.ErrServerStopped。
+func (s *Server) Listen(ctx context.Context) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if s.closed {
+        return grpc.ErrServerStopped
+    }
+    if s.lis != nil {
+        return nil
+    }
+    lis, err := net.Listen(s.network, s.address)
+    if err != nil {
+        return fmt.Errorf("[gRPC] server listen on %s failed: %w", s.address, err)
+    }
+    s.lis = lis
+    return nil
+}
+
 // Start 启动 gRPC 服务器。
 func (s *Server) Start(ctx context.Context) error {
-    if s.err != nil {
-        return s.err
+    s.mu.Lock()
+    closed := s.closed
+    s.mu.Unlock()
+    if closed {
+        // 启动前已停止时不再绑定端口。
+        return nil
+    }
+    if err := s.Listen(ctx); err != nil {
+        if errors.Is(err, grpc.ErrServerStopped) {
+            return nil
+        }
+        return err
     }
+    s.mu.Lock()
+    lis := s.lis
+    if lis == nil {
+        // 监听后启动前已被停止。
+        s.mu.Unlock()
+        return nil
+    }
+    s.serving = true
+    s.mu.Unlock()
     s.baseCtx = ctx
-    glog.Infof("[gRPC] server listening on: %s", s.lis.Addr().String())
-    s.health.Resume()
-    return s.Serve(s.lis)
+    glog.Infof("[gRPC] server listening on: %s", lis.Addr().String())
+    s.resumeHealth(ctx)
+    if err := s.Serve(lis); !errors.Is(err, grpc.ErrServerStopped) {
+        return err
+    }
+    return nil
 }
 
 // Stop 停止 gRPC 服务器。
+//
+// 停止超时后仍未结束的调用将被强制关闭。
 func (s *Server) Stop(ctx context.Context) error {
-    s.health.Shutdown()
-    s.GracefulStop()
     glog.Info("[gRPC] server stopping")
-    return nil
+    s.draining.Store(true)
+    s.shutdownHealth()
+    s.mu.Lock()
+    s.closed = true
+    serving := s.serving
+    s.mu.Unlock()
+    // 未启动服务时没有需要排空的请求，例如启动失败时释放资源。
+    if serving && s.drainDelay > 0 {
+        timer := time.NewTimer(s.drainDelay)
+        select {
+        case <-ctx.Done():
+        case <-timer.C:
+        }
+        timer.Stop()
+    }
+    done := make(chan struct{})
+    go func() {
+        s.GracefulStop()
+        close(done)
+    }()
+    select {
+    case <-done:
+    case <-ctx.Done():
+        s.Server.Stop()
+        <-done
+    }
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    // 已绑定但未启动服务的监听器需要手动释放。
+    var err error
+    if s.lis != nil && !s.serving {
+        if cErr := s.lis.Close(); cErr != nil && !errors.Is(cErr, net.ErrClosed) {
+            err = cErr
+        }
+    }
+    s.lis = nil
+    s.serving = false
+    return err
 }
 
-// listen 网络监听。
-func (s *Server) listen() error {
-    lis, err := net.Listen(s.network, s.address)
-    if err != nil {
-        return err
+// Draining 返回服务器是否处于排空状态。
+func (s *Server) Draining() bool {
+    return s.draining.Load()
+}
+
+// ListenAddr 返回实际监听地址，未监听时返回 nil。
+func (s *Server) ListenAddr() net.Addr {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if s.lis == nil {
+        return nil
     }
-    s.lis = lis
-    return nil
+    return s.lis.Addr()
 }
@@ -0,0 +1,59 @@
+package codec
+
+import (
+    "bufio"
+    "errors"
+    "io"
+)
+
+// DefaultMaxFrameSize 默认最大帧长度（4 MiB）。
+const DefaultMaxFrameSize = 4 << 20
+
+var (
+    // ErrFrameTooLarge 帧长度超过上限。
+    ErrFrameTooLarge = errors.New("codec: frame too large")
+    // ErrInvalidFrame 帧格式错误。
+    ErrInvalidFrame = errors.New("codec: invalid frame")
+)
+
+// Framer 定义消息分帧接口。
+type Framer interface {
+    // ReadFrame 从 r 读取一个完整的帧，返回的切片由调用方持有。
+    ReadFrame(r *bufio.Reader) ([]byte, error)
+    // WriteFrame 将帧编码后写入 w。
+    WriteFrame(w io.Writer, frame []byte) error
+}
+
+// Option 定义一个分帧选项类型。
+type Option func(o *option)
+
+// option 分帧选项实体对象。
+type option struct {
+    maxFrameSize int
+}
+
+// MaxFrameSize 配置最大帧长度（不含帧头与分隔符），默认 DefaultMaxFrameSize，读写超过上限时返回 ErrFrameTooLarge。
+func MaxFrameSize(n int) Option {
+    return func(o *option) { o.maxFrameSize = n }
+}
+
+// newOption 新建分帧选项。
+func newOption(opts ...Option) option {
+    o := option{maxFrameSize: DefaultMaxFrameSize}
+    for _, opt := range opts {
+        opt(&o)
+    }
+    return o
+}
+
+// readFull 读取 n 字节的帧内容。
+func readFull(r *bufio.Reader, n int) ([]byte, error) {
+    frame := make([]byte, n)
+    if _, err := io.ReadFull(r, frame); err != nil {
+        if errors.Is(err, io.EOF) {
+            return nil, io.ErrUnexpectedEOF
+        }
+        return nil, err
+    }
+    return frame, nil
+}
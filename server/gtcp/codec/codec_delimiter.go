@@ -0,0 +1,70 @@
+package codec
+
+import (
+    "bufio"
+    "bytes"
+    "fmt"
+    "io"
+)
+
+// delimiter 分隔符分帧。
+type delimiter struct {
+    option
+    delim []byte
+    line  bool
+}
+
+// Delimiter 新建分隔符分帧，返回的帧不包含分隔符，帧内容不能包含分隔符。
+func Delimiter(delim []byte, opts ...Option) Framer {
+    if len(delim) == 0 {
+        panic("codec: empty delimiter")
+    }
+    return &delimiter{option: newOption(opts...), delim: bytes.Clone(delim)}
+}
+
+// Line 新建按行分帧，以 \n 分隔，读取时同时去除行尾的 \r。
+func Line(opts ...Option) Framer {
+    return &delimiter{option: newOption(opts...), delim: []byte{'\n'}, line: true}
+}
+
+func (f *delimiter) ReadFrame(r *bufio.Reader) ([]byte, error) {
+    last := f.delim[len(f.delim)-1]
+    var frame []byte
+    for {
+        chunk, err := r.ReadSlice(last)
+        if len(frame)+len(chunk) > f.maxFrameSize+len(f.delim) {
+            return nil, fmt.Errorf("%w: exceeds %d", ErrFrameTooLarge, f.maxFrameSize)
+        }
+        frame = append(frame, chunk...)
+        if err == bufio.ErrBufferFull {
+            continue
+        }
+        if err != nil {
+            if err == io.EOF && len(frame) > 0 {
+                return nil, io.ErrUnexpectedEOF
+            }
+            return nil, err
+        }
+        if bytes.HasSuffix(frame, f.delim) {
+            break
+        }
+    }
+    frame = frame[:len(frame)-len(f.delim)]
+    if f.line {
+        frame = bytes.TrimSuffix(frame, []byte{'\r'})
+    }
+    return frame, nil
+}
+
+func (f *delimiter) WriteFrame(w io.Writer, frame []byte) error {
+    if len(frame) > f.maxFrameSize {
+        return fmt.Errorf("%w: %d > %d", ErrFrameTooLarge, len(frame), f.maxFrameSize)
+    }
+    if bytes.Contains(frame, f.delim) {
+        return fmt.Errorf("%w: frame contains delimiter", ErrInvalidFrame)
+    }
+    buf := make([]byte, 0, len(frame)+len(f.delim))
+    buf = append(append(buf, frame...), f.delim...)
+    _, err := w.Write(buf)
+    return err
+}
@@ -0,0 +1,67 @@
+package codec
+
+import (
+    "bufio"
+    "encoding/binary"
+    "fmt"
+    "io"
+)
+
+// lengthField 定长长度字段前缀分帧。
+type lengthField struct {
+    option
+    size  int
+    order binary.ByteOrder
+}
+
+// LengthField 新建定长长度字段前缀分帧，size 为帧头字节数（1、2 或 4），order 为字节序。
+//
+// 帧头仅表示帧内容长度，不包含帧头本身。
+func LengthField(size int, order binary.ByteOrder, opts ...Option) Framer {
+    if size != 1 && size != 2 && size != 4 {
+        panic(fmt.Sprintf("codec: invalid length field size %d", size))
+    }
+    f := &lengthField{option: newOption(opts...), size: size, order: order}
+    if limit := 1<<(8*size) - 1; f.maxFrameSize > limit {
+        f.maxFrameSize = limit
+    }
+    return f
+}
+
+func (f *lengthField) ReadFrame(r *bufio.Reader) ([]byte, error) {
+    var head [4]byte
+    if _, err := io.ReadFull(r, head[:f.size]); err != nil {
+        return nil, err
+    }
+    var n int
+    switch f.size {
+    case 1:
+        n = int(head[0])
+    case 2:
+        n = int(f.order.Uint16(head[:2]))
+    default:
+        n = int(f.order.Uint32(head[:4]))
+    }
+    if n > f.maxFrameSize {
+        return nil, fmt.Errorf("%w: %d > %d", ErrFrameTooLarge, n, f.maxFrameSize)
+    }
+    return readFull(r, n)
+}
+
+func (f *lengthField) WriteFrame(w io.Writer, frame []byte) error {
+    if len(frame) > f.maxFrameSize {
+        return fmt.Errorf("%w: %d > %d", ErrFrameTooLarge, len(frame), f.maxFrameSize)
+    }
+    buf := make([]byte, f.size+len(frame))
+    switch f.size {
+    case 1:
+        buf[0] = byte(len(frame))
+    case 2:
+        f.order.PutUint16(buf, uint16(len(frame)))
+    default:
+        f.order.PutUint32(buf, uint32(len(frame)))
+    }
+    copy(buf[f.size:], frame)
+    _, err := w.Write(buf)
+    return err
+}
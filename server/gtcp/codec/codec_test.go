@@ -0,0 +1,72 @@
+package codec
+
+import (
+    "bufio"
+    "bytes"
+    "encoding/binary"
+    "errors"
+    "io"
+    "testing"
+)
+
+func TestFramer(t *testing.T) {
+    framers := map[string]Framer{
+        "u8":        LengthField(1, binary.BigEndian),
+        "u16-be":    LengthField(2, binary.BigEndian),
+        "u16-le":    LengthField(2, binary.LittleEndian),
+        "u32-be":    LengthField(4, binary.BigEndian),
+        "u32-le":    LengthField(4, binary.LittleEndian),
+        "delimiter": Delimiter([]byte("\r\n\r\n")),
+        "line":      Line(),
+        "varint":    Varint(),
+    }
+    frames := [][]byte{[]byte("hello"), {}, bytes.Repeat([]byte("a"), 200), []byte("dove")}
+    for name, f := range framers {
+        t.Run(name, func(t *testing.T) {
+            var buf bytes.Buffer
+            for _, frame := range frames {
+                if err := f.WriteFrame(&buf, frame); err != nil {
+                    t.Fatal(err)
+                }
+            }
+            // 使用最小缓冲区覆盖跨缓冲区读取。
+            r := bufio.NewReaderSize(&buf, 16)
+            for _, want := range frames {
+                got, err := f.ReadFrame(r)
+                if err != nil {
+                    t.Fatal(err)
+                }
+                if !bytes.Equal(got, want) {
+                    t.Fatalf("expect %q, got %q", want, got)
+                }
+            }
+            if _, err := f.ReadFrame(r); !errors.Is(err, io.EOF) {
+                t.Fatalf("expect io.EOF, got %v", err)
+            }
+        })
+    }
+}
+
+func TestMaxFrameSize(t *testing.T) {
+    framers := map[string][2]Framer{
+        "length":    {LengthField(4, binary.BigEndian, MaxFrameSize(8)), LengthField(4, binary.BigEndian)},
+        "delimiter": {Line(MaxFrameSize(8)), Line()},
+        "varint":    {Varint(MaxFrameSize(8)), Varint()},
+    }
+    for name, fs := range framers {
+        t.Run(name, func(t *testing.T) {
+            f := fs[0]
+            if err := f.WriteFrame(io.Discard, make([]byte, 9)); !errors.Is(err, ErrFrameTooLarge) {
+                t.Fatalf("expect ErrFrameTooLarge on write, got %v", err)
+            }
+            var buf bytes.Buffer
+            _ = fs[1].WriteFrame(&buf, bytes.Repeat([]byte("x"), 32))
+            if _, err := f.ReadFrame(bufio.NewReader(&buf)); !errors.Is(err, ErrFrameTooLarge) {
+                t.Fatalf("expect ErrFrameTooLarge on read, got %v", err)
+            }
+        })
+    }
+    if err := LengthField(1, binary.BigEndian).WriteFrame(io.Discard, make([]byte, 256)); !errors.Is(err, ErrFrameTooLarge) {
+        t.Fatalf("expect ErrFrameTooLarge for 1 byte header, got %v", err)
+    }
+}
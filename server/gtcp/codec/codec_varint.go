@@ -0,0 +1,41 @@
+package codec
+
+import (
+    "bufio"
+    "encoding/binary"
+    "fmt"
+    "io"
+)
+
+// varint Protobuf varint 长度前缀分帧。
+type varint struct {
+    option
+}
+
+// Varint 新建 Protobuf varint 长度前缀分帧，与 protodelim 及 Java writeDelimitedTo 格式兼容。
+func Varint(opts ...Option) Framer {
+    return &varint{option: newOption(opts...)}
+}
+
+func (f *varint) ReadFrame(r *bufio.Reader) ([]byte, error) {
+    n, err := binary.ReadUvarint(r)
+    if err != nil {
+        if err == io.EOF || err == io.ErrUnexpectedEOF {
+            return nil, err
+        }
+        return nil, fmt.Errorf("%w: %v", ErrInvalidFrame, err)
+    }
+    if n > uint64(f.maxFrameSize) {
+        return nil, fmt.Errorf("%w: %d > %d", ErrFrameTooLarge, n, f.maxFrameSize)
+    }
+    return readFull(r, int(n))
+}
+
+func (f *varint) WriteFrame(w io.Writer, frame []byte) error {
+    if len(frame) > f.maxFrameSize {
+        return fmt.Errorf("%w: %d > %d", ErrFrameTooLarge, len(frame), f.maxFrameSize)
+    }
+    buf := binary.AppendUvarint(make([]byte, 0, binary.MaxVarintLen64+len(frame)), uint64(len(frame)))
+    _, err := w.Write(append(buf, frame...))
+    return err
+}
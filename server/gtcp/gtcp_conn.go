@@ -0,0 +1,113 @@
+package gtcp
+
+import (
+    "context"
+    "net"
+    "sync"
+    "sync/atomic"
+    "time"
+)
+
+// serverConn 服务端连接，负责空闲超时与上下文取消后的读取中断。
+type serverConn struct {
+    net.Conn
+    ctx    context.Context
+    cancel context.CancelFunc
+    ip     string
+
+    readIdle  time.Duration
+    writeIdle time.Duration
+
+    mu            sync.Mutex
+    readDeadline  time.Time // 调用方设置的读取截止时间。
+    writeDeadline time.Time // 调用方设置的写入截止时间。
+    stop          func() bool
+    lastRead      atomic.Int64 // 最近一次读取到数据的时间（纳秒）。
+    closed        chan struct{}
+    closeOnce     sync.Once
+}
+
+// newServerConn 新建服务端连接，ctx 结束后阻塞中与后续的读取立即返回超时错误。
+func newServerConn(nc net.Conn, ctx context.Context, cancel context.CancelFunc, readIdle, writeIdle time.Duration) *serverConn {
+    c := &serverConn{Conn: nc, ctx: ctx, cancel: cancel, readIdle: readIdle, writeIdle: writeIdle, closed: make(chan struct{})}
+    c.lastRead.Store(time.Now().UnixNano())
+    c.stop = context.AfterFunc(ctx, func() {
+        _ = c.Conn.SetReadDeadline(time.Now())
+    })
+    return c
+}
+
+// effective 返回调用方截止时间与空闲截止时间中较早者。
+func effective(deadline time.Time, idle time.Duration) time.Time {
+    if idle <= 0 {
+        return deadline
+    }
+    t := time.Now().Add(idle)
+    if !deadline.IsZero() && deadline.Before(t) {
+        return deadline
+    }
+    return t
+}
+
+func (c *serverConn) Read(b []byte) (int, error) {
+    if c.ctx.Err() != nil {
+        _ = c.Conn.SetReadDeadline(time.Now())
+    } else if c.readIdle > 0 {
+        c.mu.Lock()
+        deadline := effective(c.readDeadline, c.readIdle)
+        c.mu.Unlock()
+        _ = c.Conn.SetReadDeadline(deadline)
+        // 设置期间上下文结束时重新中断读取。
+        if c.ctx.Err() != nil {
+            _ = c.Conn.SetReadDeadline(time.Now())
+        }
+    }
+    n, err := c.Conn.Read(b)
+    if n > 0 {
+        c.lastRead.Store(time.Now().UnixNano())
+    }
+    return n, err
+}
+
+func (c *serverConn) Write(b []byte) (int, error) {
+    if c.writeIdle > 0 {
+        c.mu.Lock()
+        deadline := effective(c.writeDeadline, c.writeIdle)
+        c.mu.Unlock()
+        _ = c.Conn.SetWriteDeadline(deadline)
+    }
+    return c.Conn.Write(b)
+}
+
+func (c *serverConn) SetDeadline(t time.Time) error {
+    c.mu.Lock()
+    c.readDeadline, c.writeDeadline = t, t
+    c.mu.Unlock()
+    if c.ctx.Err() != nil {
+        return c.Conn.SetWriteDeadline(t)
+    }
+    return c.Conn.SetDeadline(t)
+}
+
+func (c *serverConn) SetReadDeadline(t time.Time) error {
+    c.mu.Lock()
+    c.readDeadline = t
+    c.mu.Unlock()
+    if c.ctx.Err() != nil {
+        return nil
+    }
+    return c.Conn.SetReadDeadline(t)
+}
+
+func (c *serverConn) SetWriteDeadline(t time.Time) error {
+    c.mu.Lock()
+    c.writeDeadline = t
+    c.mu.Unlock()
+    return c.Conn.SetWriteDeadline(t)
+}
+
+func (c *serverConn) Close() error {
+    c.stop()
+    c.closeOnce.Do(func() { close(c.closed) })
+    return c.Conn.Close()
+}
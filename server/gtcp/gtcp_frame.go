@@ -0,0 +1,68 @@
+package gtcp
+
+import (
+    "bufio"
+    "context"
+    "errors"
+    "io"
+    "net"
+    "sync"
+
+    "github.com/camry/g/v2/glog"
+    "github.com/camry/g/v2/gnet/gtcp"
+
+    "github.com/camry/dove/v2/server/gtcp/codec"
+)
+
+// Codec 配置 FrameHandler 使用的分帧器，默认 4 字节大端长度前缀。
+func Codec(f codec.Framer) ServerOption {
+    return func(s *Server) { s.framer = f }
+}
+
+// FrameHandler 配置按帧处理的处理器。
+//
+// 服务器按 Codec 持续读取连接中的完整帧并依次调用处理器，同一连接的帧按顺序处理，
+// 读取失败或连接上下文取消后关闭连接。
+func FrameHandler(handler func(ctx context.Context, conn *FrameConn, frame []byte)) ServerOption {
+    return func(s *Server) { s.frameHandler = handler }
+}
+
+// FrameConn 按帧读写的 TCP 连接。
+type FrameConn struct {
+    *gtcp.Conn
+    framer codec.Framer
+    reader *bufio.Reader
+    wmu    sync.Mutex
+}
+
+// NewFrameConn 使用指定分帧器新建按帧读写的连接，读取帧后不应再使用 gtcp.Conn 的读取方法。
+func NewFrameConn(conn *gtcp.Conn, framer codec.Framer) *FrameConn {
+    return &FrameConn{Conn: conn, framer: framer, reader: bufio.NewReader(conn.Conn)}
+}
+
+// ReadFrame 读取一个完整的帧。
+func (c *FrameConn) ReadFrame() ([]byte, error) {
+    return c.framer.ReadFrame(c.reader)
+}
+
+// WriteFrame 写入一个帧，可并发调用。
+func (c *FrameConn) WriteFrame(frame []byte) error {
+    c.wmu.Lock()
+    defer c.wmu.Unlock()
+    return c.framer.WriteFrame(c.Conn.Conn, frame)
+}
+
+// serveFrames 循环读取帧并调用帧处理器。
+func (s *Server) serveFrames(ctx context.Context, fc *FrameConn) {
+    for ctx.Err() == nil {
+        frame, err := fc.ReadFrame()
+        if err != nil {
+            var ne net.Error
+            if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) && !(errors.As(err, &ne) && ne.Timeout()) {
+                glog.Warnf("[TCP] server read frame from %s failed: %v", fc.RemoteAddr(), err)
+            }
+            return
+        }
+        s.frameHandler(ctx, fc, frame)
+    }
+}
@@ -3,16 +3,36 @@ package gtcp
 import (
     "context"
     "crypto/tls"
+    "encoding/binary"
     "errors"
+    "fmt"
     "net"
+    "sync"
+    "sync/atomic"
+    "time"
 
     "github.com/camry/g/v2/glog"
     "github.com/camry/g/v2/gnet/gtcp"
 
+    "github.com/camry/dove/v2/proxyproto"
     "github.com/camry/dove/v2/server"
+    "github.com/camry/dove/v2/server/gtcp/codec"
 )
 
-var _ server.Server = (*Server)(nil)
+var (
+    _ server.Server   = (*Server)(nil)
+    _ server.Listener = (*Server)(nil)
+)
+
+// OverflowPolicy 定义连接数超过上限时的处理策略。
+type OverflowPolicy int
+
+const (
+    // OverflowReject 立即关闭超出上限的新连接。
+    OverflowReject OverflowPolicy = iota
+    // OverflowQueue 暂停接受新连接直到有连接释放，新连接在内核队列中等待。
+    OverflowQueue
+)
 
 // ServerOption 定义一个 TCP 服务选项类型。
 type ServerOption func(s *Server)
@@ -27,49 +47,452 @@ func TLSConfig(c *tls.Config) ServerOption {
     return func(s *Server) { s.tlsConfig = c }
 }
 
+// ProxyProtocol 配置解析 PROXY 协议 v1/v2 头。
+//
+// 仅解析 proxyproto.Trusted 配置的可信来源的协议头，未配置时 Listen 返回 proxyproto.ErrNoTrusted。
+// 连接的 RemoteAddr 返回协议头中的客户端地址，单 IP 连接数限制按客户端地址计算，
+// 协议头可通过 proxyproto.FromContext 从连接上下文获取。
+func ProxyProtocol(opts ...proxyproto.Option) ServerOption {
+    return func(s *Server) {
+        s.proxyProtocol = true
+        s.proxyOpts = opts
+    }
+}
+
 // Handler 配置处理器。
+//
+// 与 gtcp.Server 一致，处理器返回后连接不会自动关闭，可交给其他协程继续使用，
+// 连接在关闭前计入连接数限制，Stop 时等待其关闭，超过停止超时仍未关闭时强制关闭。
 func Handler(handler func(conn *gtcp.Conn)) ServerOption {
-    return func(s *Server) { s.handler = handler }
+    return func(s *Server) { s.setHandler(handler) }
+}
+
+// ContextHandler 配置带连接上下文的处理器。
+//
+// 连接上下文派生自 App 上下文，Stop 时取消，处理器返回后连接自动关闭。
+func ContextHandler(handler func(ctx context.Context, conn *gtcp.Conn)) ServerOption {
+    return func(s *Server) {
+        s.handler = handler
+        s.detached = false
+    }
+}
+
+// MaxConns 配置最大并发连接数，0 表示不限制。
+func MaxConns(n int) ServerOption {
+    return func(s *Server) { s.maxConns = n }
 }
 
-// Server 定义 TCP 服务包装器。
+// MaxConnsPerIP 配置单个 IP 的最大并发连接数，超出时立即关闭新连接，0 表示不限制。
+func MaxConnsPerIP(n int) ServerOption {
+    return func(s *Server) { s.maxConnsPerIP = n }
+}
+
+// Overflow 配置连接数超过 MaxConns 时的处理策略，默认 OverflowReject。
+func Overflow(policy OverflowPolicy) ServerOption {
+    return func(s *Server) { s.overflow = policy }
+}
+
+// ReadIdleTimeout 配置读取空闲超时时间，超过该时间未读取到数据时读取返回超时错误，0 表示不限制。
+func ReadIdleTimeout(d time.Duration) ServerOption {
+    return func(s *Server) { s.readIdleTimeout = d }
+}
+
+// WriteIdleTimeout 配置写入空闲超时时间，单次写入超过该时间未完成时返回超时错误，0 表示不限制。
+func WriteIdleTimeout(d time.Duration) ServerOption {
+    return func(s *Server) { s.writeIdleTimeout = d }
+}
+
+// Server 定义 TCP 服务器。
 type Server struct {
-    *gtcp.Server
+    mu               sync.Mutex
+    address          string                                    // 服务器监听地址。
+    handler          func(ctx context.Context, c *gtcp.Conn)   // 连接处理器。
+    detached         bool                                      // 处理器返回后是否保留连接。
+    tlsConfig        *tls.Config                               // TLS 配置。
+    maxConns         int                                       // 最大并发连接数。
+    maxConnsPerIP    int                                       // 单个 IP 最大并发连接数。
+    overflow         OverflowPolicy                            // 连接数超过上限时的处理策略。
+    readIdleTimeout  time.Duration                             // 读取空闲超时时间。
+    writeIdleTimeout time.Duration                             // 写入空闲超时时间。
+    framer           codec.Framer                              // 分帧器。
+    frameHandler     func(context.Context, *FrameConn, []byte) // 帧处理器。
+    sessions         *SessionManager                           // 会话管理器。
+    proxyProtocol    bool                                      // 是否解析 PROXY 协议头。
+    proxyOpts        []proxyproto.Option                       // PROXY 协议选项。
+
+    lis     net.Listener
+    closed  bool
+    ctx     context.Context
+    cancel  context.CancelFunc
+    slots   chan struct{}
+    conns   map[*serverConn]struct{}
+    perIP   map[string]int
+    handles sync.WaitGroup
 
-    address   string           // 服务器监听地址。
-    handler   func(*gtcp.Conn) // 连接处理器。
-    tlsConfig *tls.Config      // TLS 配置。
+    accepted atomic.Int64
+    rejected atomic.Int64
+    drained  atomic.Int64
+    killed   atomic.Int64
 }
 
 // NewServer 新建 TCP 服务器。
 func NewServer(opts ...ServerOption) *Server {
     srv := &Server{
         address: ":0",
-        handler: func(conn *gtcp.Conn) {},
+        handler: func(ctx context.Context, conn *gtcp.Conn) {},
+        conns:   make(map[*serverConn]struct{}),
+        perIP:   make(map[string]int),
     }
     for _, opt := range opts {
         opt(srv)
     }
-    if srv.tlsConfig != nil {
-        srv.Server = gtcp.NewServerTLS(srv.address, srv.tlsConfig, srv.handler)
-    } else {
-        srv.Server = gtcp.NewServer(srv.address, srv.handler)
+    if srv.frameHandler != nil && srv.framer == nil {
+        srv.framer = codec.LengthField(4, binary.BigEndian)
+    }
+    if srv.maxConns > 0 {
+        srv.slots = make(chan struct{}, srv.maxConns)
     }
     return srv
 }
 
+// Listen 绑定 TCP 服务监听地址。
+func (s *Server) Listen(ctx context.Context) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if s.lis != nil {
+        return nil
+    }
+    lis, err := net.Listen("tcp", s.address)
+    if err != nil {
+        return fmt.Errorf("[TCP] server listen on %s failed: %w", s.address, err)
+    }
+    if s.proxyProtocol {
+        pl, err := proxyproto.NewListener(lis, s.proxyOpts...)
+        if err != nil {
+            _ = lis.Close()
+            return fmt.Errorf("[TCP] server listen on %s failed: %w", s.address, err)
+        }
+        lis = pl
+    }
+    if s.tlsConfig != nil {
+        lis = tls.NewListener(lis, s.tlsConfig)
+    }
+    s.lis = lis
+    return nil
+}
+
 // Start 启动 TCP 服务器。
-func (s *Server) Start(ctx context.Context) (err error) {
-    glog.Infof("[TCP] server listening on %s", s.GetListenedAddress())
-    err = s.Run(ctx)
-    if !errors.Is(err, net.ErrClosed) {
+func (s *Server) Start(ctx context.Context) error {
+    if err := s.Listen(ctx); err != nil {
         return err
     }
-    return nil
+    s.mu.Lock()
+    if s.closed {
+        s.mu.Unlock()
+        // 启动前已停止时释放监听器。
+        if err := s.lis.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+            return err
+        }
+        return nil
+    }
+    s.ctx, s.cancel = context.WithCancel(ctx)
+    lis := s.lis
+    s.mu.Unlock()
+    glog.Infof("[TCP] server listening on %s", lis.Addr().String())
+    var delay time.Duration
+    for {
+        if s.slots != nil && s.overflow == OverflowQueue {
+            select {
+            case s.slots <- struct{}{}:
+            case <-s.ctx.Done():
+                return nil
+            }
+        }
+        nc, err := lis.Accept()
+        if err != nil {
+            if s.slots != nil && s.overflow == OverflowQueue {
+                <-s.slots
+            }
+            if errors.Is(err, net.ErrClosed) {
+                return nil
+            }
+            var ne net.Error
+            if errors.As(err, &ne) && ne.Timeout() {
+                // 参考 http.Server 的临时错误退避策略。
+                delay = min(max(delay*2, 5*time.Millisecond), time.Second)
+                glog.Warnf("[TCP] server accept error: %v; retrying in %v", err, delay)
+                time.Sleep(delay)
+                continue
+            }
+            return err
+        }
+        delay = 0
+        if s.slots != nil && s.overflow == OverflowReject {
+            select {
+            case s.slots <- struct{}{}:
+            default:
+                glog.Warnf("[TCP] server reject connection from %s: too many connections", nc.RemoteAddr())
+                s.rejected.Add(1)
+                _ = nc.Close()
+                continue
+            }
+        }
+        if s.proxyProtocol {
+            // 读取协议头可能阻塞，不占用接受循环。
+            go s.handshake(nc)
+            continue
+        }
+        s.admit(nc)
+    }
+}
+
+// handshake 读取 PROXY 协议头后登记连接。
+func (s *Server) handshake(nc net.Conn) {
+    if err := proxyproto.Handshake(nc); err != nil {
+        glog.Warnf("[TCP] server reject connection from %s: read PROXY header failed: %v", nc.RemoteAddr(), err)
+        s.reject(nc)
+        return
+    }
+    s.admit(nc)
+}
+
+// admit 登记新连接并启动处理协程，登记失败时关闭连接。
+func (s *Server) admit(nc net.Conn) {
+    c, ok := s.track(nc)
+    if !ok {
+        s.reject(nc)
+        return
+    }
+    go s.serve(c)
+}
+
+// reject 关闭被拒绝的连接并释放连接槽位。
+func (s *Server) reject(nc net.Conn) {
+    s.rejected.Add(1)
+    if s.slots != nil {
+        <-s.slots
+    }
+    _ = nc.Close()
+}
+
+// track 登记新连接，连接所属 IP 超过上限或服务器已停止时返回 false。
+func (s *Server) track(nc net.Conn) (*serverConn, bool) {
+    ip := remoteIP(nc.RemoteAddr())
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if s.closed {
+        return nil, false
+    }
+    if s.maxConnsPerIP > 0 && s.perIP[ip] >= s.maxConnsPerIP {
+        glog.Warnf("[TCP] server reject connection from %s: too many connections from %s", nc.RemoteAddr(), ip)
+        return nil, false
+    }
+    ctx, cancel := context.WithCancel(proxyproto.NewContext(s.ctx, nc))
+    c := newServerConn(nc, ctx, cancel, s.readIdleTimeout, s.writeIdleTimeout)
+    c.ip = ip
+    s.perIP[ip]++
+    s.conns[c] = struct{}{}
+    s.handles.Add(1)
+    s.accepted.Add(1)
+    return c, true
+}
+
+// release 注销连接。
+func (s *Server) release(c *serverConn) {
+    s.mu.Lock()
+    delete(s.conns, c)
+    if s.perIP[c.ip]--; s.perIP[c.ip] <= 0 {
+        delete(s.perIP, c.ip)
+    }
+    s.mu.Unlock()
+    if s.slots != nil {
+        <-s.slots
+    }
+    s.handles.Done()
+}
+
+// serve 处理连接，处理器返回后关闭连接，Handler 配置的处理器返回后等待连接被关闭。
+func (s *Server) serve(c *serverConn) {
+    defer s.release(c)
+    defer c.cancel()
+    defer c.Close()
+    defer func() {
+        if r := recover(); r != nil {
+            glog.Errorf("[TCP] server handle connection from %s panic: %v", c.RemoteAddr(), r)
+        }
+    }()
+    ctx := c.ctx
+    conn := gtcp.NewConnByNetConn(c)
+    var fc *FrameConn
+    if s.frameHandler != nil {
+        fc = NewFrameConn(conn, s.framer)
+    }
+    if s.sessions != nil {
+        sess := s.sessions.open(c.ctx, sessionConn{c: c, fc: fc}, conn)
+        defer s.sessions.Remove(sess)
+        ctx = sess.ctx
+    }
+    if fc != nil {
+        s.serveFrames(ctx, fc)
+        return
+    }
+    s.handler(ctx, conn)
+    if s.detached {
+        // 连接可能已交给其他协程，关闭后才注销。
+        <-c.closed
+    }
 }
 
 // Stop 停止 TCP 服务器。
+//
+// 停止接受新连接并取消全部连接上下文，阻塞中的读取将立即返回超时错误，写入不受影响；
+// 随后等待处理器返回，超过停止超时仍未返回的连接将被强制关闭。
 func (s *Server) Stop(ctx context.Context) error {
     glog.Info("[TCP] server stopping")
-    return s.Close(ctx)
+    s.mu.Lock()
+    s.closed = true
+    if s.cancel != nil {
+        s.cancel()
+    }
+    for c := range s.conns {
+        c.cancel()
+    }
+    active := len(s.conns)
+    var err error
+    if s.lis != nil {
+        if cErr := s.lis.Close(); cErr != nil && !errors.Is(cErr, net.ErrClosed) {
+            err = cErr
+        }
+    }
+    s.mu.Unlock()
+
+    done := make(chan struct{})
+    go func() {
+        s.handles.Wait()
+        close(done)
+    }()
+    killed := 0
+    select {
+    case <-done:
+    case <-ctx.Done():
+        s.mu.Lock()
+        killed = len(s.conns)
+        for c := range s.conns {
+            _ = c.Close()
+        }
+        s.mu.Unlock()
+    }
+    s.drained.Store(int64(active - killed))
+    s.killed.Store(int64(killed))
+    if active > 0 {
+        glog.Infof("[TCP] server stopped: %d connections drained, %d killed", active-killed, killed)
+    }
+    return err
+}
+
+// SetAddress 设置服务监听地址，需在 Listen 前调用。
+func (s *Server) SetAddress(address string) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.address = address
+}
+
+// GetAddress 获取服务监听地址。
+func (s *Server) GetAddress() string {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return s.address
+}
+
+// SetHandler 设置处理器，语义同 Handler，需在 Start 前调用。
+func (s *Server) SetHandler(handler func(conn *gtcp.Conn)) {
+    s.setHandler(handler)
+}
+
+// setHandler 设置处理器返回后保留连接的处理器。
+func (s *Server) setHandler(handler func(conn *gtcp.Conn)) {
+    s.handler = func(_ context.Context, conn *gtcp.Conn) { handler(conn) }
+    s.detached = true
+}
+
+// SetTLSConfig 设置 TLS 配置，需在 Listen 前调用。
+func (s *Server) SetTLSConfig(c *tls.Config) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.tlsConfig = c
+}
+
+// Run 启动 TCP 服务器，同 Start。
+func (s *Server) Run(ctx context.Context) error {
+    return s.Start(ctx)
+}
+
+// Close 停止 TCP 服务器，同 Stop。
+func (s *Server) Close(ctx context.Context) error {
+    return s.Stop(ctx)
+}
+
+// Stats TCP 服务器连接统计。
+type Stats struct {
+    Active   int   // 当前活动连接数。
+    Accepted int64 // 累计接受的连接数。
+    Rejected int64 // 累计拒绝的连接数。
+    Drained  int64 // 停止时处理器正常返回的连接数。
+    Killed   int64 // 停止时被强制关闭的连接数。
+}
+
+// Stats 返回连接统计。
+func (s *Server) Stats() Stats {
+    return Stats{
+        Active:   s.ActiveConns(),
+        Accepted: s.accepted.Load(),
+        Rejected: s.rejected.Load(),
+        Drained:  s.drained.Load(),
+        Killed:   s.killed.Load(),
+    }
+}
+
+// ActiveConns 返回当前活动连接数。
+func (s *Server) ActiveConns() int {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return len(s.conns)
+}
+
+// ListenAddr 返回实际监听地址，未监听时返回 nil。
+func (s *Server) ListenAddr() net.Addr {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if s.lis == nil {
+        return nil
+    }
+    return s.lis.Addr()
+}
+
+// GetListenedAddress 获取当前服务器监听地址，未监听时返回配置地址。
+func (s *Server) GetListenedAddress() string {
+    if addr := s.ListenAddr(); addr != nil {
+        return addr.String()
+    }
+    return s.GetAddress()
+}
+
+// GetListenedPort 获取当前服务器监听端口，未监听时返回 -1。
+func (s *Server) GetListenedPort() int {
+    if addr, ok := s.ListenAddr().(*net.TCPAddr); ok {
+        return addr.Port
+    }
+    return -1
+}
+
+// remoteIP 返回远程地址的 IP。
+func remoteIP(addr net.Addr) string {
+    if a, ok := addr.(*net.TCPAddr); ok {
+        return a.IP.String()
+    }
+    host, _, err := net.SplitHostPort(addr.String())
+    if err != nil {
+        return addr.String()
+    }
+    return host
 }
@@ -0,0 +1,439 @@
+package gtcp
+
+import (
+    "bufio"
+    "bytes"
+    "context"
+    "errors"
+    "io"
+    "net"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/camry/g/v2/gnet/gtcp"
+
+    "github.com/camry/dove/v2/proxyproto"
+    "github.com/camry/dove/v2/server/gtcp/codec"
+)
+
+// startServer 启动 TCP 服务器并返回其监听地址。
+func startServer(t *testing.T, s *Server) string {
+    if err := s.Listen(context.Background()); err != nil {
+        t.Fatal(err)
+    }
+    go func() { _ = s.Start(context.Background()) }()
+    t.Cleanup(func() { _ = s.Stop(context.Background()) })
+    return s.ListenAddr().String()
+}
+
+// waitClosed 等待连接被服务端关闭。
+func waitClosed(t *testing.T, c net.Conn) {
+    _ = c.SetReadDeadline(time.Now().Add(time.Second))
+    if _, err := c.Read(make([]byte, 1)); !errors.Is(err, io.EOF) {
+        t.Fatalf("expect connection closed, got %v", err)
+    }
+}
+
+func TestServer_MaxConns(t *testing.T) {
+    block := make(chan struct{})
+    defer close(block)
+    s := NewServer(Address("127.0.0.1:0"), MaxConns(1), ContextHandler(func(ctx context.Context, conn *gtcp.Conn) {
+        _ = conn.Send([]byte("ok"))
+        <-block
+    }))
+    addr := startServer(t, s)
+    c1, err := net.Dial("tcp", addr)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer c1.Close()
+    if _, err := io.ReadFull(c1, make([]byte, 2)); err != nil {
+        t.Fatal(err)
+    }
+    c2, err := net.Dial("tcp", addr)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer c2.Close()
+    waitClosed(t, c2)
+    if n := s.ActiveConns(); n != 1 {
+        t.Fatalf("expect 1 active connection, got %d", n)
+    }
+}
+
+func TestServer_MaxConnsPerIP(t *testing.T) {
+    block := make(chan struct{})
+    defer close(block)
+    s := NewServer(Address("127.0.0.1:0"), MaxConnsPerIP(1), Handler(func(conn *gtcp.Conn) {
+        defer conn.Close()
+        <-block
+    }))
+    addr := startServer(t, s)
+    c1, _ := net.Dial("tcp", addr)
+    defer c1.Close()
+    time.Sleep(20 * time.Millisecond)
+    c2, _ := net.Dial("tcp", addr)
+    defer c2.Close()
+    waitClosed(t, c2)
+}
+
+func TestServer_ReadIdleTimeout(t *testing.T) {
+    errs := make(chan error, 1)
+    s := NewServer(Address("127.0.0.1:0"), ReadIdleTimeout(50*time.Millisecond), ContextHandler(func(ctx context.Context, conn *gtcp.Conn) {
+        _, err := conn.Recv(1)
+        errs <- err
+    }))
+    addr := startServer(t, s)
+    c, _ := net.Dial("tcp", addr)
+    defer c.Close()
+    select {
+    case err := <-errs:
+        var ne net.Error
+        if !errors.As(err, &ne) || !ne.Timeout() {
+            t.Fatalf("expect timeout error, got %v", err)
+        }
+    case <-time.After(time.Second):
+        t.Fatal("expect read idle timeout")
+    }
+    waitClosed(t, c)
+}
+
+func TestServer_HandlerKeepsConn(t *testing.T) {
+    s := NewServer(Address("127.0.0.1:0"), MaxConns(1))
+    s.SetHandler(func(conn *gtcp.Conn) {
+        // 处理器返回后由其他协程继续使用连接。
+        go func() {
+            defer conn.Close()
+            data, err := conn.Recv(4)
+            if err == nil {
+                _ = conn.Send(data)
+            }
+        }()
+    })
+    addr := startServer(t, s)
+    c, err := net.Dial("tcp", addr)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer c.Close()
+    time.Sleep(20 * time.Millisecond)
+    _, _ = c.Write([]byte("ping"))
+    buf := make([]byte, 4)
+    _ = c.SetReadDeadline(time.Now().Add(time.Second))
+    if _, err := io.ReadFull(c, buf); err != nil || string(buf) != "ping" {
+        t.Fatalf("expect ping, got %q %v", buf, err)
+    }
+    waitClosed(t, c)
+    deadline := time.Now().Add(time.Second)
+    for s.ActiveConns() != 0 && time.Now().Before(deadline) {
+        time.Sleep(5 * time.Millisecond)
+    }
+    if n := s.ActiveConns(); n != 0 {
+        t.Fatalf("expect connection released after close, got %d", n)
+    }
+}
+
+func TestServer_KickDetached(t *testing.T) {
+    closed := make(chan struct{}, 1)
+    m := NewSessionManager(
+        OnSessionOpen(func(sess *Session) { sess.Bind("u1") }),
+        OnSessionClose(func(*Session) { closed <- struct{}{} }),
+    )
+    s := NewServer(Address("127.0.0.1:0"), Sessions(m))
+    // 处理器立即返回，连接保留给其他协程。
+    s.SetHandler(func(conn *gtcp.Conn) {})
+    addr := startServer(t, s)
+    c, err := net.Dial("tcp", addr)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer c.Close()
+    deadline := time.Now().Add(time.Second)
+    for m.Count() == 0 && time.Now().Before(deadline) {
+        time.Sleep(5 * time.Millisecond)
+    }
+    if !m.Kick("u1", nil) {
+        t.Fatal("expect session u1 kicked")
+    }
+    waitClosed(t, c)
+    select {
+    case <-closed:
+    case <-time.After(time.Second):
+        t.Fatal("expect session removed after kick")
+    }
+    deadline = time.Now().Add(time.Second)
+    for s.ActiveConns() != 0 && time.Now().Before(deadline) {
+        time.Sleep(5 * time.Millisecond)
+    }
+    if n := s.ActiveConns(); n != 0 {
+        t.Fatalf("expect connection released after kick, got %d", n)
+    }
+}
+
+func TestServer_StopCancelsContext(t *testing.T) {
+    done := make(chan error, 1)
+    s := NewServer(Address("127.0.0.1:0"), ContextHandler(func(ctx context.Context, conn *gtcp.Conn) {
+        _, _ = conn.Recv(1)
+        if ctx.Err() == nil {
+            done <- errors.New("expect context canceled")
+            return
+        }
+        // 上下文取消后仍可写入。
+        done <- conn.Send([]byte("bye"))
+    }))
+    addr := startServer(t, s)
+    c, _ := net.Dial("tcp", addr)
+    defer c.Close()
+    time.Sleep(20 * time.Millisecond)
+    _ = s.Stop(context.Background())
+    select {
+    case err := <-done:
+        if err != nil {
+            t.Fatal(err)
+        }
+    case <-time.After(time.Second):
+        t.Fatal("expect handler to exit after stop")
+    }
+    buf := make([]byte, 3)
+    if _, err := io.ReadFull(c, buf); err != nil || string(buf) != "bye" {
+        t.Fatalf("expect bye, got %q %v", buf, err)
+    }
+}
+
+func TestServer_Drain(t *testing.T) {
+    release := make(chan struct{})
+    s := NewServer(Address("127.0.0.1:0"), ContextHandler(func(ctx context.Context, conn *gtcp.Conn) {
+        mode, _ := conn.Recv(1)
+        if string(mode) == "d" {
+            <-ctx.Done()
+            return
+        }
+        // 忽略上下文的处理器将在停止超时后被强制关闭。
+        <-release
+    }))
+    defer close(release)
+    addr := startServer(t, s)
+    for _, mode := range []string{"d", "d", "k"} {
+        c, err := net.Dial("tcp", addr)
+        if err != nil {
+            t.Fatal(err)
+        }
+        defer c.Close()
+        _, _ = c.Write([]byte(mode))
+    }
+    time.Sleep(20 * time.Millisecond)
+    if got := s.Stats().Active; got != 3 {
+        t.Fatalf("expect 3 active connections, got %d", got)
+    }
+    ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+    defer cancel()
+    _ = s.Stop(ctx)
+    if st := s.Stats(); st.Drained != 2 || st.Killed != 1 || st.Accepted != 3 {
+        t.Fatalf("expect 2 drained and 1 killed, got %+v", st)
+    }
+}
+
+func TestServer_FrameHandler(t *testing.T) {
+    framer := codec.Varint(codec.MaxFrameSize(16))
+    s := NewServer(Address("127.0.0.1:0"), Codec(framer), FrameHandler(func(ctx context.Context, conn *FrameConn, frame []byte) {
+        _ = conn.WriteFrame(append([]byte("echo:"), frame...))
+    }))
+    addr := startServer(t, s)
+    c, err := net.Dial("tcp", addr)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer c.Close()
+    // 多个帧合并发送。
+    var buf bytes.Buffer
+    for _, msg := range []string{"a", "bc"} {
+        _ = framer.WriteFrame(&buf, []byte(msg))
+    }
+    _, _ = c.Write(buf.Bytes())
+    r := bufio.NewReader(c)
+    for _, want := range []string{"echo:a", "echo:bc"} {
+        got, err := framer.ReadFrame(r)
+        if err != nil || string(got) != want {
+            t.Fatalf("expect %q, got %q %v", want, got, err)
+        }
+    }
+    // 超过最大帧长度时关闭连接。
+    _, _ = c.Write([]byte{32})
+    waitClosed(t, c)
+}
+
+
+func TestServer_Sessions(t *testing.T) {
+    framer := codec.Line()
+    m := NewSessionManager()
+    s := NewServer(Address("127.0.0.1:0"), Sessions(m), Codec(framer), FrameHandler(func(ctx context.Context, conn *FrameConn, frame []byte) {
+        sess, _ := SessionFromContext(ctx)
+        sess.Bind(string(frame))
+        sess.Join("room")
+        _ = sess.Send([]byte("joined"))
+    }))
+    addr := startServer(t, s)
+    readers := make(map[string]*bufio.Reader)
+    for _, uid := range []string{"u1", "u2"} {
+        c, err := net.Dial("tcp", addr)
+        if err != nil {
+            t.Fatal(err)
+        }
+        defer c.Close()
+        _ = framer.WriteFrame(c, []byte(uid))
+        r := bufio.NewReader(c)
+        if got, err := framer.ReadFrame(r); err != nil || string(got) != "joined" {
+            t.Fatalf("expect joined, got %q %v", got, err)
+        }
+        readers[uid] = r
+    }
+    if n := m.Broadcast("room", []byte("hello")); n != 2 {
+        t.Fatalf("expect broadcast to 2 sessions, got %d", n)
+    }
+    for uid, r := range readers {
+        if got, err := framer.ReadFrame(r); err != nil || string(got) != "hello" {
+            t.Fatalf("%s expect hello, got %q %v", uid, got, err)
+        }
+    }
+    if !m.Kick("u1", []byte("bye")) {
+        t.Fatal("expect session u1 found")
+    }
+    if got, err := framer.ReadFrame(readers["u1"]); err != nil || string(got) != "bye" {
+        t.Fatalf("expect bye, got %q %v", got, err)
+    }
+    if _, err := framer.ReadFrame(readers["u1"]); !errors.Is(err, io.EOF) {
+        t.Fatalf("expect connection closed, got %v", err)
+    }
+    deadline := time.Now().Add(time.Second)
+    for m.Count() != 1 && time.Now().Before(deadline) {
+        time.Sleep(10 * time.Millisecond)
+    }
+    if _, ok := m.GetByUID("u1"); ok || m.GroupCount("room") != 1 {
+        t.Fatalf("expect session u1 removed, got %d sessions in room", m.GroupCount("room"))
+    }
+}
+
+func TestServer_SessionHeartbeat(t *testing.T) {
+    m := NewSessionManager(HeartbeatTimeout(50 * time.Millisecond))
+    s := NewServer(Address("127.0.0.1:0"), Sessions(m), ContextHandler(func(ctx context.Context, conn *gtcp.Conn) {
+        for {
+            if _, err := conn.Recv(1); err != nil {
+                return
+            }
+        }
+    }))
+    addr := startServer(t, s)
+    c, err := net.Dial("tcp", addr)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer c.Close()
+    // 持续发送心跳时会话保持。
+    for range 5 {
+        _, _ = c.Write([]byte{0})
+        time.Sleep(20 * time.Millisecond)
+    }
+    if n := m.Count(); n != 1 {
+        t.Fatalf("expect 1 session, got %d", n)
+    }
+    waitClosed(t, c)
+}
+
+func TestSession_Backpressure(t *testing.T) {
+    m := NewSessionManager(SendQueueSize(1))
+    block := make(chan struct{})
+    opened := make(chan *Session, 1)
+    s := NewServer(Address("127.0.0.1:0"), Sessions(m), ContextHandler(func(ctx context.Context, conn *gtcp.Conn) {
+        sess, _ := SessionFromContext(ctx)
+        // 占用写锁模拟慢连接。
+        sess.wmu.Lock()
+        opened <- sess
+        <-block
+        sess.wmu.Unlock()
+        <-ctx.Done()
+    }))
+    addr := startServer(t, s)
+    c, err := net.Dial("tcp", addr)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer c.Close()
+    defer close(block)
+    sess := <-opened
+    // 写入协程取走一条消息后阻塞，队列容纳一条。
+    _ = sess.Send([]byte("1"))
+    time.Sleep(20 * time.Millisecond)
+    if err := sess.Send([]byte("2")); err != nil {
+        t.Fatal(err)
+    }
+    if err := sess.Send([]byte("3")); !errors.Is(err, ErrQueueFull) {
+        t.Fatalf("expect ErrQueueFull, got %v", err)
+    }
+}
+
+func TestServer_ProxyProtocol(t *testing.T) {
+    addrs := make(chan string, 1)
+    s := NewServer(Address("127.0.0.1:0"), ProxyProtocol(proxyproto.Trusted("127.0.0.0/8")), ContextHandler(func(ctx context.Context, conn *gtcp.Conn) {
+        h, _ := proxyproto.FromContext(ctx)
+        addrs <- conn.RemoteAddr().String() + " " + h.Destination.String()
+    }))
+    addr := startServer(t, s)
+    c, err := net.Dial("tcp", addr)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer c.Close()
+    _, _ = c.Write([]byte("PROXY TCP4 192.0.2.1 198.51.100.1 5678 80\r\n"))
+    select {
+    case got := <-addrs:
+        if got != "192.0.2.1:5678 198.51.100.1:80" {
+            t.Fatalf("expect client address from PROXY header, got %s", got)
+        }
+    case <-time.After(time.Second):
+        t.Fatal("expect handler called")
+    }
+}
+
+func TestServer_ProxyProtocolUntrusted(t *testing.T) {
+    got := make(chan string, 1)
+    s := NewServer(Address("127.0.0.1:0"), MaxConnsPerIP(1), ProxyProtocol(proxyproto.Trusted("10.0.0.0/8")), ContextHandler(func(ctx context.Context, conn *gtcp.Conn) {
+        data, _ := conn.Recv(5)
+        got <- conn.RemoteAddr().String() + " " + string(data)
+        <-ctx.Done()
+    }))
+    addr := startServer(t, s)
+    c1, err := net.Dial("tcp", addr)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer c1.Close()
+    _, _ = c1.Write([]byte("PROXY TCP4 192.0.2.1 198.51.100.1 5678 80\r\n"))
+    select {
+    case v := <-got:
+        if !strings.HasPrefix(v, "127.0.0.1:") || !strings.HasSuffix(v, " PROXY") {
+            t.Fatalf("expect untrusted header ignored, got %s", v)
+        }
+    case <-time.After(time.Second):
+        t.Fatal("expect handler called")
+    }
+    // 伪造不同客户端地址不能绕过单 IP 连接数限制。
+    c2, err := net.Dial("tcp", addr)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer c2.Close()
+    _, _ = c2.Write([]byte("PROXY TCP4 192.0.2.2 198.51.100.1 5678 80\r\n"))
+    _ = c2.SetReadDeadline(time.Now().Add(time.Second))
+    var ne net.Error
+    if _, err := c2.Read(make([]byte, 1)); err == nil || errors.As(err, &ne) && ne.Timeout() {
+        t.Fatalf("expect connection rejected, got %v", err)
+    }
+}
+
+func TestServer_ProxyProtocolNoTrusted(t *testing.T) {
+    s := NewServer(Address("127.0.0.1:0"), ProxyProtocol())
+    if err := s.Listen(context.Background()); !errors.Is(err, proxyproto.ErrNoTrusted) {
+        t.Fatalf("expect ErrNoTrusted, got %v", err)
+    }
+}
@@ -0,0 +1,580 @@
+package gtcp
+
+import (
+    "context"
+    "errors"
+    "net"
+    "sync"
+    "sync/atomic"
+    "time"
+
+    "github.com/camry/g/v2/glog"
+    "github.com/camry/g/v2/gnet/gtcp"
+)
+
+var (
+    // ErrQueueFull 会话发送队列已满。
+    ErrQueueFull = errors.New("gtcp: session send queue full")
+    // ErrSessionClosed 会话已关闭。
+    ErrSessionClosed = errors.New("gtcp: session closed")
+)
+
+// flushTimeout 会话关闭时写出剩余消息的超时时间。
+const flushTimeout = time.Second
+
+// BackpressurePolicy 定义会话发送队列已满时的处理策略。
+type BackpressurePolicy int
+
+const (
+    // BackpressureDrop 丢弃新消息并返回 ErrQueueFull。
+    BackpressureDrop BackpressurePolicy = iota
+    // BackpressureDropOldest 丢弃队列中最早的消息后入队新消息。
+    BackpressureDropOldest
+    // BackpressureClose 关闭发送过慢的会话并返回 ErrQueueFull。
+    BackpressureClose
+)
+
+// Sessions 配置会话管理器，连接建立时创建会话，连接关闭时移除会话。
+//
+// 会话可通过处理器上下文的 SessionFromContext 获取，同一管理器可由多个服务器共享。
+func Sessions(m *SessionManager) ServerOption {
+    return func(s *Server) { s.sessions = m }
+}
+
+// SessionOption 定义一个会话管理器选项类型。
+type SessionOption func(m *SessionManager)
+
+// SendQueueSize 配置单个会话的发送队列长度，默认 256。
+func SendQueueSize(n int) SessionOption {
+    return func(m *SessionManager) { m.queueSize = n }
+}
+
+// Backpressure 配置发送队列已满时的处理策略，默认 BackpressureDrop。
+func Backpressure(policy BackpressurePolicy) SessionOption {
+    return func(m *SessionManager) { m.policy = policy }
+}
+
+// HeartbeatTimeout 配置心跳超时时间，超过该时间未收到任何数据的会话将被关闭，0 表示不检测。
+func HeartbeatTimeout(d time.Duration) SessionOption {
+    return func(m *SessionManager) { m.heartbeat = d }
+}
+
+// OnSessionOpen 配置会话创建回调。
+func OnSessionOpen(fn func(s *Session)) SessionOption {
+    return func(m *SessionManager) { m.onOpen = fn }
+}
+
+// OnSessionClose 配置会话移除回调。
+func OnSessionClose(fn func(s *Session)) SessionOption {
+    return func(m *SessionManager) { m.onClose = fn }
+}
+
+// SessionConn 定义会话的底层连接。
+//
+// gtcp 服务器的连接已实现该接口，其他协议（如 gws 的 WebSocket 连接）实现后可通过 SessionManager.Open 接入会话管理。
+type SessionConn interface {
+    // RemoteAddr 返回远程地址。
+    RemoteAddr() net.Addr
+    // LastActive 返回最近一次收到数据的时间，用于心跳检测。
+    LastActive() time.Time
+    // WriteMessage 写出一条消息，typ 为 SendMessage 传入的消息类型，Send 发送的消息为 0。
+    WriteMessage(typ int, data []byte) error
+    // SetWriteDeadline 设置写入截止时间，会话关闭时用于限制剩余消息的写出时间。
+    SetWriteDeadline(t time.Time) error
+    // Shutdown 关闭连接，会话关闭或写入失败后调用，连接的读取循环随后返回。
+    Shutdown()
+}
+
+// SessionManager 定义会话管理器，负责会话查找、分组广播与踢出。
+type SessionManager struct {
+    queueSize int                // 发送队列长度。
+    policy    BackpressurePolicy // 发送队列已满时的处理策略。
+    heartbeat time.Duration      // 心跳超时时间。
+    onOpen    func(s *Session)   // 会话创建回调。
+    onClose   func(s *Session)   // 会话移除回调。
+
+    seq      atomic.Uint64
+    mu       sync.RWMutex
+    sessions map[uint64]*Session
+    uids     map[string]*Session
+    groups   map[string]map[*Session]struct{}
+    watching bool
+}
+
+// NewSessionManager 新建会话管理器。
+func NewSessionManager(opts ...SessionOption) *SessionManager {
+    m := &SessionManager{
+        queueSize: 256,
+        sessions:  make(map[uint64]*Session),
+        uids:      make(map[string]*Session),
+        groups:    make(map[string]map[*Session]struct{}),
+    }
+    for _, opt := range opts {
+        opt(m)
+    }
+    if m.queueSize <= 0 {
+        m.queueSize = 1
+    }
+    return m
+}
+
+// Open 为连接创建会话并启动写入协程，会话上下文派生自 ctx，连接的读取循环结束后需调用 Remove。
+func (m *SessionManager) Open(ctx context.Context, c SessionConn) *Session {
+    return m.open(ctx, c, nil)
+}
+
+// open 为连接创建会话，conn 为 gtcp 服务器的连接，其他协议为 nil。
+func (m *SessionManager) open(ctx context.Context, c SessionConn, conn *gtcp.Conn) *Session {
+    s := &Session{
+        id:      m.seq.Add(1),
+        m:       m,
+        c:       c,
+        conn:    conn,
+        queue:   make(chan message, m.queueSize),
+        closing: make(chan struct{}),
+        done:    make(chan struct{}),
+    }
+    s.ctx = context.WithValue(ctx, sessionKey{}, s)
+    m.mu.Lock()
+    m.sessions[s.id] = s
+    if m.heartbeat > 0 && !m.watching {
+        m.watching = true
+        go m.watch()
+    }
+    m.mu.Unlock()
+    go s.writeLoop()
+    if m.onOpen != nil {
+        m.onOpen(s)
+    }
+    return s
+}
+
+// Remove 移除会话并停止其写入协程。
+func (m *SessionManager) Remove(s *Session) {
+    m.mu.Lock()
+    delete(m.sessions, s.id)
+    if s.uid != "" && m.uids[s.uid] == s {
+        delete(m.uids, s.uid)
+    }
+    for group := range s.groups {
+        m.leave(group, s)
+    }
+    m.mu.Unlock()
+    close(s.done)
+    if m.onClose != nil {
+        m.onClose(s)
+    }
+}
+
+// leave 将会话移出分组，调用方需持有锁。
+func (m *SessionManager) leave(group string, s *Session) {
+    members := m.groups[group]
+    delete(members, s)
+    if len(members) == 0 {
+        delete(m.groups, group)
+    }
+    delete(s.groups, group)
+}
+
+// watch 定期关闭心跳超时的会话，没有会话时退出。
+func (m *SessionManager) watch() {
+    ticker := time.NewTicker(max(m.heartbeat/2, 10*time.Millisecond))
+    defer ticker.Stop()
+    for range ticker.C {
+        m.mu.Lock()
+        if len(m.sessions) == 0 {
+            m.watching = false
+            m.mu.Unlock()
+            return
+        }
+        var expired []*Session
+        for _, s := range m.sessions {
+            if time.Since(s.LastActive()) > m.heartbeat {
+                expired = append(expired, s)
+            }
+        }
+        m.mu.Unlock()
+        for _, s := range expired {
+            glog.Warnf("[Session] session %d from %s heartbeat timeout", s.id, s.RemoteAddr())
+            s.Close()
+        }
+    }
+}
+
+// Get 按会话 ID 查找会话。
+func (m *SessionManager) Get(id uint64) (*Session, bool) {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+    s, ok := m.sessions[id]
+    return s, ok
+}
+
+// GetByUID 按绑定的用户 ID 查找会话。
+func (m *SessionManager) GetByUID(uid string) (*Session, bool) {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+    s, ok := m.uids[uid]
+    return s, ok
+}
+
+// Count 返回当前会话数。
+func (m *SessionManager) Count() int {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+    return len(m.sessions)
+}
+
+// GroupCount 返回分组内的会话数。
+func (m *SessionManager) GroupCount(group string) int {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+    return len(m.groups[group])
+}
+
+// Range 遍历全部会话，fn 返回 false 时停止遍历。
+func (m *SessionManager) Range(fn func(s *Session) bool) {
+    for _, s := range m.snapshot("", false) {
+        if !fn(s) {
+            return
+        }
+    }
+}
+
+// Broadcast 向分组内全部会话发送消息，返回成功入队的会话数。
+func (m *SessionManager) Broadcast(group string, frame []byte) int {
+    return m.broadcast(m.snapshot(group, true), message{data: frame})
+}
+
+// BroadcastMessage 向分组内全部会话发送指定类型的消息，返回成功入队的会话数。
+func (m *SessionManager) BroadcastMessage(group string, typ int, data []byte) int {
+    return m.broadcast(m.snapshot(group, true), message{typ: typ, data: data})
+}
+
+// BroadcastAll 向全部会话发送消息，返回成功入队的会话数。
+func (m *SessionManager) BroadcastAll(frame []byte) int {
+    return m.broadcast(m.snapshot("", false), message{data: frame})
+}
+
+// Kick 发送消息后关闭指定用户 ID 的会话，frame 为 nil 时直接关闭，会话不存在时返回 false。
+func (m *SessionManager) Kick(uid string, frame []byte) bool {
+    s, ok := m.GetByUID(uid)
+    if !ok {
+        return false
+    }
+    s.Kick(frame)
+    return true
+}
+
+// snapshot 返回全部会话或分组会话的快照，广播时不持有锁。
+func (m *SessionManager) snapshot(group string, grouped bool) []*Session {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+    if grouped {
+        list := make([]*Session, 0, len(m.groups[group]))
+        for s := range m.groups[group] {
+            list = append(list, s)
+        }
+        return list
+    }
+    list := make([]*Session, 0, len(m.sessions))
+    for _, s := range m.sessions {
+        list = append(list, s)
+    }
+    return list
+}
+
+// broadcast 向会话列表发送消息，单个会话队列已满不影响其他会话。
+func (m *SessionManager) broadcast(list []*Session, msg message) int {
+    n := 0
+    for _, s := range list {
+        if s.send(msg) == nil {
+            n++
+        }
+    }
+    return n
+}
+
+// sessionKey 会话上下文键。
+type sessionKey struct{}
+
+// SessionFromContext 从处理器上下文中获取会话。
+func SessionFromContext(ctx context.Context) (*Session, bool) {
+    s, ok := ctx.Value(sessionKey{}).(*Session)
+    return s, ok
+}
+
+// message 待发送的消息。
+type message struct {
+    typ  int
+    data []byte
+}
+
+// Session 定义连接会话。
+//
+// 发送的消息进入有界队列，由独立协程按序写出，慢连接不会阻塞调用方；
+// 使用 FrameHandler 时消息按 Codec 分帧写入，否则原样写入。
+type Session struct {
+    id    uint64
+    m     *SessionManager
+    c     SessionConn
+    conn  *gtcp.Conn
+    ctx   context.Context
+    queue chan message
+
+    mu     sync.RWMutex
+    wmu    sync.Mutex
+    uid    string
+    attrs  map[string]any
+    groups map[string]struct{}
+
+    closeOnce sync.Once
+    closing   chan struct{}
+    done      chan struct{}
+}
+
+// ID 返回会话 ID。
+func (s *Session) ID() uint64 {
+    return s.id
+}
+
+// Context 返回会话上下文，连接关闭或服务器停止时取消。
+func (s *Session) Context() context.Context {
+    return s.ctx
+}
+
+// Conn 返回会话所属的 gtcp 连接，通过 SessionManager.Open 接入的其他协议连接返回 nil。
+func (s *Session) Conn() *gtcp.Conn {
+    return s.conn
+}
+
+// RemoteAddr 返回远程地址。
+func (s *Session) RemoteAddr() net.Addr {
+    return s.c.RemoteAddr()
+}
+
+// LastActive 返回最近一次收到数据的时间。
+func (s *Session) LastActive() time.Time {
+    return s.c.LastActive()
+}
+
+// UID 返回绑定的用户 ID。
+func (s *Session) UID() string {
+    s.m.mu.RLock()
+    defer s.m.mu.RUnlock()
+    return s.uid
+}
+
+// Bind 绑定用户 ID，同一用户 ID 仅指向最近绑定的会话。
+func (s *Session) Bind(uid string) {
+    s.m.mu.Lock()
+    defer s.m.mu.Unlock()
+    if _, ok := s.m.sessions[s.id]; !ok {
+        return
+    }
+    if s.uid != "" && s.m.uids[s.uid] == s {
+        delete(s.m.uids, s.uid)
+    }
+    s.uid = uid
+    if uid != "" {
+        s.m.uids[uid] = s
+    }
+}
+
+// Join 加入分组。
+func (s *Session) Join(group string) {
+    s.m.mu.Lock()
+    defer s.m.mu.Unlock()
+    if _, ok := s.m.sessions[s.id]; !ok {
+        return
+    }
+    members, ok := s.m.groups[group]
+    if !ok {
+        members = make(map[*Session]struct{})
+        s.m.groups[group] = members
+    }
+    members[s] = struct{}{}
+    if s.groups == nil {
+        s.groups = make(map[string]struct{})
+    }
+    s.groups[group] = struct{}{}
+}
+
+// Leave 离开分组。
+func (s *Session) Leave(group string) {
+    s.m.mu.Lock()
+    defer s.m.mu.Unlock()
+    if _, ok := s.groups[group]; ok {
+        s.m.leave(group, s)
+    }
+}
+
+// Set 设置会话属性。
+func (s *Session) Set(key string, value any) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if s.attrs == nil {
+        s.attrs = make(map[string]any)
+    }
+    s.attrs[key] = value
+}
+
+// Get 获取会话属性。
+func (s *Session) Get(key string) (any, bool) {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    v, ok := s.attrs[key]
+    return v, ok
+}
+
+// Send 将消息放入发送队列，队列已满时按 Backpressure 策略处理。
+func (s *Session) Send(frame []byte) error {
+    return s.send(message{data: frame})
+}
+
+// SendMessage 将指定类型的消息放入发送队列，消息类型由底层连接解释，如 WebSocket 的文本与二进制消息。
+func (s *Session) SendMessage(typ int, data []byte) error {
+    return s.send(message{typ: typ, data: data})
+}
+
+// send 将消息放入发送队列，队列已满时按 Backpressure 策略处理。
+func (s *Session) send(msg message) error {
+    select {
+    case <-s.closing:
+        return ErrSessionClosed
+    case <-s.done:
+        return ErrSessionClosed
+    default:
+    }
+    select {
+    case s.queue <- msg:
+        return nil
+    default:
+    }
+    switch s.m.policy {
+    case BackpressureDropOldest:
+        for range 2 {
+            select {
+            case <-s.queue:
+            default:
+            }
+            select {
+            case s.queue <- msg:
+                return nil
+            default:
+            }
+        }
+    case BackpressureClose:
+        glog.Warnf("[Session] session %d from %s closed: send queue full", s.id, s.RemoteAddr())
+        s.Close()
+    }
+    return ErrQueueFull
+}
+
+// Write 直接写入消息，与发送队列的写入互斥。
+func (s *Session) Write(frame []byte) error {
+    return s.write(message{data: frame})
+}
+
+// write 写出一条消息，与发送队列的写入互斥。
+func (s *Session) write(msg message) error {
+    s.wmu.Lock()
+    defer s.wmu.Unlock()
+    return s.c.WriteMessage(msg.typ, msg.data)
+}
+
+// Close 关闭会话，写出队列中剩余的消息后关闭连接。
+func (s *Session) Close() {
+    s.closeOnce.Do(func() { close(s.closing) })
+}
+
+// Kick 发送消息后关闭会话，frame 为 nil 时直接关闭。
+func (s *Session) Kick(frame []byte) {
+    if frame != nil {
+        _ = s.Send(frame)
+    }
+    s.Close()
+}
+
+// writeLoop 按序写出发送队列中的消息。
+func (s *Session) writeLoop() {
+    for {
+        select {
+        case msg := <-s.queue:
+            if err := s.write(msg); err != nil {
+                s.fail(err)
+                return
+            }
+        case <-s.closing:
+            s.flush()
+            s.shutdown()
+            return
+        case <-s.done:
+            return
+        }
+    }
+}
+
+// flush 在超时时间内写出队列中剩余的消息。
+func (s *Session) flush() {
+    _ = s.c.SetWriteDeadline(time.Now().Add(flushTimeout))
+    for {
+        select {
+        case msg := <-s.queue:
+            if err := s.write(msg); err != nil {
+                return
+            }
+        default:
+            return
+        }
+    }
+}
+
+// fail 写入失败时关闭会话。
+func (s *Session) fail(err error) {
+    if !errors.Is(err, net.ErrClosed) {
+        glog.Warnf("[Session] session %d write to %s failed: %v", s.id, s.RemoteAddr(), err)
+    }
+    s.closeOnce.Do(func() { close(s.closing) })
+    s.shutdown()
+}
+
+// shutdown 关闭底层连接，处理器随后返回。
+func (s *Session) shutdown() {
+    s.c.Shutdown()
+}
+
+// sessionConn 将 gtcp 服务端连接适配为 SessionConn，按帧模式时消息按帧写入。
+type sessionConn struct {
+    c  *serverConn
+    fc *FrameConn
+}
+
+// RemoteAddr 返回远程地址。
+func (c sessionConn) RemoteAddr() net.Addr {
+    return c.c.RemoteAddr()
+}
+
+// LastActive 返回最近一次收到数据的时间。
+func (c sessionConn) LastActive() time.Time {
+    return time.Unix(0, c.c.lastRead.Load())
+}
+
+// WriteMessage 写出一条消息，TCP 连接不区分消息类型。
+func (c sessionConn) WriteMessage(_ int, data []byte) error {
+    if c.fc != nil {
+        return c.fc.WriteFrame(data)
+    }
+    _, err := c.c.Write(data)
+    return err
+}
+
+// SetWriteDeadline 设置写入截止时间。
+func (c sessionConn) SetWriteDeadline(t time.Time) error {
+    return c.c.SetWriteDeadline(t)
+}
+
+// Shutdown 取消连接上下文并关闭连接。
+func (c sessionConn) Shutdown() {
+    c.c.cancel()
+    _ = c.c.Close()
+}
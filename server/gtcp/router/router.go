@@ -0,0 +1,110 @@
+package router
+
+import (
+    "encoding/binary"
+    "encoding/json"
+    "fmt"
+
+    "google.golang.org/protobuf/proto"
+
+    "github.com/camry/dove/v2/server/gtcp/codec"
+)
+
+// Kind 消息类型。
+type Kind uint8
+
+const (
+    // KindRequest 请求消息，接收方需按序列号回复。
+    KindRequest Kind = iota + 1
+    // KindResponse 响应消息。
+    KindResponse
+    // KindPush 单向推送消息，接收方不回复。
+    KindPush
+)
+
+// headerSize 消息头长度：类型（1）+ 状态码（2）+ 命令（4）+ 序列号（4）。
+const headerSize = 11
+
+// Message 路由消息，按大端序编码为 kind|code|cmd|seq|payload。
+type Message struct {
+    Kind    Kind
+    Code    uint16 // 响应状态码，0 表示成功，非 0 时负载为 JSON 编码的 errors.Error。
+    Cmd     uint32
+    Seq     uint32
+    Payload []byte
+}
+
+// Marshal 编码消息。
+func (m *Message) Marshal() []byte {
+    buf := make([]byte, headerSize+len(m.Payload))
+    buf[0] = byte(m.Kind)
+    binary.BigEndian.PutUint16(buf[1:], m.Code)
+    binary.BigEndian.PutUint32(buf[3:], m.Cmd)
+    binary.BigEndian.PutUint32(buf[7:], m.Seq)
+    copy(buf[headerSize:], m.Payload)
+    return buf
+}
+
+// Unmarshal 解码消息，负载引用 frame 的底层数组。
+func (m *Message) Unmarshal(frame []byte) error {
+    if len(frame) < headerSize {
+        return fmt.Errorf("%w: message header too short", codec.ErrInvalidFrame)
+    }
+    m.Kind = Kind(frame[0])
+    m.Code = binary.BigEndian.Uint16(frame[1:])
+    m.Cmd = binary.BigEndian.Uint32(frame[3:])
+    m.Seq = binary.BigEndian.Uint32(frame[7:])
+    m.Payload = frame[headerSize:]
+    return nil
+}
+
+// Codec 定义消息负载编解码接口，可实现 msgpack 等其他格式。
+type Codec interface {
+    Marshal(v any) ([]byte, error)
+    Unmarshal(data []byte, v any) error
+}
+
+// JSON JSON 负载编解码器。
+var JSON Codec = jsonCodec{}
+
+// Proto Protobuf 负载编解码器，值必须实现 proto.Message。
+var Proto Codec = protoCodec{}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+    return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+    return json.Unmarshal(data, v)
+}
+
+type protoCodec struct{}
+
+func (protoCodec) Marshal(v any) ([]byte, error) {
+    m, ok := v.(proto.Message)
+    if !ok {
+        return nil, fmt.Errorf("router: %T is not a proto.Message", v)
+    }
+    return proto.Marshal(m)
+}
+
+func (protoCodec) Unmarshal(data []byte, v any) error {
+    m, ok := v.(proto.Message)
+    if !ok {
+        return fmt.Errorf("router: %T is not a proto.Message", v)
+    }
+    return proto.Unmarshal(data, m)
+}
+
+// encode 编码负载，[]byte 原样返回，nil 返回空负载。
+func encode(c Codec, v any) ([]byte, error) {
+    switch v := v.(type) {
+    case nil:
+        return nil, nil
+    case []byte:
+        return v, nil
+    }
+    return c.Marshal(v)
+}
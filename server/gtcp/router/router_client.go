@@ -0,0 +1,196 @@
+package router
+
+import (
+    "bufio"
+    "context"
+    "encoding/binary"
+    "encoding/json"
+    "net"
+    "sync"
+    "sync/atomic"
+
+    "github.com/camry/g/v2/glog"
+
+    "github.com/camry/dove/v2/errors"
+    "github.com/camry/dove/v2/server/gtcp/codec"
+)
+
+// ErrClientClosed 客户端已关闭。
+var ErrClientClosed = errors.ServiceUnavailable("CLIENT_CLOSED", "router client closed")
+
+// ClientOption 定义一个路由客户端选项类型。
+type ClientOption func(c *Client)
+
+// ClientFramer 配置客户端分帧器，需与服务端 gtcp.Codec 一致，默认 4 字节大端长度前缀。
+func ClientFramer(f codec.Framer) ClientOption {
+    return func(c *Client) { c.framer = f }
+}
+
+// ClientCodec 配置客户端负载编解码器，需与服务端一致，默认 JSON。
+func ClientCodec(cc Codec) ClientOption {
+    return func(c *Client) { c.codec = cc }
+}
+
+// OnPush 配置服务端推送消息的处理函数，处理函数在读取协程中依次调用。
+func OnPush(fn func(cmd uint32, payload []byte)) ClientOption {
+    return func(c *Client) { c.onPush = fn }
+}
+
+// Client 路由客户端，支持按序列号关联的并发请求与服务端推送。
+type Client struct {
+    conn    net.Conn
+    framer  codec.Framer
+    codec   Codec
+    onPush  func(cmd uint32, payload []byte)
+    seq     atomic.Uint32
+    wmu     sync.Mutex
+    mu      sync.Mutex
+    pending map[uint32]chan *Message
+    err     error
+    done    chan struct{}
+}
+
+// Dial 连接路由服务端。
+func Dial(ctx context.Context, address string, opts ...ClientOption) (*Client, error) {
+    var d net.Dialer
+    conn, err := d.DialContext(ctx, "tcp", address)
+    if err != nil {
+        return nil, err
+    }
+    return NewClient(conn, opts...), nil
+}
+
+// NewClient 使用已建立的连接新建路由客户端。
+func NewClient(conn net.Conn, opts ...ClientOption) *Client {
+    c := &Client{
+        conn:    conn,
+        framer:  codec.LengthField(4, binary.BigEndian),
+        codec:   JSON,
+        pending: make(map[uint32]chan *Message),
+        done:    make(chan struct{}),
+    }
+    for _, o := range opts {
+        o(c)
+    }
+    go c.readLoop()
+    return c
+}
+
+// readLoop 读取消息并分发响应与推送。
+func (c *Client) readLoop() {
+    r := bufio.NewReader(c.conn)
+    var err error
+    for {
+        var frame []byte
+        if frame, err = c.framer.ReadFrame(r); err != nil {
+            break
+        }
+        msg := &Message{}
+        if err := msg.Unmarshal(frame); err != nil {
+            glog.Warnf("[TCP] router client decode message failed: %v", err)
+            continue
+        }
+        switch msg.Kind {
+        case KindResponse:
+            c.mu.Lock()
+            ch, ok := c.pending[msg.Seq]
+            delete(c.pending, msg.Seq)
+            c.mu.Unlock()
+            if ok {
+                ch <- msg
+            }
+        case KindPush:
+            if c.onPush != nil {
+                c.onPush(msg.Cmd, msg.Payload)
+            }
+        }
+    }
+    c.mu.Lock()
+    c.err = err
+    for seq, ch := range c.pending {
+        close(ch)
+        delete(c.pending, seq)
+    }
+    c.mu.Unlock()
+    close(c.done)
+}
+
+// write 写入消息。
+func (c *Client) write(msg *Message) error {
+    c.wmu.Lock()
+    defer c.wmu.Unlock()
+    return c.framer.WriteFrame(c.conn, msg.Marshal())
+}
+
+// Call 发送请求并等待响应，响应状态码非 0 时返回 *errors.Error。
+//
+// req 为 []byte、reply 为 *[]byte 时不经编解码器处理，reply 为 nil 时丢弃响应负载。
+func (c *Client) Call(ctx context.Context, cmd uint32, req, reply any) error {
+    payload, err := encode(c.codec, req)
+    if err != nil {
+        return err
+    }
+    seq := c.seq.Add(1)
+    ch := make(chan *Message, 1)
+    c.mu.Lock()
+    select {
+    case <-c.done:
+        c.mu.Unlock()
+        return ErrClientClosed.WithCause(c.err)
+    default:
+    }
+    c.pending[seq] = ch
+    c.mu.Unlock()
+    if err := c.write(&Message{Kind: KindRequest, Cmd: cmd, Seq: seq, Payload: payload}); err != nil {
+        c.forget(seq)
+        return err
+    }
+    var msg *Message
+    select {
+    case <-ctx.Done():
+        c.forget(seq)
+        return ctx.Err()
+    case msg = <-ch:
+    }
+    if msg == nil {
+        return ErrClientClosed.WithCause(c.err)
+    }
+    if msg.Code != 0 {
+        se := &errors.Error{}
+        if err := json.Unmarshal(msg.Payload, se); err != nil || se.Code == 0 {
+            se = errors.New(int(msg.Code), errors.UnknownReason, string(msg.Payload))
+        }
+        return se
+    }
+    switch v := reply.(type) {
+    case nil:
+        return nil
+    case *[]byte:
+        *v = msg.Payload
+        return nil
+    }
+    return c.codec.Unmarshal(msg.Payload, reply)
+}
+
+// forget 移除等待中的请求。
+func (c *Client) forget(seq uint32) {
+    c.mu.Lock()
+    delete(c.pending, seq)
+    c.mu.Unlock()
+}
+
+// Push 向服务端发送单向消息。
+func (c *Client) Push(cmd uint32, v any) error {
+    payload, err := encode(c.codec, v)
+    if err != nil {
+        return err
+    }
+    return c.write(&Message{Kind: KindPush, Cmd: cmd, Payload: payload})
+}
+
+// Close 关闭客户端连接。
+func (c *Client) Close() error {
+    err := c.conn.Close()
+    <-c.done
+    return err
+}
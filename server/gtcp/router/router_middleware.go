@@ -0,0 +1,85 @@
+package router
+
+import (
+    "context"
+    "fmt"
+    "time"
+
+    "github.com/camry/g/v2/glog"
+
+    "github.com/camry/dove/v2"
+    "github.com/camry/dove/v2/errors"
+)
+
+// Recovery 返回恢复处理函数 panic 的中间件，panic 时响应 500 错误。
+func Recovery() Middleware {
+    return func(next HandlerFunc) HandlerFunc {
+        return func(ctx context.Context, req *Request) (resp any, err error) {
+            defer func() {
+                if rec := recover(); rec != nil {
+                    glog.Errorf("[TCP] router handle %d from %s panic: %v", req.Message.Cmd, req.Conn.RemoteAddr(), rec)
+                    err = errors.InternalServer("PANIC", fmt.Sprint(rec))
+                }
+            }()
+            return next(ctx, req)
+        }
+    }
+}
+
+// Logging 返回记录每条消息处理结果的中间件，默认使用 glog 全局日志记录器。
+func Logging(logger ...glog.Logger) Middleware {
+    l := glog.GetLogger()
+    if len(logger) > 0 {
+        l = logger[0]
+    }
+    return func(next HandlerFunc) HandlerFunc {
+        return func(ctx context.Context, req *Request) (any, error) {
+            start := time.Now()
+            resp, err := next(ctx, req)
+            level := glog.LevelInfo
+            kvs := []any{
+                "transport", "tcp",
+                "cmd", req.Message.Cmd,
+                "seq", req.Message.Seq,
+                "code", errors.Code(err),
+                "latency", time.Since(start).Seconds(),
+                "peer", req.Conn.RemoteAddr().String(),
+            }
+            if app, ok := dove.FromContext(ctx); ok {
+                kvs = append(kvs, "app_name", app.Name(), "app_id", app.ID())
+            }
+            if err != nil {
+                level = glog.LevelWarn
+                kvs = append(kvs, "error", err.Error())
+            }
+            _ = l.Log(level, kvs...)
+            return resp, err
+        }
+    }
+}
+
+// AuthFunc 定义认证函数，返回携带认证信息的上下文。
+type AuthFunc func(ctx context.Context, req *Request) (context.Context, error)
+
+// Auth 返回认证中间件，public 中的命令无需认证，认证失败时响应 401 错误。
+func Auth(fn AuthFunc, public ...uint32) Middleware {
+    skip := make(map[uint32]struct{}, len(public))
+    for _, cmd := range public {
+        skip[cmd] = struct{}{}
+    }
+    return func(next HandlerFunc) HandlerFunc {
+        return func(ctx context.Context, req *Request) (any, error) {
+            if _, ok := skip[req.Message.Cmd]; ok {
+                return next(ctx, req)
+            }
+            authCtx, err := fn(ctx, req)
+            if err != nil {
+                if se := errors.FromError(err); se.Code != errors.UnknownCode {
+                    return nil, se
+                }
+                return nil, errors.Unauthorized("UNAUTHORIZED", err.Error())
+            }
+            return next(authCtx, req)
+        }
+    }
+}
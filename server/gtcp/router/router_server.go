@@ -0,0 +1,189 @@
+package router
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "sync"
+
+    "github.com/camry/g/v2/glog"
+
+    "github.com/camry/dove/v2/errors"
+    "github.com/camry/dove/v2/server/gtcp"
+)
+
+// ErrUnknownCommand 未注册的命令。
+var ErrUnknownCommand = errors.NotFound("UNKNOWN_COMMAND", "unknown command")
+
+// Request 路由请求。
+type Request struct {
+    Conn    *gtcp.FrameConn
+    Message *Message
+    router  *Router
+}
+
+// Bind 使用路由编解码器解码请求负载。
+func (r *Request) Bind(v any) error {
+    return r.router.codec.Unmarshal(r.Message.Payload, v)
+}
+
+// Push 向请求所在连接推送消息。
+func (r *Request) Push(cmd uint32, v any) error {
+    return r.router.Push(r.Conn, cmd, v)
+}
+
+// HandlerFunc 定义消息处理函数，返回值经编解码器编码后作为响应负载，[]byte 原样发送。
+type HandlerFunc func(ctx context.Context, req *Request) (any, error)
+
+// Middleware 定义路由中间件类型。
+type Middleware func(HandlerFunc) HandlerFunc
+
+// Option 定义一个路由选项类型。
+type Option func(r *Router)
+
+// WithCodec 配置负载编解码器，默认 JSON。
+func WithCodec(c Codec) Option {
+    return func(r *Router) { r.codec = c }
+}
+
+// NotFound 配置未注册命令的处理函数，默认返回 ErrUnknownCommand。
+func NotFound(h HandlerFunc) Option {
+    return func(r *Router) { r.notFound = h }
+}
+
+// Router 按命令 ID 分发消息的路由器。
+type Router struct {
+    mu          sync.RWMutex
+    codec       Codec
+    handlers    map[uint32]HandlerFunc
+    routes      map[uint32]HandlerFunc // 已包装中间件的处理函数。
+    middlewares []Middleware
+    notFound    HandlerFunc
+    fallback    HandlerFunc // 已包装中间件的 notFound。
+}
+
+// New 新建路由器。
+func New(opts ...Option) *Router {
+    r := &Router{
+        codec:    JSON,
+        handlers: make(map[uint32]HandlerFunc),
+        routes:   make(map[uint32]HandlerFunc),
+        notFound: func(ctx context.Context, req *Request) (any, error) {
+            return nil, ErrUnknownCommand
+        },
+    }
+    for _, o := range opts {
+        o(r)
+    }
+    r.fallback = r.notFound
+    return r
+}
+
+// Use 追加中间件，按添加顺序由外向内执行，作用于全部处理函数。
+func (r *Router) Use(m ...Middleware) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    r.middlewares = append(r.middlewares, m...)
+    for cmd, h := range r.handlers {
+        r.routes[cmd] = r.chain(h)
+    }
+    r.fallback = r.chain(r.notFound)
+}
+
+// Handle 注册命令处理函数。
+func (r *Router) Handle(cmd uint32, h HandlerFunc) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    r.handlers[cmd] = h
+    r.routes[cmd] = r.chain(h)
+}
+
+// chain 为处理函数包装中间件，调用方需持有锁。
+func (r *Router) chain(h HandlerFunc) HandlerFunc {
+    for i := len(r.middlewares) - 1; i >= 0; i-- {
+        h = r.middlewares[i](h)
+    }
+    return h
+}
+
+// Register 注册强类型命令处理函数，请求负载解码为 Req，响应 *Resp 经编解码器编码。
+func Register[Req, Resp any](r *Router, cmd uint32, h func(ctx context.Context, req *Req) (*Resp, error)) {
+    r.Handle(cmd, func(ctx context.Context, req *Request) (any, error) {
+        in := new(Req)
+        if err := req.Bind(in); err != nil {
+            return nil, errors.BadRequest("INVALID_PAYLOAD", err.Error())
+        }
+        out, err := h(ctx, in)
+        if err != nil || out == nil {
+            return nil, err
+        }
+        return out, nil
+    })
+}
+
+// Handler 返回将路由器挂载为 gtcp 帧处理器的服务选项。
+func Handler(r *Router) gtcp.ServerOption {
+    return gtcp.FrameHandler(r.ServeFrame)
+}
+
+// ServeFrame 处理一个帧，签名与 gtcp.FrameHandler 一致。
+//
+// 请求消息的响应携带相同的命令与序列号，处理函数返回错误时响应状态码为 errors.Code(err)。
+func (r *Router) ServeFrame(ctx context.Context, conn *gtcp.FrameConn, frame []byte) {
+    msg := &Message{}
+    if err := msg.Unmarshal(frame); err != nil {
+        glog.Warnf("[TCP] router decode message from %s failed: %v", conn.RemoteAddr(), err)
+        return
+    }
+    if msg.Kind != KindRequest && msg.Kind != KindPush {
+        glog.Warnf("[TCP] router ignore message kind %d from %s", msg.Kind, conn.RemoteAddr())
+        return
+    }
+    r.mu.RLock()
+    h, ok := r.routes[msg.Cmd]
+    if !ok {
+        h = r.fallback
+    }
+    r.mu.RUnlock()
+    resp, err := h(ctx, &Request{Conn: conn, Message: msg, router: r})
+    if msg.Kind == KindPush {
+        if err != nil {
+            glog.Warnf("[TCP] router handle push %d from %s failed: %v", msg.Cmd, conn.RemoteAddr(), err)
+        }
+        return
+    }
+    reply := &Message{Kind: KindResponse, Cmd: msg.Cmd, Seq: msg.Seq}
+    if err == nil {
+        reply.Payload, err = encode(r.codec, resp)
+    }
+    if err != nil {
+        reply.Code, reply.Payload = errorPayload(err)
+    }
+    if err := conn.WriteFrame(reply.Marshal()); err != nil {
+        glog.Warnf("[TCP] router reply %d to %s failed: %v", msg.Cmd, conn.RemoteAddr(), err)
+    }
+}
+
+// Push 向连接推送单向消息。
+func (r *Router) Push(conn *gtcp.FrameConn, cmd uint32, v any) error {
+    payload, err := encode(r.codec, v)
+    if err != nil {
+        return err
+    }
+    return conn.WriteFrame((&Message{Kind: KindPush, Cmd: cmd, Payload: payload}).Marshal())
+}
+
+// errorPayload 将错误编码为响应状态码与 JSON 负载。
+func errorPayload(err error) (uint16, []byte) {
+    se := errors.FromError(err)
+    code := se.Code
+    if code <= 0 || code > 0xffff {
+        code = http.StatusInternalServerError
+    }
+    data, mErr := json.Marshal(se)
+    if mErr != nil {
+        data = []byte(fmt.Sprintf(`{"code":%d}`, code))
+    }
+    return uint16(code), data
+}
@@ -0,0 +1,130 @@
+package router
+
+import (
+    "context"
+    stderrors "errors"
+    "fmt"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/camry/dove/v2/errors"
+    "github.com/camry/dove/v2/server/gtcp"
+)
+
+const (
+    cmdLogin uint32 = iota + 1
+    cmdEcho
+    cmdPanic
+    cmdNotify
+)
+
+type echoReq struct {
+    Text string `json:"text"`
+}
+
+type echoResp struct {
+    Text string `json:"text"`
+    User string `json:"user"`
+}
+
+type userKey struct{}
+
+func TestRouter(t *testing.T) {
+    r := New()
+    r.Use(Recovery(), Logging(), Auth(func(ctx context.Context, req *Request) (context.Context, error) {
+        return context.WithValue(ctx, userKey{}, "u1"), nil
+    }, cmdLogin))
+    r.Handle(cmdLogin, func(ctx context.Context, req *Request) (any, error) {
+        return nil, errors.Unauthorized("BAD_TOKEN", "bad token")
+    })
+    Register(r, cmdEcho, func(ctx context.Context, req *echoReq) (*echoResp, error) {
+        return &echoResp{Text: req.Text, User: ctx.Value(userKey{}).(string)}, nil
+    })
+    r.Handle(cmdPanic, func(ctx context.Context, req *Request) (any, error) {
+        panic("boom")
+    })
+    r.Handle(cmdNotify, func(ctx context.Context, req *Request) (any, error) {
+        return nil, req.Push(cmdNotify, req.Message.Payload)
+    })
+    s := gtcp.NewServer(gtcp.Address("127.0.0.1:0"), Handler(r))
+    if err := s.Listen(context.Background()); err != nil {
+        t.Fatal(err)
+    }
+    go func() { _ = s.Start(context.Background()) }()
+    defer s.Stop(context.Background())
+
+    pushed := make(chan string, 1)
+    c, err := Dial(context.Background(), s.ListenAddr().String(), OnPush(func(cmd uint32, payload []byte) {
+        pushed <- string(payload)
+    }))
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer c.Close()
+    ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+    defer cancel()
+
+    var wg sync.WaitGroup
+    for i := range 20 {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            var resp echoResp
+            if err := c.Call(ctx, cmdEcho, &echoReq{Text: fmt.Sprint(i)}, &resp); err != nil {
+                t.Error(err)
+                return
+            }
+            if resp.Text != fmt.Sprint(i) || resp.User != "u1" {
+                t.Errorf("unexpected response %+v for %d", resp, i)
+            }
+        }()
+    }
+    wg.Wait()
+
+    if err := c.Call(ctx, cmdLogin, nil, nil); !errors.IsUnauthorized(err) || errors.Reason(err) != "BAD_TOKEN" {
+        t.Fatalf("expect BAD_TOKEN, got %v", err)
+    }
+    if err := c.Call(ctx, cmdPanic, nil, nil); !errors.IsInternalServer(err) {
+        t.Fatalf("expect internal server error, got %v", err)
+    }
+    if err := c.Call(ctx, 99, nil, nil); !stderrors.Is(err, ErrUnknownCommand) {
+        t.Fatalf("expect ErrUnknownCommand, got %v", err)
+    }
+    if err := c.Push(cmdNotify, []byte("hi")); err != nil {
+        t.Fatal(err)
+    }
+    select {
+    case got := <-pushed:
+        if got != "hi" {
+            t.Fatalf("expect hi, got %q", got)
+        }
+    case <-ctx.Done():
+        t.Fatal("expect server push")
+    }
+}
+
+func TestRouter_Chain(t *testing.T) {
+    var wraps, calls, applied int
+    r := New()
+    r.Handle(cmdNotify, func(ctx context.Context, req *Request) (any, error) {
+        calls++
+        return nil, nil
+    })
+    // 后追加的中间件同样作用于已注册的处理函数，中间件仅在注册时包装一次。
+    r.Use(func(next HandlerFunc) HandlerFunc {
+        wraps++
+        return func(ctx context.Context, req *Request) (any, error) {
+            applied++
+            return next(ctx, req)
+        }
+    })
+    wraps = 0
+    frame := (&Message{Kind: KindPush, Cmd: cmdNotify}).Marshal()
+    for range 3 {
+        r.ServeFrame(context.Background(), nil, frame)
+    }
+    if calls != 3 || applied != 3 || wraps != 0 {
+        t.Fatalf("expect 3 calls without rewrapping, got calls %d applied %d wraps %d", calls, applied, wraps)
+    }
+}
@@ -0,0 +1,120 @@
+package gudp
+
+import (
+    "fmt"
+    "net"
+
+    "golang.org/x/net/ipv4"
+    "golang.org/x/net/ipv6"
+)
+
+// JoinGroup 配置加入的组播组地址，可多次调用累加。
+//
+// 监听地址通常为 ":端口" 或 "组播地址:端口"，IPv4 组播建议配合 Network("udp4") 使用。
+func JoinGroup(groups ...string) ServerOption {
+    return func(s *Server) { s.groups = append(s.groups, groups...) }
+}
+
+// Interfaces 配置组播网卡名称，组播组在每个网卡上分别加入，首个网卡同时作为组播发送网卡；
+// 未配置时由系统选择默认网卡。
+func Interfaces(names ...string) ServerOption {
+    return func(s *Server) { s.interfaces = append(s.interfaces, names...) }
+}
+
+// MulticastTTL 配置发送组播数据的 TTL（IPv6 为跳数限制），0 表示使用系统默认值 1。
+func MulticastTTL(ttl int) ServerOption {
+    return func(s *Server) { s.ttl = ttl }
+}
+
+// MulticastLoopback 配置发送的组播数据是否回环到本机。
+func MulticastLoopback(on bool) ServerOption {
+    return func(s *Server) { s.loopback = &on }
+}
+
+// setup 配置套接字缓冲区与组播选项。
+func (s *Server) setup(conn *net.UDPConn) error {
+    if s.readBuffer > 0 {
+        if err := conn.SetReadBuffer(s.readBuffer); err != nil {
+            return err
+        }
+    }
+    if s.writeBuffer > 0 {
+        if err := conn.SetWriteBuffer(s.writeBuffer); err != nil {
+            return err
+        }
+    }
+    ifis := make([]*net.Interface, 0, len(s.interfaces))
+    for _, name := range s.interfaces {
+        ifi, err := net.InterfaceByName(name)
+        if err != nil {
+            return err
+        }
+        ifis = append(ifis, ifi)
+    }
+    // 未配置网卡时由系统选择。
+    targets := ifis
+    if len(targets) == 0 {
+        targets = []*net.Interface{nil}
+    }
+    var v4, v6 bool
+    if ip := conn.LocalAddr().(*net.UDPAddr).IP; ip.To4() != nil {
+        v4 = true
+    } else {
+        v6 = true
+    }
+    p4, p6 := ipv4.NewPacketConn(conn), ipv6.NewPacketConn(conn)
+    for _, group := range s.groups {
+        ip := net.ParseIP(group)
+        if ip == nil || !ip.IsMulticast() {
+            return fmt.Errorf("invalid multicast group %q", group)
+        }
+        addr := &net.UDPAddr{IP: ip}
+        for _, ifi := range targets {
+            var err error
+            if ip.To4() != nil {
+                v4 = true
+                err = p4.JoinGroup(ifi, addr)
+            } else {
+                err = p6.JoinGroup(ifi, addr)
+            }
+            if err != nil {
+                return fmt.Errorf("join multicast group %s failed: %w", group, err)
+            }
+        }
+    }
+    if v4 {
+        if len(ifis) > 0 {
+            if err := p4.SetMulticastInterface(ifis[0]); err != nil {
+                return err
+            }
+        }
+        if s.ttl > 0 {
+            if err := p4.SetMulticastTTL(s.ttl); err != nil {
+                return err
+            }
+        }
+        if s.loopback != nil {
+            if err := p4.SetMulticastLoopback(*s.loopback); err != nil {
+                return err
+            }
+        }
+    }
+    if v6 {
+        if len(ifis) > 0 {
+            if err := p6.SetMulticastInterface(ifis[0]); err != nil {
+                return err
+            }
+        }
+        if s.ttl > 0 {
+            if err := p6.SetMulticastHopLimit(s.ttl); err != nil {
+                return err
+            }
+        }
+        if s.loopback != nil {
+            if err := p6.SetMulticastLoopback(*s.loopback); err != nil {
+                return err
+            }
+        }
+    }
+    return nil
+}
@@ -0,0 +1,113 @@
+package gudp
+
+import (
+    "context"
+    "errors"
+    "net"
+    "sync"
+    "time"
+
+    "github.com/camry/g/v2/glog"
+    "github.com/camry/g/v2/gnet/gudp"
+)
+
+// PacketHandler 配置按数据包处理的处理器。
+//
+// 服务器持续读取数据包并放入有界队列，由 Workers 个协程并发调用处理器，队列已满时丢弃数据包并计入 Overflowed；
+// pkt 来自缓冲池，仅在处理器返回前有效，需要保留时应复制；reply 向数据包来源地址回复。
+// 上下文派生自 App 上下文，Stop 时取消，队列中尚未处理的数据包将被丢弃。
+func PacketHandler(handler func(ctx context.Context, pkt []byte, addr net.Addr, reply func([]byte) error)) ServerOption {
+    return func(s *Server) { s.packetHandler = handler }
+}
+
+// Workers 配置 PacketHandler 的并发协程数，默认 GOMAXPROCS。
+func Workers(n int) ServerOption {
+    return func(s *Server) { s.workers = n }
+}
+
+// QueueSize 配置 PacketHandler 的待处理队列长度，默认 1024。
+func QueueSize(n int) ServerOption {
+    return func(s *Server) { s.queueSize = n }
+}
+
+// MaxPacketSize 配置最大数据包字节数，超过时丢弃并计入 Dropped，默认 65507。
+func MaxPacketSize(n int) ServerOption {
+    return func(s *Server) { s.maxPacketSize = n }
+}
+
+// packet 待处理的数据包。
+type packet struct {
+    buf  *[]byte
+    n    int
+    addr *net.UDPAddr
+}
+
+// servePackets 读取数据包并分发给工作协程，上下文结束后等待工作协程退出。
+func (s *Server) servePackets(ctx context.Context, conn *gudp.ServerConn) {
+    queue := make(chan packet, s.queueSize)
+    var wg sync.WaitGroup
+    for range s.workers {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for p := range queue {
+                s.handlePacket(ctx, conn, p)
+            }
+        }()
+    }
+    defer func() {
+        close(queue)
+        wg.Wait()
+    }()
+    var delay time.Duration
+    for {
+        buf := s.buffers.Get().(*[]byte)
+        n, addr, err := conn.ReadFromUDP(*buf)
+        if err != nil {
+            s.buffers.Put(buf)
+            if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+                return
+            }
+            // 与 TCP 接受循环相同的退避策略，避免持续出错时空转。
+            delay = min(max(delay*2, 5*time.Millisecond), time.Second)
+            glog.Warnf("[UDP] server read packet failed: %v; retrying in %v", err, delay)
+            time.Sleep(delay)
+            continue
+        }
+        delay = 0
+        s.received.Add(1)
+        // 缓冲区比最大长度多 1 字节，读满说明数据包被截断。
+        if n > s.maxPacketSize {
+            s.dropped.Add(1)
+            s.buffers.Put(buf)
+            continue
+        }
+        select {
+        case queue <- packet{buf: buf, n: n, addr: addr}:
+        default:
+            s.overflowed.Add(1)
+            s.buffers.Put(buf)
+        }
+    }
+}
+
+// handlePacket 调用处理器并归还缓冲区，上下文结束后丢弃数据包。
+func (s *Server) handlePacket(ctx context.Context, conn *gudp.ServerConn, p packet) {
+    defer s.buffers.Put(p.buf)
+    if ctx.Err() != nil {
+        s.dropped.Add(1)
+        return
+    }
+    defer func() {
+        if r := recover(); r != nil {
+            // 处理器 panic 的数据包计入丢弃数。
+            s.dropped.Add(1)
+            glog.Errorf("[UDP] server handle packet from %s panic: %v", p.addr, r)
+        }
+    }()
+    s.packetHandler(ctx, (*p.buf)[:p.n], p.addr, func(b []byte) error {
+        _, err := conn.WriteToUDP(b, p.addr)
+        return err
+    })
+    s.processed.Add(1)
+}
@@ -2,6 +2,13 @@ package gudp
 
 import (
     "context"
+    "errors"
+    "fmt"
+    "net"
+    "runtime"
+    "sync"
+    "sync/atomic"
+    "time"
 
     "github.com/camry/g/v2/glog"
     "github.com/camry/g/v2/gnet/gudp"
@@ -9,7 +16,10 @@ import (
     "github.com/camry/dove/v2/server"
 )
 
-var _ server.Server = (*Server)(nil)
+var (
+    _ server.Server   = (*Server)(nil)
+    _ server.Listener = (*Server)(nil)
+)
 
 // ServerOption 定义一个 UDP 服务选项类型。
 type ServerOption func(s *Server)
@@ -19,40 +29,284 @@ func Address(address string) ServerOption {
     return func(s *Server) { s.address = address }
 }
 
+// Network 配置监听网络，可选 udp、udp4、udp6，默认 udp。
+func Network(network string) ServerOption {
+    return func(s *Server) { s.network = network }
+}
+
+// ReadBuffer 配置套接字接收缓冲区大小（SO_RCVBUF），0 表示使用系统默认值。
+func ReadBuffer(bytes int) ServerOption {
+    return func(s *Server) { s.readBuffer = bytes }
+}
+
+// WriteBuffer 配置套接字发送缓冲区大小（SO_SNDBUF），0 表示使用系统默认值。
+func WriteBuffer(bytes int) ServerOption {
+    return func(s *Server) { s.writeBuffer = bytes }
+}
+
+// ReusePort 配置 SO_REUSEADDR 与 SO_REUSEPORT，允许多个进程或服务器共享同一端口。
+func ReusePort(on bool) ServerOption {
+    return func(s *Server) { s.reusePort = on }
+}
+
 // Handler 配置处理器。
 func Handler(handler func(conn *gudp.ServerConn)) ServerOption {
+    return func(s *Server) { s.setHandler(handler) }
+}
+
+// ContextHandler 配置带上下文的处理器。
+//
+// 上下文派生自 App 上下文，Stop 时取消。
+func ContextHandler(handler func(ctx context.Context, conn *gudp.ServerConn)) ServerOption {
     return func(s *Server) { s.handler = handler }
 }
 
 // Server 定义 UDP 服务器。
 type Server struct {
-    *gudp.Server
+    mu          sync.Mutex
+    network     string                                           // UDP 服务器监听网络。
+    address     string                                           // UDP 服务器监听地址。
+    handler     func(ctx context.Context, conn *gudp.ServerConn) // UDP 连接的处理程序。
+    readBuffer  int                                              // 套接字接收缓冲区大小。
+    writeBuffer int                                              // 套接字发送缓冲区大小。
+    reusePort   bool                                             // 是否共享端口。
+    groups      []string                                         // 加入的组播组。
+    interfaces  []string                                         // 组播网卡名称。
+    ttl         int                                              // 组播 TTL。
+    loopback    *bool                                            // 是否回环组播数据。
 
-    address string                      // UDP 服务器监听地址。
-    handler func(conn *gudp.ServerConn) // UDP 连接的处理程序。
+    packetHandler func(ctx context.Context, pkt []byte, addr net.Addr, reply func([]byte) error) // 数据包处理器。
+    workers       int                                                                         // 数据包处理协程数。
+    queueSize     int                                                                         // 数据包队列长度。
+    maxPacketSize int                                                                         // 最大数据包字节数。
+    buffers       sync.Pool
+
+    conn    *gudp.ServerConn
+    closed  bool
+    cancel  context.CancelFunc
+    active  atomic.Int64
+    handles sync.WaitGroup
+    drained atomic.Int64
+    killed  atomic.Int64
+
+    received   atomic.Int64
+    processed  atomic.Int64
+    dropped    atomic.Int64
+    overflowed atomic.Int64
 }
 
 // NewServer 新建 UDP 服务器。
 func NewServer(opts ...ServerOption) *Server {
     srv := &Server{
+        network: "udp",
         address: ":0",
-        handler: func(conn *gudp.ServerConn) {},
+        handler: func(ctx context.Context, conn *gudp.ServerConn) {},
     }
     for _, opt := range opts {
         opt(srv)
     }
-    srv.Server = gudp.NewServer(srv.address, srv.handler)
+    if srv.packetHandler != nil {
+        if srv.workers <= 0 {
+            srv.workers = runtime.GOMAXPROCS(0)
+        }
+        if srv.queueSize <= 0 {
+            srv.queueSize = 1024
+        }
+        if srv.maxPacketSize <= 0 {
+            srv.maxPacketSize = 65507
+        }
+        srv.buffers.New = func() any {
+            buf := make([]byte, srv.maxPacketSize+1)
+            return &buf
+        }
+        srv.handler = srv.servePackets
+    }
     return srv
 }
 
-// Start 启动 UDP 服务器。
+// Listen 绑定 UDP 服务监听地址。
+func (s *Server) Listen(ctx context.Context) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if s.conn != nil {
+        return nil
+    }
+    lc := net.ListenConfig{}
+    if s.reusePort {
+        lc.Control = reusePort
+    }
+    pc, err := lc.ListenPacket(ctx, s.network, s.address)
+    if err != nil {
+        return fmt.Errorf("[UDP] server listen on %s failed: %w", s.address, err)
+    }
+    conn := pc.(*net.UDPConn)
+    if err := s.setup(conn); err != nil {
+        _ = conn.Close()
+        return fmt.Errorf("[UDP] server listen on %s failed: %w", s.address, err)
+    }
+    s.conn = gudp.NewServerConn(conn)
+    return nil
+}
+
+// Start 启动 UDP 服务器，处理器返回后结束。
 func (s *Server) Start(ctx context.Context) error {
-    glog.Infof("[UDP] server listening on %s", s.GetListenedAddress())
-    return s.Run(ctx)
+    if err := s.Listen(ctx); err != nil {
+        return err
+    }
+    s.mu.Lock()
+    if s.closed {
+        s.mu.Unlock()
+        // 启动前已停止时释放监听连接。
+        if err := s.conn.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+            return err
+        }
+        return nil
+    }
+    ctx, s.cancel = context.WithCancel(ctx)
+    conn := s.conn
+    s.handles.Add(1)
+    s.mu.Unlock()
+    defer s.handles.Done()
+    glog.Infof("[UDP] server listening on %s", conn.LocalAddr().String())
+    // 上下文结束后中断阻塞中的读取，写入不受影响。
+    stop := context.AfterFunc(ctx, func() {
+        _ = conn.SetReadDeadline(time.Now())
+    })
+    defer stop()
+    s.active.Add(1)
+    defer s.active.Add(-1)
+    defer func() {
+        if r := recover(); r != nil {
+            glog.Errorf("[UDP] server handler panic: %v", r)
+        }
+    }()
+    s.handler(ctx, conn)
+    return nil
 }
 
 // Stop 停止 UDP 服务器。
+//
+// 取消处理器上下文，阻塞中的读取将立即返回超时错误；随后等待处理器返回，
+// 超过停止超时仍未返回时强制关闭监听连接，处理器此后的读写返回 net.ErrClosed，Stop 不再等待其返回。
 func (s *Server) Stop(ctx context.Context) error {
     glog.Info("[UDP] server stopping")
-    return s.Close(ctx)
+    s.mu.Lock()
+    s.closed = true
+    if s.cancel != nil {
+        s.cancel()
+    }
+    conn := s.conn
+    s.mu.Unlock()
+    if conn == nil {
+        return nil
+    }
+    active := s.active.Load()
+    done := make(chan struct{})
+    go func() {
+        s.handles.Wait()
+        close(done)
+    }()
+    var (
+        killed int64
+        err    error
+    )
+    select {
+    case <-done:
+        err = conn.Close()
+    case <-ctx.Done():
+        // 超时仍在运行的处理器视为被强制结束，关闭监听连接使其读写立即失败。
+        // 关闭后处理器可能立即返回，需在关闭前统计。
+        killed = s.active.Load()
+        err = conn.Close()
+    }
+    s.drained.Store(active - killed)
+    s.killed.Store(killed)
+    if active > 0 {
+        glog.Infof("[UDP] server stopped: %d handlers drained, %d killed", active-killed, killed)
+    }
+    if err != nil && !errors.Is(err, net.ErrClosed) {
+        return err
+    }
+    return nil
+}
+
+// SetAddress 设置服务监听地址，需在 Listen 前调用。
+func (s *Server) SetAddress(address string) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.address = address
+}
+
+// SetHandler 设置处理器，需在 Start 前调用。
+func (s *Server) SetHandler(handler func(conn *gudp.ServerConn)) {
+    s.setHandler(handler)
+}
+
+// setHandler 设置不带上下文的处理器。
+func (s *Server) setHandler(handler func(conn *gudp.ServerConn)) {
+    s.handler = func(_ context.Context, conn *gudp.ServerConn) { handler(conn) }
+}
+
+// Run 启动 UDP 服务器，同 Start。
+func (s *Server) Run(ctx context.Context) error {
+    return s.Start(ctx)
+}
+
+// Close 停止 UDP 服务器，同 Stop。
+func (s *Server) Close(ctx context.Context) error {
+    return s.Stop(ctx)
+}
+
+// Stats UDP 服务器处理器统计。
+type Stats struct {
+    Active  int64 // 当前运行中的处理器数。
+    Drained int64 // 停止时正常返回的处理器数。
+    Killed  int64 // 停止超时时仍在运行、监听连接被强制关闭的处理器数。
+
+    Received   int64 // PacketHandler 累计读取的数据包数。
+    Processed  int64 // PacketHandler 累计处理的数据包数。
+    Dropped    int64 // 超过最大长度、处理器 panic 或停止时未处理而丢弃的数据包数。
+    Overflowed int64 // 队列已满而丢弃的数据包数。
+}
+
+// Stats 返回处理器统计。
+func (s *Server) Stats() Stats {
+    return Stats{
+        Active:  s.active.Load(),
+        Drained: s.drained.Load(),
+        Killed:  s.killed.Load(),
+
+        Received:   s.received.Load(),
+        Processed:  s.processed.Load(),
+        Dropped:    s.dropped.Load(),
+        Overflowed: s.overflowed.Load(),
+    }
+}
+
+// ListenAddr 返回实际监听地址，未监听时返回 nil。
+func (s *Server) ListenAddr() net.Addr {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if s.conn == nil {
+        return nil
+    }
+    return s.conn.LocalAddr()
+}
+
+// GetListenedAddress 获取当前服务器监听地址，未监听时返回配置地址。
+func (s *Server) GetListenedAddress() string {
+    if addr := s.ListenAddr(); addr != nil {
+        return addr.String()
+    }
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return s.address
+}
+
+// GetListenedPort 获取当前服务器监听端口，未监听时返回 -1。
+func (s *Server) GetListenedPort() int {
+    if addr, ok := s.ListenAddr().(*net.UDPAddr); ok {
+        return addr.Port
+    }
+    return -1
 }
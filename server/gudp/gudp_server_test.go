@@ -0,0 +1,214 @@
+package gudp
+
+import (
+    "context"
+    "errors"
+    "net"
+    "testing"
+    "time"
+
+    "github.com/camry/g/v2/gnet/gudp"
+)
+
+func TestServer_Drain(t *testing.T) {
+    exited := make(chan struct{})
+    s := NewServer(Address("127.0.0.1:0"), ContextHandler(func(ctx context.Context, conn *gudp.ServerConn) {
+        defer close(exited)
+        for ctx.Err() == nil {
+            data, addr, err := conn.Recv(0)
+            if err != nil {
+                continue
+            }
+            _ = conn.Send(data, addr)
+        }
+    }))
+    if err := s.Listen(context.Background()); err != nil {
+        t.Fatal(err)
+    }
+    go func() { _ = s.Start(context.Background()) }()
+
+    c, err := net.Dial("udp", s.ListenAddr().String())
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer c.Close()
+    _, _ = c.Write([]byte("ping"))
+    _ = c.SetReadDeadline(time.Now().Add(time.Second))
+    buf := make([]byte, 4)
+    if _, err := c.Read(buf); err != nil || string(buf) != "ping" {
+        t.Fatalf("expect ping, got %q %v", buf, err)
+    }
+    if got := s.Stats().Active; got != 1 {
+        t.Fatalf("expect 1 active handler, got %d", got)
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+    defer cancel()
+    if err := s.Stop(ctx); err != nil {
+        t.Fatal(err)
+    }
+    select {
+    case <-exited:
+    default:
+        t.Fatal("expect handler exited")
+    }
+    if st := s.Stats(); st.Drained != 1 || st.Killed != 0 {
+        t.Fatalf("expect 1 drained, got %+v", st)
+    }
+}
+
+func TestServer_Kill(t *testing.T) {
+    exited := make(chan error, 1)
+    s := NewServer(Address("127.0.0.1:0"))
+    s.SetHandler(func(conn *gudp.ServerConn) {
+        // 忽略上下文的处理器在监听连接关闭后才退出。
+        for {
+            if _, _, err := conn.Recv(0); errors.Is(err, net.ErrClosed) {
+                exited <- err
+                return
+            }
+        }
+    })
+    if err := s.Listen(context.Background()); err != nil {
+        t.Fatal(err)
+    }
+    go func() { _ = s.Run(context.Background()) }()
+    time.Sleep(20 * time.Millisecond)
+
+    ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+    defer cancel()
+    if err := s.Close(ctx); err != nil {
+        t.Fatal(err)
+    }
+    if st := s.Stats(); st.Drained != 0 || st.Killed != 1 {
+        t.Fatalf("expect 1 killed, got %+v", st)
+    }
+    select {
+    case <-exited:
+    case <-time.After(time.Second):
+        t.Fatal("expect handler exited after connection closed")
+    }
+}
+
+func TestServer_ReusePort(t *testing.T) {
+    s1 := NewServer(Address("127.0.0.1:0"), ReusePort(true), ReadBuffer(1<<16), WriteBuffer(1<<16))
+    if err := s1.Listen(context.Background()); err != nil {
+        t.Fatal(err)
+    }
+    defer s1.Stop(context.Background())
+    s2 := NewServer(Address(s1.GetListenedAddress()), ReusePort(true))
+    if err := s2.Listen(context.Background()); err != nil {
+        t.Fatalf("expect shared port, got %v", err)
+    }
+    defer s2.Stop(context.Background())
+    s3 := NewServer(Address(s1.GetListenedAddress()))
+    if err := s3.Listen(context.Background()); err == nil {
+        _ = s3.Stop(context.Background())
+        t.Fatal("expect address in use without ReusePort")
+    }
+}
+
+func TestServer_Multicast(t *testing.T) {
+    const group = "239.255.77.1"
+    received := make(chan string, 1)
+    s := NewServer(Network("udp4"), Address("0.0.0.0:0"), JoinGroup(group), Interfaces("lo"), ContextHandler(func(ctx context.Context, conn *gudp.ServerConn) {
+        data, _, err := conn.Recv(0)
+        if err == nil {
+            received <- string(data)
+        }
+    }))
+    if err := s.Listen(context.Background()); err != nil {
+        t.Skipf("multicast unavailable: %v", err)
+    }
+    go func() { _ = s.Start(context.Background()) }()
+    defer s.Stop(context.Background())
+
+    // 发送端通过 lo 网卡发送并回环组播数据。
+    sender := NewServer(Network("udp4"), Address("127.0.0.1:0"), Interfaces("lo"), MulticastTTL(1), MulticastLoopback(true))
+    if err := sender.Listen(context.Background()); err != nil {
+        t.Fatal(err)
+    }
+    defer sender.Stop(context.Background())
+    dst := &net.UDPAddr{IP: net.ParseIP(group), Port: s.GetListenedPort()}
+    if err := sender.conn.Send([]byte("announce"), dst); err != nil {
+        t.Fatal(err)
+    }
+    select {
+    case got := <-received:
+        if got != "announce" {
+            t.Fatalf("expect announce, got %q", got)
+        }
+    case <-time.After(time.Second):
+        t.Fatal("expect multicast packet received")
+    }
+}
+
+func TestServer_PacketHandler(t *testing.T) {
+    s := NewServer(Address("127.0.0.1:0"), MaxPacketSize(8), PacketHandler(func(ctx context.Context, pkt []byte, addr net.Addr, reply func([]byte) error) {
+        if string(pkt) == "panic" {
+            panic("boom")
+        }
+        _ = reply(append([]byte("echo:"), pkt...))
+    }))
+    if err := s.Listen(context.Background()); err != nil {
+        t.Fatal(err)
+    }
+    go func() { _ = s.Start(context.Background()) }()
+    defer s.Stop(context.Background())
+    c, err := net.Dial("udp", s.ListenAddr().String())
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer c.Close()
+    // 超过最大长度的数据包被丢弃。
+    _, _ = c.Write([]byte("oversized"))
+    // 处理器 panic 的数据包计入丢弃数。
+    _, _ = c.Write([]byte("panic"))
+    _, _ = c.Write([]byte("ping"))
+    _ = c.SetReadDeadline(time.Now().Add(time.Second))
+    buf := make([]byte, 64)
+    n, err := c.Read(buf)
+    if err != nil || string(buf[:n]) != "echo:ping" {
+        t.Fatalf("expect echo:ping, got %q %v", buf[:n], err)
+    }
+    deadline := time.Now().Add(time.Second)
+    for s.Stats().Dropped != 2 && time.Now().Before(deadline) {
+        time.Sleep(10 * time.Millisecond)
+    }
+    if st := s.Stats(); st.Received != 3 || st.Dropped != 2 || st.Processed != 1 {
+        t.Fatalf("expect 3 received, 2 dropped and 1 processed, got %+v", st)
+    }
+}
+
+func TestServer_PacketOverflow(t *testing.T) {
+    started := make(chan struct{}, 1)
+    s := NewServer(Address("127.0.0.1:0"), Workers(1), QueueSize(1), PacketHandler(func(ctx context.Context, pkt []byte, addr net.Addr, reply func([]byte) error) {
+        started <- struct{}{}
+        <-ctx.Done()
+    }))
+    if err := s.Listen(context.Background()); err != nil {
+        t.Fatal(err)
+    }
+    go func() { _ = s.Start(context.Background()) }()
+    c, err := net.Dial("udp", s.ListenAddr().String())
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer c.Close()
+    _, _ = c.Write([]byte("1"))
+    <-started
+    // 工作协程阻塞，队列容纳一个数据包，其余溢出。
+    for range 3 {
+        _, _ = c.Write([]byte("x"))
+    }
+    deadline := time.Now().Add(time.Second)
+    for s.Stats().Received != 4 && time.Now().Before(deadline) {
+        time.Sleep(10 * time.Millisecond)
+    }
+    ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+    defer cancel()
+    _ = s.Stop(ctx)
+    if st := s.Stats(); st.Overflowed != 2 || st.Dropped != 1 || st.Processed != 1 || st.Killed != 0 {
+        t.Fatalf("expect 2 overflowed and 1 dropped, got %+v", st)
+    }
+}
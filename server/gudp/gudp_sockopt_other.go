@@ -0,0 +1,13 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package gudp
+
+import (
+    "errors"
+    "syscall"
+)
+
+// reusePort 当前平台不支持 SO_REUSEPORT。
+func reusePort(network, address string, c syscall.RawConn) error {
+    return errors.New("SO_REUSEPORT is not supported on this platform")
+}
@@ -0,0 +1,23 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package gudp
+
+import (
+    "syscall"
+
+    "golang.org/x/sys/unix"
+)
+
+// reusePort 在套接字绑定前设置 SO_REUSEADDR 与 SO_REUSEPORT。
+func reusePort(network, address string, c syscall.RawConn) error {
+    var err error
+    if cErr := c.Control(func(fd uintptr) {
+        if err = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEADDR, 1); err != nil {
+            return
+        }
+        err = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEPORT, 1)
+    }); cErr != nil {
+        return cErr
+    }
+    return err
+}
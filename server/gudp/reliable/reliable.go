@@ -0,0 +1,98 @@
+package reliable
+
+import (
+    "errors"
+    "time"
+)
+
+var (
+    // ErrSessionTimeout 会话超过超时时间未收到数据或报文重传次数超过上限。
+    ErrSessionTimeout = errors.New("reliable: session timeout")
+    // ErrSessionClosed 会话已被服务器关闭。
+    ErrSessionClosed = errors.New("reliable: session closed")
+)
+
+// Option 定义一个可靠 UDP 会话选项类型。
+type Option func(o *option)
+
+// option 可靠 UDP 会话选项实体对象。
+type option struct {
+    mtu        int
+    sndWnd     int
+    rcvWnd     int
+    interval   time.Duration
+    nodelay    bool
+    fastResend int
+    deadLink   int
+    timeout    time.Duration
+    linger     time.Duration
+    maxSess    int
+}
+
+// MTU 配置单个数据报的最大字节数（不含 IP 与 UDP 头），默认 1400。
+func MTU(n int) Option {
+    return func(o *option) { o.mtu = n }
+}
+
+// Window 配置发送与接收窗口大小（报文段数），默认 128。
+func Window(snd, rcv int) Option {
+    return func(o *option) { o.sndWnd, o.rcvWnd = snd, rcv }
+}
+
+// Interval 配置内部刷新间隔，影响确认与重传的时延，默认 10 毫秒。
+func Interval(d time.Duration) Option {
+    return func(o *option) { o.interval = d }
+}
+
+// NoDelay 配置低延迟模式，最小重传超时 30 毫秒且超时后重传超时按 1.5 倍退避，默认开启。
+func NoDelay(on bool) Option {
+    return func(o *option) { o.nodelay = on }
+}
+
+// FastResend 配置被后续确认跳过多少次后快速重传，0 表示关闭，默认 2。
+func FastResend(n int) Option {
+    return func(o *option) { o.fastResend = n }
+}
+
+// DeadLink 配置报文段最大发送次数，超过时关闭会话，默认 20。
+func DeadLink(n int) Option {
+    return func(o *option) { o.deadLink = n }
+}
+
+// SessionTimeout 配置会话超时时间，超过该时间未收到对端任何数据时关闭会话，默认 30 秒。
+func SessionTimeout(d time.Duration) Option {
+    return func(o *option) { o.timeout = d }
+}
+
+// Linger 配置关闭会话时等待数据确认与对端关闭的最长时间，默认 5 秒。
+func Linger(d time.Duration) Option {
+    return func(o *option) { o.linger = d }
+}
+
+// MaxSessions 配置服务端最大会话数，超出时丢弃新会话的数据报，0 表示不限制，仅服务端有效。
+func MaxSessions(n int) Option {
+    return func(o *option) { o.maxSess = n }
+}
+
+// newOption 返回应用选项后的配置。
+func newOption(opts []Option) *option {
+    o := &option{
+        mtu:        1400,
+        sndWnd:     128,
+        rcvWnd:     128,
+        interval:   10 * time.Millisecond,
+        nodelay:    true,
+        fastResend: 2,
+        deadLink:   20,
+        timeout:    30 * time.Second,
+        linger:     5 * time.Second,
+    }
+    for _, opt := range opts {
+        opt(o)
+    }
+    o.mtu = max(o.mtu, headerSize+1)
+    o.sndWnd = max(o.sndWnd, 1)
+    o.rcvWnd = max(o.rcvWnd, 1)
+    o.interval = max(o.interval, time.Millisecond)
+    return o
+}
@@ -0,0 +1,495 @@
+package reliable
+
+import (
+    "encoding/binary"
+    "errors"
+    "io"
+    "slices"
+)
+
+// 报文命令。
+const (
+    cmdPush byte = 1 // 数据。
+    cmdAck  byte = 2 // 确认。
+    cmdWask byte = 3 // 窗口探测请求。
+    cmdWins byte = 4 // 窗口大小通告。
+    cmdFin  byte = 5 // 结束，与数据一样按序可靠传输。
+)
+
+// headerSize 报文头长度：conv(4) cmd(1) wnd(2) ts(4) sn(4) una(4) len(2)。
+const headerSize = 21
+
+const (
+    askSend = 1 // 需要发送窗口探测请求。
+    askTell = 2 // 需要通告窗口大小。
+
+    rtoMin     = 30    // 最小重传超时（毫秒）。
+    rtoDefault = 200   // 初始重传超时（毫秒）。
+    rtoMax     = 60000 // 最大重传超时（毫秒）。
+    probeInit  = 7000  // 初始窗口探测间隔（毫秒）。
+    probeLimit = 120000
+)
+
+var errInvalidSegment = errors.New("reliable: invalid segment")
+
+// segment 报文段。
+type segment struct {
+    cmd  byte
+    wnd  uint16
+    ts   uint32
+    sn   uint32
+    una  uint32
+    data []byte
+
+    resendts uint32
+    rto      uint32
+    fastack  uint32
+    xmit     uint32
+}
+
+// encode 将报文段追加到 b。
+func (s *segment) encode(conv uint32, b []byte) []byte {
+    b = binary.BigEndian.AppendUint32(b, conv)
+    b = append(b, s.cmd)
+    b = binary.BigEndian.AppendUint16(b, s.wnd)
+    b = binary.BigEndian.AppendUint32(b, s.ts)
+    b = binary.BigEndian.AppendUint32(b, s.sn)
+    b = binary.BigEndian.AppendUint32(b, s.una)
+    b = binary.BigEndian.AppendUint16(b, uint16(len(s.data)))
+    return append(b, s.data...)
+}
+
+// diff 返回考虑回绕的序号或时间差。
+func diff(a, b uint32) int32 {
+    return int32(a - b)
+}
+
+// ack 待发送的确认。
+type ack struct {
+    sn uint32
+    ts uint32
+}
+
+// arq 流式自动重传控制块，参考 KCP 实现选择确认、快速重传与拥塞控制，非并发安全。
+type arq struct {
+    conv     uint32
+    mtu      int
+    mss      int
+    interval uint32
+    nodelay  bool
+    resend   uint32
+    deadLink uint32
+    output   func([]byte)
+
+    sndUna, sndNxt, rcvNxt uint32
+    sndWnd, rcvWnd, rmtWnd uint32
+    cwnd, ssthresh, incr   uint32
+
+    rxSrtt, rxRttvar int32
+    rxRto, rxMinRto  uint32
+
+    probe     int
+    tsProbe   uint32
+    probeWait uint32
+
+    sndQueue []segment
+    sndBuf   []segment
+    rcvQueue []segment
+    rcvBuf   []segment
+    acks     []ack
+    buffer   []byte
+
+    current uint32
+    dead    bool // 报文重传次数超过上限。
+    finSent bool // 已发送结束报文。
+    finRecv bool // 已按序收到对端结束报文。
+}
+
+// newARQ 新建控制块。
+func newARQ(conv uint32, o *option, output func([]byte)) *arq {
+    k := &arq{
+        conv:     conv,
+        mtu:      o.mtu,
+        mss:      o.mtu - headerSize,
+        interval: uint32(o.interval.Milliseconds()),
+        nodelay:  o.nodelay,
+        resend:   uint32(o.fastResend),
+        deadLink: uint32(o.deadLink),
+        output:   output,
+        sndWnd:   uint32(o.sndWnd),
+        rcvWnd:   uint32(o.rcvWnd),
+        rmtWnd:   uint32(o.rcvWnd),
+        cwnd:     1,
+        ssthresh: 2,
+        rxRto:    rtoDefault,
+        rxMinRto: 100,
+        buffer:   make([]byte, 0, o.mtu),
+    }
+    k.incr = uint32(k.mss)
+    if k.nodelay {
+        k.rxMinRto = rtoMin
+    }
+    if k.resend == 0 {
+        k.resend = ^uint32(0)
+    }
+    return k
+}
+
+// send 将数据追加到发送队列，流模式下合并到未满的最后一个报文段。
+func (k *arq) send(b []byte) {
+    for len(b) > 0 {
+        if n := len(k.sndQueue); n > 0 {
+            last := &k.sndQueue[n-1]
+            if last.cmd == cmdPush && len(last.data) < k.mss {
+                c := min(k.mss-len(last.data), len(b))
+                last.data = append(last.data, b[:c]...)
+                b = b[c:]
+                continue
+            }
+        }
+        c := min(k.mss, len(b))
+        k.sndQueue = append(k.sndQueue, segment{cmd: cmdPush, data: append(make([]byte, 0, c), b[:c]...)})
+        b = b[c:]
+    }
+}
+
+// close 在发送队列末尾追加结束报文。
+func (k *arq) close() {
+    if !k.finSent {
+        k.finSent = true
+        k.sndQueue = append(k.sndQueue, segment{cmd: cmdFin})
+    }
+}
+
+// recv 从接收队列读取有序数据，没有数据时返回 0，读取到对端结束报文时返回 io.EOF。
+func (k *arq) recv(b []byte) (int, error) {
+    full := uint32(len(k.rcvQueue)) >= k.rcvWnd
+    n := 0
+    for len(k.rcvQueue) > 0 && n < len(b) {
+        seg := &k.rcvQueue[0]
+        if seg.cmd == cmdFin {
+            if n == 0 {
+                return 0, io.EOF
+            }
+            break
+        }
+        c := copy(b[n:], seg.data)
+        n += c
+        if seg.data = seg.data[c:]; len(seg.data) == 0 {
+            k.rcvQueue = k.rcvQueue[1:]
+        }
+    }
+    k.moveRcvBuf()
+    if full && uint32(len(k.rcvQueue)) < k.rcvWnd {
+        // 接收窗口从满恢复时主动通告。
+        k.probe |= askTell
+    }
+    return n, nil
+}
+
+// readable 返回是否有可读数据或结束报文。
+func (k *arq) readable() bool {
+    return len(k.rcvQueue) > 0
+}
+
+// waitSnd 返回尚未确认的报文段数。
+func (k *arq) waitSnd() int {
+    return len(k.sndBuf) + len(k.sndQueue)
+}
+
+// moveRcvBuf 将接收缓冲中连续的报文段移入接收队列。
+func (k *arq) moveRcvBuf() {
+    i := 0
+    for ; i < len(k.rcvBuf); i++ {
+        seg := k.rcvBuf[i]
+        if seg.sn != k.rcvNxt || uint32(len(k.rcvQueue)) >= k.rcvWnd {
+            break
+        }
+        if seg.cmd == cmdFin {
+            k.finRecv = true
+        }
+        k.rcvQueue = append(k.rcvQueue, seg)
+        k.rcvNxt++
+    }
+    k.rcvBuf = k.rcvBuf[i:]
+}
+
+// input 处理收到的数据报，数据报可包含多个报文段。
+func (k *arq) input(data []byte) error {
+    prevUna := k.sndUna
+    var maxAck, latestTs uint32
+    acked := false
+    for len(data) > 0 {
+        if len(data) < headerSize {
+            return errInvalidSegment
+        }
+        if binary.BigEndian.Uint32(data) != k.conv {
+            return errInvalidSegment
+        }
+        cmd := data[4]
+        wnd := binary.BigEndian.Uint16(data[5:])
+        ts := binary.BigEndian.Uint32(data[7:])
+        sn := binary.BigEndian.Uint32(data[11:])
+        una := binary.BigEndian.Uint32(data[15:])
+        length := int(binary.BigEndian.Uint16(data[19:]))
+        data = data[headerSize:]
+        if len(data) < length || cmd < cmdPush || cmd > cmdFin {
+            return errInvalidSegment
+        }
+        k.rmtWnd = uint32(wnd)
+        k.parseUna(una)
+        k.shrinkBuf()
+        switch cmd {
+        case cmdAck:
+            if rtt := diff(k.current, ts); rtt >= 0 {
+                k.updateRTT(rtt)
+            }
+            k.parseAck(sn)
+            k.shrinkBuf()
+            if !acked || diff(sn, maxAck) > 0 {
+                acked, maxAck, latestTs = true, sn, ts
+            }
+        case cmdPush, cmdFin:
+            if diff(sn, k.rcvNxt+k.rcvWnd) < 0 {
+                k.acks = append(k.acks, ack{sn: sn, ts: ts})
+                if diff(sn, k.rcvNxt) >= 0 {
+                    k.parseData(segment{cmd: cmd, sn: sn, data: append([]byte(nil), data[:length]...)})
+                }
+            }
+        case cmdWask:
+            k.probe |= askTell
+        }
+        data = data[length:]
+    }
+    if acked {
+        k.parseFastAck(maxAck, latestTs)
+    }
+    // 收到新的确认时增大拥塞窗口。
+    if diff(k.sndUna, prevUna) > 0 && k.cwnd < k.rmtWnd {
+        mss := uint32(k.mss)
+        if k.cwnd < k.ssthresh {
+            k.cwnd++
+            k.incr += mss
+        } else {
+            k.incr = max(k.incr, mss)
+            k.incr += mss*mss/k.incr + mss/16
+            if (k.cwnd+1)*mss <= k.incr {
+                k.cwnd = (k.incr + mss - 1) / mss
+            }
+        }
+        if k.cwnd > k.rmtWnd {
+            k.cwnd = k.rmtWnd
+            k.incr = k.rmtWnd * mss
+        }
+    }
+    return nil
+}
+
+// updateRTT 按 RFC 6298 更新往返时间与重传超时。
+func (k *arq) updateRTT(rtt int32) {
+    if k.rxSrtt == 0 {
+        k.rxSrtt = rtt
+        k.rxRttvar = rtt / 2
+    } else {
+        delta := rtt - k.rxSrtt
+        if delta < 0 {
+            delta = -delta
+        }
+        k.rxRttvar = (3*k.rxRttvar + delta) / 4
+        k.rxSrtt = max((7*k.rxSrtt+rtt)/8, 1)
+    }
+    rto := uint32(k.rxSrtt) + max(k.interval, uint32(4*k.rxRttvar))
+    k.rxRto = min(max(rto, k.rxMinRto), rtoMax)
+}
+
+// shrinkBuf 更新最早未确认的序号。
+func (k *arq) shrinkBuf() {
+    if len(k.sndBuf) > 0 {
+        k.sndUna = k.sndBuf[0].sn
+    } else {
+        k.sndUna = k.sndNxt
+    }
+}
+
+// parseAck 移除被选择确认的报文段。
+func (k *arq) parseAck(sn uint32) {
+    if diff(sn, k.sndUna) < 0 || diff(sn, k.sndNxt) >= 0 {
+        return
+    }
+    for i := range k.sndBuf {
+        if k.sndBuf[i].sn == sn {
+            k.sndBuf = slices.Delete(k.sndBuf, i, i+1)
+            return
+        }
+        if diff(sn, k.sndBuf[i].sn) < 0 {
+            return
+        }
+    }
+}
+
+// parseUna 移除被累积确认的报文段。
+func (k *arq) parseUna(una uint32) {
+    i := 0
+    for i < len(k.sndBuf) && diff(una, k.sndBuf[i].sn) > 0 {
+        i++
+    }
+    k.sndBuf = k.sndBuf[i:]
+}
+
+// parseFastAck 统计被后续确认跳过的报文段，用于快速重传。
+func (k *arq) parseFastAck(sn, ts uint32) {
+    for i := range k.sndBuf {
+        seg := &k.sndBuf[i]
+        if diff(sn, seg.sn) < 0 {
+            break
+        }
+        if sn != seg.sn && diff(seg.ts, ts) <= 0 {
+            seg.fastack++
+        }
+    }
+}
+
+// parseData 将报文段按序号插入接收缓冲并丢弃重复报文段。
+func (k *arq) parseData(seg segment) {
+    if diff(seg.sn, k.rcvNxt+k.rcvWnd) >= 0 || diff(seg.sn, k.rcvNxt) < 0 {
+        return
+    }
+    i, found := slices.BinarySearchFunc(k.rcvBuf, seg.sn, func(s segment, sn uint32) int {
+        return int(diff(s.sn, sn))
+    })
+    if !found {
+        k.rcvBuf = slices.Insert(k.rcvBuf, i, seg)
+    }
+    k.moveRcvBuf()
+}
+
+// wndUnused 返回接收窗口剩余大小。
+func (k *arq) wndUnused() uint16 {
+    if n := uint32(len(k.rcvQueue)); n < k.rcvWnd {
+        return uint16(k.rcvWnd - n)
+    }
+    return 0
+}
+
+// flushAcks 立即发送待发送的确认。
+func (k *arq) flushAcks() {
+    seg := segment{cmd: cmdAck, wnd: k.wndUnused(), una: k.rcvNxt}
+    buffer := k.buffer[:0]
+    for _, a := range k.acks {
+        if len(buffer)+headerSize > k.mtu {
+            k.output(buffer)
+            buffer = buffer[:0]
+        }
+        seg.sn, seg.ts = a.sn, a.ts
+        buffer = seg.encode(k.conv, buffer)
+    }
+    k.acks = k.acks[:0]
+    if len(buffer) > 0 {
+        k.output(buffer)
+    }
+}
+
+// flush 发送确认、窗口探测、新数据与需要重传的报文段，并根据丢包调整拥塞窗口。
+func (k *arq) flush() {
+    k.flushAcks()
+    current := k.current
+    seg := segment{wnd: k.wndUnused(), una: k.rcvNxt}
+    buffer := k.buffer[:0]
+    write := func(s *segment) {
+        if len(buffer)+headerSize+len(s.data) > k.mtu {
+            k.output(buffer)
+            buffer = buffer[:0]
+        }
+        buffer = s.encode(k.conv, buffer)
+    }
+
+    // 对端接收窗口为 0 时定期探测。
+    if k.rmtWnd == 0 {
+        if k.probeWait == 0 {
+            k.probeWait = probeInit
+            k.tsProbe = current + k.probeWait
+        } else if diff(current, k.tsProbe) >= 0 {
+            k.probeWait = min(max(k.probeWait, probeInit)+k.probeWait/2, probeLimit)
+            k.tsProbe = current + k.probeWait
+            k.probe |= askSend
+        }
+    } else {
+        k.tsProbe, k.probeWait = 0, 0
+    }
+    if k.probe&askSend != 0 {
+        seg.cmd = cmdWask
+        write(&seg)
+    }
+    if k.probe&askTell != 0 {
+        seg.cmd = cmdWins
+        write(&seg)
+    }
+    k.probe = 0
+
+    cwnd := min(k.sndWnd, k.rmtWnd, k.cwnd)
+    for len(k.sndQueue) > 0 && diff(k.sndNxt, k.sndUna+cwnd) < 0 {
+        s := k.sndQueue[0]
+        k.sndQueue = k.sndQueue[1:]
+        s.sn = k.sndNxt
+        k.sndNxt++
+        k.sndBuf = append(k.sndBuf, s)
+    }
+
+    var rtoExtra uint32
+    if !k.nodelay {
+        rtoExtra = k.rxRto >> 3
+    }
+    lost, change := false, false
+    for i := range k.sndBuf {
+        s := &k.sndBuf[i]
+        send := false
+        switch {
+        case s.xmit == 0:
+            send = true
+            s.rto = k.rxRto
+            s.resendts = current + s.rto + rtoExtra
+        case diff(current, s.resendts) >= 0:
+            // 超时重传。
+            send, lost = true, true
+            if k.nodelay {
+                s.rto += k.rxRto / 2
+            } else {
+                s.rto += max(s.rto, k.rxRto)
+            }
+            s.resendts = current + s.rto
+        case s.fastack >= k.resend:
+            // 快速重传。
+            send, change = true, true
+            s.fastack = 0
+            s.resendts = current + s.rto
+        }
+        if !send {
+            continue
+        }
+        s.xmit++
+        s.ts, s.wnd, s.una = current, seg.wnd, k.rcvNxt
+        write(s)
+        if s.xmit >= k.deadLink {
+            k.dead = true
+        }
+    }
+    if len(buffer) > 0 {
+        k.output(buffer)
+    }
+
+    mss := uint32(k.mss)
+    if change {
+        k.ssthresh = max((k.sndNxt-k.sndUna)/2, 2)
+        k.cwnd = k.ssthresh + k.resend
+        k.incr = k.cwnd * mss
+    }
+    if lost {
+        k.ssthresh = max(k.cwnd/2, 2)
+        k.cwnd = 1
+        k.incr = mss
+    }
+    if k.cwnd < 1 {
+        k.cwnd = 1
+        k.incr = mss
+    }
+}
@@ -0,0 +1,55 @@
+package reliable
+
+import (
+    "context"
+    "encoding/binary"
+    "errors"
+    "math/rand/v2"
+    "net"
+    "time"
+)
+
+// Dial 连接可靠 UDP 会话服务器，会话在首次写入数据时于服务端建立。
+func Dial(ctx context.Context, address string, opts ...Option) (*Session, error) {
+    var d net.Dialer
+    nc, err := d.DialContext(ctx, "udp", address)
+    if err != nil {
+        return nil, err
+    }
+    conn := nc.(*net.UDPConn)
+    o := newOption(opts)
+    conv := rand.Uint32() | 1
+    sess := newSession(context.Background(), conv, o, conn.LocalAddr(), conn.RemoteAddr(), func(b []byte) {
+        _, _ = conn.Write(b)
+    })
+    sess.onRemove = func() { _ = conn.Close() }
+    go func() {
+        buf := make([]byte, 64<<10)
+        for {
+            n, err := conn.Read(buf)
+            if err != nil {
+                if errors.Is(err, net.ErrClosed) {
+                    return
+                }
+                // 服务端未就绪时的 ICMP 端口不可达等错误可忽略。
+                continue
+            }
+            if n >= headerSize && binary.BigEndian.Uint32(buf) == conv {
+                sess.input(buf[:n])
+            }
+        }
+    }()
+    go func() {
+        ticker := time.NewTicker(o.interval)
+        defer ticker.Stop()
+        for {
+            select {
+            case <-ticker.C:
+                sess.update()
+            case <-sess.die:
+                return
+            }
+        }
+    }()
+    return sess, nil
+}
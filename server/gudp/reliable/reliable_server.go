@@ -0,0 +1,183 @@
+package reliable
+
+import (
+    "context"
+    "encoding/binary"
+    "errors"
+    "net"
+    "net/netip"
+    "sync"
+    "sync/atomic"
+    "time"
+
+    "github.com/camry/g/v2/glog"
+    ggudp "github.com/camry/g/v2/gnet/gudp"
+
+    "github.com/camry/dove/v2/server/gudp"
+)
+
+// sessionKey 会话键，同一地址的不同会话 ID 视为不同会话。
+type sessionKey struct {
+    addr netip.AddrPort
+    conv uint32
+}
+
+// Server 定义可靠 UDP 会话服务器，在 gudp.Server 之上为每个对端维护会话。
+type Server struct {
+    handler func(ctx context.Context, sess *Session)
+    o       *option
+
+    mu       sync.Mutex
+    sessions map[sessionKey]*Session
+    handles  sync.WaitGroup
+    rejected atomic.Int64
+}
+
+// NewServer 新建可靠 UDP 会话服务器，每个新会话在独立协程中调用处理器，处理器返回后关闭会话。
+func NewServer(handler func(ctx context.Context, sess *Session), opts ...Option) *Server {
+    return &Server{
+        handler:  handler,
+        o:        newOption(opts),
+        sessions: make(map[sessionKey]*Session),
+    }
+}
+
+// Handler 将可靠 UDP 会话服务器配置为 gudp.Server 的处理器。
+//
+// App 停止时取消全部会话上下文并拒绝新会话，阻塞中的读取立即返回超时错误，
+// 处理器返回后会话发送结束报文，等待会话关闭握手完成后返回，超过停止超时仍未结束时由 gudp.Server 强制关闭。
+func Handler(s *Server) gudp.ServerOption {
+    return gudp.ContextHandler(s.Serve)
+}
+
+// Serve 读取数据报并分发到会话，上下文结束后等待全部会话结束。
+func (s *Server) Serve(ctx context.Context, conn *ggudp.ServerConn) {
+    stop := make(chan struct{})
+    defer close(stop)
+    go s.tick(stop)
+    defer func() {
+        for _, sess := range s.snapshot() {
+            sess.terminate(ErrSessionClosed)
+        }
+        s.handles.Wait()
+    }()
+    buf := make([]byte, 64<<10)
+    for {
+        if ctx.Err() != nil {
+            // 停止接受新会话，继续收发数据直到已有会话结束。
+            if s.Count() == 0 {
+                return
+            }
+            _ = conn.SetReadDeadline(time.Now().Add(s.o.interval))
+        }
+        n, addr, err := conn.ReadFromUDPAddrPort(buf)
+        if err != nil {
+            if errors.Is(err, net.ErrClosed) {
+                return
+            }
+            var ne net.Error
+            if !errors.As(err, &ne) || !ne.Timeout() {
+                glog.Warnf("[UDP] reliable server read failed: %v", err)
+            }
+            continue
+        }
+        s.input(ctx, conn, addr, buf[:n])
+    }
+}
+
+// input 将数据报交给对应会话，首个数据报为序号 0 的数据时创建会话。
+func (s *Server) input(ctx context.Context, conn *ggudp.ServerConn, addr netip.AddrPort, data []byte) {
+    if len(data) < headerSize {
+        return
+    }
+    key := sessionKey{addr: addr, conv: binary.BigEndian.Uint32(data)}
+    s.mu.Lock()
+    sess, ok := s.sessions[key]
+    if !ok {
+        if ctx.Err() != nil || data[4] != cmdPush || binary.BigEndian.Uint32(data[11:]) != 0 {
+            s.mu.Unlock()
+            return
+        }
+        if s.o.maxSess > 0 && len(s.sessions) >= s.o.maxSess {
+            s.mu.Unlock()
+            s.rejected.Add(1)
+            return
+        }
+        remote := net.UDPAddrFromAddrPort(addr)
+        sess = newSession(ctx, key.conv, s.o, conn.LocalAddr(), remote, func(b []byte) {
+            _, _ = conn.WriteToUDPAddrPort(b, addr)
+        })
+        sess.onRemove = func() {
+            s.mu.Lock()
+            if s.sessions[key] == sess {
+                delete(s.sessions, key)
+            }
+            s.mu.Unlock()
+        }
+        s.sessions[key] = sess
+        s.handles.Add(1)
+        go s.serve(sess)
+    }
+    s.mu.Unlock()
+    sess.input(data)
+}
+
+// serve 调用处理器，处理器返回后关闭会话。
+func (s *Server) serve(sess *Session) {
+    defer s.handles.Done()
+    defer sess.Close()
+    defer func() {
+        if r := recover(); r != nil {
+            glog.Errorf("[UDP] reliable session %d from %s panic: %v", sess.conv, sess.remote, r)
+        }
+    }()
+    s.handler(sess.ctx, sess)
+}
+
+// tick 定时刷新全部会话。
+func (s *Server) tick(stop chan struct{}) {
+    ticker := time.NewTicker(s.o.interval)
+    defer ticker.Stop()
+    for {
+        select {
+        case <-ticker.C:
+            for _, sess := range s.snapshot() {
+                sess.update()
+            }
+        case <-stop:
+            return
+        }
+    }
+}
+
+// snapshot 返回当前全部会话。
+func (s *Server) snapshot() []*Session {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    list := make([]*Session, 0, len(s.sessions))
+    for _, sess := range s.sessions {
+        list = append(list, sess)
+    }
+    return list
+}
+
+// Count 返回当前会话数。
+func (s *Server) Count() int {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return len(s.sessions)
+}
+
+// Rejected 返回因超过 MaxSessions 而丢弃的新会话数据报数。
+func (s *Server) Rejected() int64 {
+    return s.rejected.Load()
+}
+
+// Range 遍历全部会话，fn 返回 false 时停止遍历。
+func (s *Server) Range(fn func(sess *Session) bool) {
+    for _, sess := range s.snapshot() {
+        if !fn(sess) {
+            return
+        }
+    }
+}
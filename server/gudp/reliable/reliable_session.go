@@ -0,0 +1,294 @@
+package reliable
+
+import (
+    "context"
+    "net"
+    "os"
+    "sync"
+    "time"
+)
+
+var _ net.Conn = (*Session)(nil)
+
+// Session 定义可靠 UDP 会话，提供有序可靠的字节流，实现 net.Conn。
+//
+// 可通过 gtcp.NewConnByNetConn 与 gtcp.NewFrameConn 复用 TCP 的分帧与路由代码。
+// 服务端会话上下文取消后，阻塞中的读取与因发送队列已满而阻塞的写入立即返回超时错误，
+// 已缓存的数据仍可读取，其他写入不受影响。
+type Session struct {
+    conv   uint32
+    o      *option
+    local  net.Addr
+    remote net.Addr
+    ctx    context.Context
+    cancel context.CancelFunc
+    start  time.Time
+
+    mu       sync.Mutex
+    k        *arq
+    rd, wd   time.Time
+    closed   bool
+    closedAt time.Time
+    lastRecv time.Time
+    err      error
+
+    readEvent  chan struct{}
+    writeEvent chan struct{}
+    closing    chan struct{}
+    die        chan struct{}
+    dieOnce    sync.Once
+    onRemove   func()
+}
+
+// newSession 新建会话，write 用于发送数据报。
+func newSession(ctx context.Context, conv uint32, o *option, local, remote net.Addr, write func([]byte)) *Session {
+    s := &Session{
+        conv:       conv,
+        o:          o,
+        local:      local,
+        remote:     remote,
+        start:      time.Now(),
+        lastRecv:   time.Now(),
+        readEvent:  make(chan struct{}, 1),
+        writeEvent: make(chan struct{}, 1),
+        closing:    make(chan struct{}),
+        die:        make(chan struct{}),
+    }
+    s.ctx, s.cancel = context.WithCancel(ctx)
+    s.k = newARQ(conv, o, write)
+    return s
+}
+
+// ID 返回会话 ID。
+func (s *Session) ID() uint32 {
+    return s.conv
+}
+
+// Context 返回会话上下文，会话结束或服务器停止时取消。
+func (s *Session) Context() context.Context {
+    return s.ctx
+}
+
+// clock 返回会话启动后经过的毫秒数。
+func (s *Session) clock() uint32 {
+    return uint32(time.Since(s.start).Milliseconds())
+}
+
+// notify 非阻塞地发送事件通知。
+func notify(ch chan struct{}) {
+    select {
+    case ch <- struct{}{}:
+    default:
+    }
+}
+
+// input 处理收到的数据报并立即发送确认。
+func (s *Session) input(data []byte) {
+    s.mu.Lock()
+    s.k.current = s.clock()
+    if err := s.k.input(data); err != nil {
+        s.mu.Unlock()
+        return
+    }
+    s.lastRecv = time.Now()
+    s.k.flush()
+    s.mu.Unlock()
+    s.wake()
+}
+
+// update 定时刷新控制块，检查会话是否结束。
+func (s *Session) update() {
+    s.mu.Lock()
+    s.k.current = s.clock()
+    s.k.flush()
+    var (
+        done bool
+        err  error
+    )
+    switch {
+    case s.k.dead || time.Since(s.lastRecv) > s.o.timeout:
+        done, err = true, ErrSessionTimeout
+    case s.closed && s.k.waitSnd() == 0 && s.k.finRecv:
+        // 双方结束报文均已确认。
+        done = true
+    case s.closed && time.Since(s.closedAt) > s.o.linger:
+        done = true
+    }
+    s.mu.Unlock()
+    s.wake()
+    if done {
+        s.terminate(err)
+    }
+}
+
+// wake 唤醒等待读取与写入的调用方重新检查状态。
+func (s *Session) wake() {
+    notify(s.readEvent)
+    notify(s.writeEvent)
+}
+
+// terminate 结束会话，err 为读写返回的错误。
+func (s *Session) terminate(err error) {
+    s.dieOnce.Do(func() {
+        s.mu.Lock()
+        s.err = err
+        s.mu.Unlock()
+        close(s.die)
+        s.cancel()
+        if s.onRemove != nil {
+            s.onRemove()
+        }
+    })
+}
+
+// dead 返回会话结束的错误，会话未结束时返回 nil。
+func (s *Session) dead() error {
+    select {
+    case <-s.die:
+        if s.err != nil {
+            return s.err
+        }
+        return ErrSessionClosed
+    default:
+        return nil
+    }
+}
+
+// wait 等待事件、关闭、截止时间或上下文取消。
+func (s *Session) wait(ev chan struct{}, deadline time.Time) error {
+    var timeout <-chan time.Time
+    if !deadline.IsZero() {
+        d := time.Until(deadline)
+        if d <= 0 {
+            return os.ErrDeadlineExceeded
+        }
+        t := time.NewTimer(d)
+        defer t.Stop()
+        timeout = t.C
+    }
+    select {
+    case <-ev:
+    case <-s.closing:
+    case <-s.die:
+    case <-s.ctx.Done():
+        // 会话结束时同样取消上下文，此时由调用方返回会话结束的错误。
+        if s.dead() == nil {
+            return os.ErrDeadlineExceeded
+        }
+    case <-timeout:
+        return os.ErrDeadlineExceeded
+    }
+    return nil
+}
+
+// Read 读取有序数据，对端关闭后返回 io.EOF。
+func (s *Session) Read(b []byte) (int, error) {
+    for {
+        s.mu.Lock()
+        if s.closed {
+            s.mu.Unlock()
+            return 0, net.ErrClosed
+        }
+        if s.k.readable() {
+            n, err := s.k.recv(b)
+            s.mu.Unlock()
+            return n, err
+        }
+        if err := s.dead(); err != nil {
+            s.mu.Unlock()
+            return 0, err
+        }
+        deadline := s.rd
+        s.mu.Unlock()
+        if err := s.wait(s.readEvent, deadline); err != nil {
+            return 0, err
+        }
+    }
+}
+
+// Write 写入数据，发送队列超过两倍发送窗口时阻塞。
+func (s *Session) Write(b []byte) (int, error) {
+    for {
+        s.mu.Lock()
+        if s.closed {
+            s.mu.Unlock()
+            return 0, net.ErrClosed
+        }
+        if err := s.dead(); err != nil {
+            s.mu.Unlock()
+            return 0, err
+        }
+        if s.k.waitSnd() < 2*s.o.sndWnd {
+            s.k.current = s.clock()
+            s.k.send(b)
+            s.k.flush()
+            s.mu.Unlock()
+            return len(b), nil
+        }
+        deadline := s.wd
+        s.mu.Unlock()
+        if err := s.wait(s.writeEvent, deadline); err != nil {
+            return 0, err
+        }
+    }
+}
+
+// Close 关闭会话，发送结束报文后等待数据确认与对端关闭，最长等待 Linger。
+func (s *Session) Close() error {
+    s.mu.Lock()
+    if s.closed {
+        s.mu.Unlock()
+        return nil
+    }
+    s.closed = true
+    s.closedAt = time.Now()
+    if s.dead() == nil {
+        s.k.current = s.clock()
+        s.k.close()
+        s.k.flush()
+    }
+    s.mu.Unlock()
+    close(s.closing)
+    t := time.NewTimer(s.o.linger + s.o.interval)
+    defer t.Stop()
+    select {
+    case <-s.die:
+    case <-t.C:
+        s.terminate(nil)
+    }
+    return nil
+}
+
+// LocalAddr 返回本地地址。
+func (s *Session) LocalAddr() net.Addr {
+    return s.local
+}
+
+// RemoteAddr 返回对端地址。
+func (s *Session) RemoteAddr() net.Addr {
+    return s.remote
+}
+
+func (s *Session) SetDeadline(t time.Time) error {
+    s.mu.Lock()
+    s.rd, s.wd = t, t
+    s.mu.Unlock()
+    s.wake()
+    return nil
+}
+
+func (s *Session) SetReadDeadline(t time.Time) error {
+    s.mu.Lock()
+    s.rd = t
+    s.mu.Unlock()
+    notify(s.readEvent)
+    return nil
+}
+
+func (s *Session) SetWriteDeadline(t time.Time) error {
+    s.mu.Lock()
+    s.wd = t
+    s.mu.Unlock()
+    notify(s.writeEvent)
+    return nil
+}
@@ -0,0 +1,301 @@
+package reliable
+
+import (
+    "bytes"
+    "context"
+    "encoding/binary"
+    "errors"
+    "io"
+    "math/rand/v2"
+    "testing"
+    "time"
+
+    ggtcp "github.com/camry/g/v2/gnet/gtcp"
+
+    "github.com/camry/dove/v2/server/gtcp"
+    "github.com/camry/dove/v2/server/gtcp/codec"
+    "github.com/camry/dove/v2/server/gtcp/router"
+    "github.com/camry/dove/v2/server/gudp"
+)
+
+func TestARQ_Lossy(t *testing.T) {
+    o := newOption([]Option{MTU(200), Window(32, 32)})
+    rng := rand.New(rand.NewPCG(1, 2))
+    var toA, toB [][]byte
+    lossy := func(queue *[][]byte) func([]byte) {
+        return func(b []byte) {
+            if rng.IntN(5) > 0 {
+                *queue = append(*queue, bytes.Clone(b))
+            }
+        }
+    }
+    a := newARQ(1, o, lossy(&toB))
+    b := newARQ(1, o, lossy(&toA))
+
+    want := make([]byte, 64<<10)
+    for i := range want {
+        want[i] = byte(rng.Uint32())
+    }
+    a.send(want)
+    a.close()
+    var got []byte
+    buf := make([]byte, 4096)
+    for now := uint32(0); now < 60000; now += 10 {
+        a.current, b.current = now, now
+        for _, p := range toB {
+            _ = b.input(p)
+        }
+        for _, p := range toA {
+            _ = a.input(p)
+        }
+        toA, toB = toA[:0], toB[:0]
+        a.flush()
+        b.flush()
+        for b.readable() {
+            n, err := b.recv(buf)
+            if err == io.EOF {
+                break
+            }
+            got = append(got, buf[:n]...)
+        }
+        if b.finRecv && a.waitSnd() == 0 {
+            break
+        }
+    }
+    if !bytes.Equal(got, want) {
+        t.Fatalf("expect %d bytes in order, got %d", len(want), len(got))
+    }
+    if !b.finRecv || a.waitSnd() != 0 || a.dead {
+        t.Fatalf("expect fin delivered, finRecv=%v waitSnd=%d dead=%v", b.finRecv, a.waitSnd(), a.dead)
+    }
+}
+
+func TestARQ_InvalidSegment(t *testing.T) {
+    k := newARQ(1, newOption(nil), func([]byte) {})
+    seg := segment{cmd: cmdPush, data: []byte("x")}
+    if err := k.input(seg.encode(2, nil)); err == nil {
+        t.Fatal("expect conv mismatch rejected")
+    }
+    b := seg.encode(1, nil)
+    binary.BigEndian.PutUint16(b[19:], 100)
+    if err := k.input(b); err == nil {
+        t.Fatal("expect truncated segment rejected")
+    }
+}
+
+func startServer(t *testing.T, rs *Server) *gudp.Server {
+    t.Helper()
+    s := gudp.NewServer(gudp.Address("127.0.0.1:0"), Handler(rs))
+    if err := s.Listen(context.Background()); err != nil {
+        t.Fatal(err)
+    }
+    go func() { _ = s.Start(context.Background()) }()
+    return s
+}
+
+func TestServer_Echo(t *testing.T) {
+    rs := NewServer(func(ctx context.Context, sess *Session) {
+        _, _ = io.Copy(sess, sess)
+    })
+    s := startServer(t, rs)
+    defer s.Stop(context.Background())
+
+    sess, err := Dial(context.Background(), s.ListenAddr().String())
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer sess.Close()
+    want := make([]byte, 256<<10)
+    for i := range want {
+        want[i] = byte(i * 7)
+    }
+    go func() { _, _ = sess.Write(want) }()
+    _ = sess.SetReadDeadline(time.Now().Add(5 * time.Second))
+    got := make([]byte, len(want))
+    if _, err := io.ReadFull(sess, got); err != nil {
+        t.Fatal(err)
+    }
+    if !bytes.Equal(got, want) {
+        t.Fatal("expect echoed bytes equal")
+    }
+    if n := rs.Count(); n != 1 {
+        t.Fatalf("expect 1 session, got %d", n)
+    }
+}
+
+func TestServer_Router(t *testing.T) {
+    type echo struct {
+        Text string `json:"text"`
+    }
+    r := router.New()
+    router.Register(r, 1, func(ctx context.Context, req *echo) (*echo, error) {
+        return req, nil
+    })
+    rs := NewServer(func(ctx context.Context, sess *Session) {
+        fc := gtcp.NewFrameConn(ggtcp.NewConnByNetConn(sess), codec.LengthField(4, binary.BigEndian))
+        for ctx.Err() == nil {
+            frame, err := fc.ReadFrame()
+            if err != nil {
+                return
+            }
+            r.ServeFrame(ctx, fc, frame)
+        }
+    })
+    s := startServer(t, rs)
+    defer s.Stop(context.Background())
+
+    sess, err := Dial(context.Background(), s.ListenAddr().String())
+    if err != nil {
+        t.Fatal(err)
+    }
+    c := router.NewClient(sess)
+    defer c.Close()
+    ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+    defer cancel()
+    var resp echo
+    if err := c.Call(ctx, 1, &echo{Text: "hi"}, &resp); err != nil || resp.Text != "hi" {
+        t.Fatalf("expect hi, got %+v %v", resp, err)
+    }
+}
+
+func TestServer_GracefulStop(t *testing.T) {
+    rs := NewServer(func(ctx context.Context, sess *Session) {
+        buf := make([]byte, 4)
+        if _, err := io.ReadFull(sess, buf); err != nil {
+            return
+        }
+        <-ctx.Done()
+        _, _ = sess.Write([]byte("bye"))
+    })
+    s := startServer(t, rs)
+
+    sess, err := Dial(context.Background(), s.ListenAddr().String())
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer sess.Close()
+    _, _ = sess.Write([]byte("ping"))
+    deadline := time.Now().Add(time.Second)
+    for rs.Count() == 0 && time.Now().Before(deadline) {
+        time.Sleep(5 * time.Millisecond)
+    }
+
+    stopped := make(chan error, 1)
+    go func() {
+        ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+        defer cancel()
+        stopped <- s.Stop(ctx)
+    }()
+    _ = sess.SetReadDeadline(time.Now().Add(3 * time.Second))
+    got, err := io.ReadAll(sess)
+    if err != nil || string(got) != "bye" {
+        t.Fatalf("expect bye then EOF, got %q %v", got, err)
+    }
+    _ = sess.Close()
+    if err := <-stopped; err != nil {
+        t.Fatal(err)
+    }
+    if st := s.Stats(); st.Drained != 1 || st.Killed != 0 {
+        t.Fatalf("expect 1 drained, got %+v", st)
+    }
+    if n := rs.Count(); n != 0 {
+        t.Fatalf("expect no sessions, got %d", n)
+    }
+}
+
+func TestServer_StopBlockedRead(t *testing.T) {
+    rs := NewServer(func(ctx context.Context, sess *Session) {
+        _, _ = io.Copy(sess, sess)
+    })
+    s := startServer(t, rs)
+
+    sess, err := Dial(context.Background(), s.ListenAddr().String())
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer sess.Close()
+    _, _ = sess.Write([]byte("ping"))
+    _ = sess.SetReadDeadline(time.Now().Add(2 * time.Second))
+    buf := make([]byte, 4)
+    if _, err := io.ReadFull(sess, buf); err != nil {
+        t.Fatal(err)
+    }
+
+    stopped := make(chan error, 1)
+    start := time.Now()
+    go func() {
+        ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+        defer cancel()
+        stopped <- s.Stop(ctx)
+    }()
+    if _, err := sess.Read(buf); err != io.EOF {
+        t.Fatalf("expect EOF after server stop, got %v", err)
+    }
+    _ = sess.Close()
+    if err := <-stopped; err != nil {
+        t.Fatal(err)
+    }
+    if d := time.Since(start); d > time.Second {
+        t.Fatalf("expect stop without waiting for timeout, took %v", d)
+    }
+    if st := s.Stats(); st.Drained != 1 || st.Killed != 0 {
+        t.Fatalf("expect 1 drained, got %+v", st)
+    }
+}
+
+func TestServer_MaxSessions(t *testing.T) {
+    rs := NewServer(func(ctx context.Context, sess *Session) {
+        _, _ = io.Copy(sess, sess)
+    }, MaxSessions(1))
+    s := startServer(t, rs)
+    defer s.Stop(context.Background())
+
+    for i := range 2 {
+        sess, err := Dial(context.Background(), s.ListenAddr().String(), SessionTimeout(300*time.Millisecond))
+        if err != nil {
+            t.Fatal(err)
+        }
+        defer sess.Close()
+        _, _ = sess.Write([]byte("ping"))
+        _, err = io.ReadFull(sess, make([]byte, 4))
+        if i == 0 && err != nil {
+            t.Fatal(err)
+        }
+        if i == 1 && !errors.Is(err, ErrSessionTimeout) {
+            t.Fatalf("expect second session rejected, got %v", err)
+        }
+    }
+    if n, r := rs.Count(), rs.Rejected(); n != 1 || r == 0 {
+        t.Fatalf("expect 1 session and rejected datagrams, got %d %d", n, r)
+    }
+}
+
+func TestSession_Timeout(t *testing.T) {
+    rs := NewServer(func(ctx context.Context, sess *Session) {
+        <-ctx.Done()
+    }, SessionTimeout(200*time.Millisecond))
+    s := startServer(t, rs)
+    defer s.Stop(context.Background())
+
+    sess, err := Dial(context.Background(), s.ListenAddr().String(), SessionTimeout(time.Minute))
+    if err != nil {
+        t.Fatal(err)
+    }
+    _, _ = sess.Write([]byte("ping"))
+    deadline := time.Now().Add(time.Second)
+    for rs.Count() == 0 && time.Now().Before(deadline) {
+        time.Sleep(5 * time.Millisecond)
+    }
+    // 关闭客户端套接字模拟对端失联。
+    sess.terminate(nil)
+    deadline = time.Now().Add(2 * time.Second)
+    for rs.Count() != 0 && time.Now().Before(deadline) {
+        time.Sleep(10 * time.Millisecond)
+    }
+    if n := rs.Count(); n != 0 {
+        t.Fatalf("expect session timed out, got %d", n)
+    }
+    if _, err := sess.Read(make([]byte, 1)); !errors.Is(err, ErrSessionClosed) {
+        t.Fatalf("expect ErrSessionClosed, got %v", err)
+    }
+}
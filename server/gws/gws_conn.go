@@ -0,0 +1,270 @@
+package gws
+
+import (
+    "context"
+    "errors"
+    "net"
+    "net/http"
+    "sync"
+    "sync/atomic"
+    "time"
+
+    "github.com/camry/g/v2/glog"
+    "github.com/gorilla/websocket"
+
+    "github.com/camry/dove/v2/server/gtcp"
+)
+
+var (
+    // ErrQueueFull 连接发送队列已满。
+    ErrQueueFull = gtcp.ErrQueueFull
+    // ErrConnClosed 连接已关闭。
+    ErrConnClosed = gtcp.ErrSessionClosed
+)
+
+// BackpressurePolicy 定义连接发送队列已满时的处理策略，与 gtcp 会话一致。
+type BackpressurePolicy = gtcp.BackpressurePolicy
+
+const (
+    // BackpressureDrop 丢弃新消息并返回 ErrQueueFull。
+    BackpressureDrop = gtcp.BackpressureDrop
+    // BackpressureDropOldest 丢弃队列中最早的消息后入队新消息。
+    BackpressureDropOldest = gtcp.BackpressureDropOldest
+    // BackpressureClose 关闭发送过慢的连接并返回 ErrQueueFull。
+    BackpressureClose = gtcp.BackpressureClose
+)
+
+// connKey 连接上下文键。
+type connKey struct{}
+
+// FromContext 从处理器上下文中获取连接。
+func FromContext(ctx context.Context) (*Conn, bool) {
+    c, ok := ctx.Value(connKey{}).(*Conn)
+    return c, ok
+}
+
+// Conn 定义 WebSocket 连接会话。
+//
+// 连接基于 gtcp.Session 实现，用户 ID、分组与发送队列由会话管理器维护；
+// 发送的消息进入有界队列，由会话协程按序写出，慢连接不会阻塞调用方。
+type Conn struct {
+    s        *Server
+    ws       *websocket.Conn
+    req      *http.Request
+    ctx      context.Context
+    cancel   context.CancelFunc
+    sess     *gtcp.Session
+    lastRead atomic.Int64 // 最近一次收到消息或 Pong 的时间（纳秒）。
+
+    closeOnce sync.Once
+    closeCode int
+    closeText string
+    closing   chan struct{}
+}
+
+// newConn 新建连接会话。
+func newConn(s *Server, ws *websocket.Conn, r *http.Request) *Conn {
+    c := &Conn{
+        s:       s,
+        ws:      ws,
+        req:     r,
+        closing: make(chan struct{}),
+    }
+    c.lastRead.Store(time.Now().UnixNano())
+    return c
+}
+
+// ID 返回连接 ID，即会话 ID。
+func (c *Conn) ID() uint64 {
+    return c.sess.ID()
+}
+
+// Session 返回连接所属会话。
+func (c *Conn) Session() *gtcp.Session {
+    return c.sess
+}
+
+// Context 返回连接上下文，连接关闭或服务器停止时取消。
+func (c *Conn) Context() context.Context {
+    return c.sess.Context()
+}
+
+// Request 返回升级请求。
+func (c *Conn) Request() *http.Request {
+    return c.req
+}
+
+// Subprotocol 返回协商的子协议。
+func (c *Conn) Subprotocol() string {
+    return c.ws.Subprotocol()
+}
+
+// RemoteAddr 返回远程地址。
+func (c *Conn) RemoteAddr() net.Addr {
+    return c.ws.RemoteAddr()
+}
+
+// UID 返回绑定的用户 ID。
+func (c *Conn) UID() string {
+    return c.sess.UID()
+}
+
+// Bind 绑定用户 ID，同一用户 ID 仅指向最近绑定的会话。
+func (c *Conn) Bind(uid string) {
+    c.sess.Bind(uid)
+}
+
+// Join 加入分组。
+func (c *Conn) Join(group string) {
+    c.sess.Join(group)
+}
+
+// Leave 离开分组。
+func (c *Conn) Leave(group string) {
+    c.sess.Leave(group)
+}
+
+// Set 设置连接属性。
+func (c *Conn) Set(key string, value any) {
+    c.sess.Set(key, value)
+}
+
+// Get 获取连接属性。
+func (c *Conn) Get(key string) (any, bool) {
+    return c.sess.Get(key)
+}
+
+// Send 将消息放入发送队列，队列已满时按 Backpressure 策略处理。
+func (c *Conn) Send(messageType int, data []byte) error {
+    select {
+    case <-c.closing:
+        return ErrConnClosed
+    default:
+    }
+    return c.sess.SendMessage(messageType, data)
+}
+
+// SendText 发送文本消息。
+func (c *Conn) SendText(text string) error {
+    return c.Send(TextMessage, []byte(text))
+}
+
+// Close 写出队列中剩余的消息后发送关闭帧，等待对端确认后关闭连接。
+func (c *Conn) Close(code int, text string) {
+    c.closeOnce.Do(func() {
+        c.closeCode, c.closeText = code, text
+        close(c.closing)
+    })
+}
+
+// abort 立即发送关闭帧并关闭连接。
+func (c *Conn) abort(code int, text string) {
+    c.Close(code, text)
+    _ = c.ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, text), time.Now().Add(c.s.writeTimeout))
+    _ = c.ws.NetConn().Close()
+}
+
+// touch 收到消息或 Pong 时延长心跳超时，关闭过程中不再延长。
+func (c *Conn) touch() {
+    c.lastRead.Store(time.Now().UnixNano())
+    select {
+    case <-c.closing:
+        return
+    default:
+    }
+    if c.s.pongTimeout > 0 {
+        _ = c.ws.SetReadDeadline(time.Now().Add(c.s.pongTimeout))
+    }
+}
+
+// keepalive 定时发送 Ping，连接关闭时通知会话写出剩余消息。
+func (c *Conn) keepalive() {
+    var tick <-chan time.Time
+    if c.s.pingInterval > 0 {
+        ticker := time.NewTicker(c.s.pingInterval)
+        defer ticker.Stop()
+        tick = ticker.C
+    }
+    for {
+        select {
+        case <-tick:
+            if err := c.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(c.s.writeTimeout)); err != nil {
+                if !errors.Is(err, websocket.ErrCloseSent) {
+                    c.fail(err)
+                }
+                return
+            }
+        case <-c.closing:
+            c.sess.Close()
+            return
+        case <-c.ctx.Done():
+            // 服务器停止时先关闭连接再取消上下文，仍需通知会话发送关闭帧。
+            select {
+            case <-c.closing:
+                c.sess.Close()
+            default:
+            }
+            return
+        }
+    }
+}
+
+// fail 写入失败时关闭连接，读取循环随后返回。
+func (c *Conn) fail(err error) {
+    if !errors.Is(err, net.ErrClosed) && !errors.Is(err, websocket.ErrCloseSent) {
+        glog.Warnf("[WS] connection %d write to %s failed: %v", c.ID(), c.RemoteAddr(), err)
+    }
+    c.closeOnce.Do(func() { close(c.closing) })
+    _ = c.ws.NetConn().Close()
+}
+
+// sessionConn 将 WebSocket 连接适配为 gtcp.SessionConn。
+type sessionConn struct {
+    c *Conn
+}
+
+// RemoteAddr 返回远程地址。
+func (sc sessionConn) RemoteAddr() net.Addr {
+    return sc.c.RemoteAddr()
+}
+
+// LastActive 返回最近一次收到消息或 Pong 的时间。
+func (sc sessionConn) LastActive() time.Time {
+    return time.Unix(0, sc.c.lastRead.Load())
+}
+
+// WriteMessage 在写入超时时间内写出一条消息，类型为 0 时按二进制消息写出，写入失败时关闭连接。
+func (sc sessionConn) WriteMessage(typ int, data []byte) error {
+    if typ == 0 {
+        typ = BinaryMessage
+    }
+    c := sc.c
+    _ = c.ws.SetWriteDeadline(time.Now().Add(c.s.writeTimeout))
+    if err := c.ws.WriteMessage(typ, data); err != nil {
+        _ = c.ws.NetConn().Close()
+        return err
+    }
+    return nil
+}
+
+// SetWriteDeadline 每条消息按 WriteTimeout 设置写入截止时间，此处无需处理。
+func (sc sessionConn) SetWriteDeadline(time.Time) error {
+    return nil
+}
+
+// Shutdown 发送关闭帧，对端在写入超时时间内未确认时关闭连接。
+func (sc sessionConn) Shutdown() {
+    c := sc.c
+    // 会话因发送队列已满或心跳超时关闭时使用正常关闭码。
+    c.closeOnce.Do(func() {
+        c.closeCode = websocket.CloseNormalClosure
+        close(c.closing)
+    })
+    deadline := time.Now().Add(c.s.writeTimeout)
+    if err := c.ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(c.closeCode, c.closeText), deadline); err != nil {
+        c.fail(err)
+        return
+    }
+    // 读取循环收到对端关闭帧后返回。
+    _ = c.ws.SetReadDeadline(deadline)
+}
@@ -0,0 +1,506 @@
+package gws
+
+import (
+    "context"
+    "crypto/tls"
+    "errors"
+    "fmt"
+    "net"
+    "net/http"
+    "sync"
+    "sync/atomic"
+    "time"
+
+    "github.com/camry/g/v2/glog"
+    "github.com/gorilla/websocket"
+
+    "github.com/camry/dove/v2/metadata"
+    "github.com/camry/dove/v2/server"
+    "github.com/camry/dove/v2/server/ghttp"
+    "github.com/camry/dove/v2/server/gtcp"
+)
+
+var (
+    _ server.Server   = (*Server)(nil)
+    _ server.Listener = (*Server)(nil)
+    _ http.Handler    = (*Server)(nil)
+)
+
+// 消息类型。
+const (
+    TextMessage   = websocket.TextMessage
+    BinaryMessage = websocket.BinaryMessage
+)
+
+// ServerOption 定义一个 WebSocket 服务选项类型。
+type ServerOption func(s *Server)
+
+// Address 配置服务监听地址。
+//
+// 未配置时服务器不监听端口，需将其作为 http.Handler 挂载到已有 HTTP 服务（如 ghttp.Server）的路径上。
+func Address(address string) ServerOption {
+    return func(s *Server) { s.address = address }
+}
+
+// Path 配置独立监听时的升级路径，默认 "/"。
+func Path(path string) ServerOption {
+    return func(s *Server) { s.path = path }
+}
+
+// TLSConfig 配置 TLS。
+func TLSConfig(c *tls.Config) ServerOption {
+    return func(s *Server) { s.tlsConf = c }
+}
+
+// Filter 配置升级前执行的 HTTP 过滤器（中间件），如认证，可多次调用累加，按配置顺序由外向内执行。
+func Filter(filters ...ghttp.FilterFunc) ServerOption {
+    return func(s *Server) { s.filters = append(s.filters, filters...) }
+}
+
+// CheckOrigin 配置跨域校验函数，默认仅允许同源请求。
+func CheckOrigin(fn func(r *http.Request) bool) ServerOption {
+    return func(s *Server) { s.upgrader.CheckOrigin = fn }
+}
+
+// Subprotocols 配置支持的子协议。
+func Subprotocols(protocols ...string) ServerOption {
+    return func(s *Server) { s.upgrader.Subprotocols = protocols }
+}
+
+// ReadLimit 配置单条消息的最大字节数，超过时关闭连接，默认 4 MiB。
+func ReadLimit(n int64) ServerOption {
+    return func(s *Server) { s.readLimit = n }
+}
+
+// PingInterval 配置心跳 Ping 发送间隔，默认 30 秒，0 表示不发送。
+func PingInterval(d time.Duration) ServerOption {
+    return func(s *Server) { s.pingInterval = d }
+}
+
+// PongTimeout 配置心跳超时时间，超过该时间未收到任何消息或 Pong 时关闭连接，默认 60 秒，0 表示不检测。
+func PongTimeout(d time.Duration) ServerOption {
+    return func(s *Server) { s.pongTimeout = d }
+}
+
+// WriteTimeout 配置单条消息的写入超时时间，默认 10 秒。
+func WriteTimeout(d time.Duration) ServerOption {
+    return func(s *Server) { s.writeTimeout = d }
+}
+
+// SendQueueSize 配置单个连接的发送队列长度，默认 256，配置 Sessions 时不生效。
+func SendQueueSize(n int) ServerOption {
+    return func(s *Server) { s.queueSize = n }
+}
+
+// Backpressure 配置发送队列已满时的处理策略，默认 BackpressureDrop，配置 Sessions 时不生效。
+func Backpressure(policy BackpressurePolicy) ServerOption {
+    return func(s *Server) { s.policy = policy }
+}
+
+// Sessions 配置会话管理器，可与 gtcp 服务器共享，以便跨协议按用户 ID 查找会话与分组广播。
+//
+// 未配置时按 SendQueueSize 与 Backpressure 新建会话管理器。
+func Sessions(m *gtcp.SessionManager) ServerOption {
+    return func(s *Server) { s.sessions = m }
+}
+
+// MetadataOptions 配置元数据传播选项，升级请求头中的元数据提取到连接上下文。
+func MetadataOptions(opts ...metadata.Option) ServerOption {
+    return func(s *Server) { s.metadata = metadata.NewPropagator(opts...) }
+}
+
+// OnConnect 配置连接建立回调，在读取消息前调用。
+func OnConnect(fn func(ctx context.Context, conn *Conn)) ServerOption {
+    return func(s *Server) { s.onConnect = fn }
+}
+
+// MessageHandler 配置消息处理器，同一连接的消息按顺序处理。
+func MessageHandler(fn func(ctx context.Context, conn *Conn, messageType int, data []byte)) ServerOption {
+    return func(s *Server) { s.onMessage = fn }
+}
+
+// OnDisconnect 配置连接关闭回调，err 为读取结束的原因。
+func OnDisconnect(fn func(conn *Conn, err error)) ServerOption {
+    return func(s *Server) { s.onDisconnect = fn }
+}
+
+// Server 定义 WebSocket 服务器。
+type Server struct {
+    mu           sync.Mutex
+    address      string                                                          // 服务器监听地址。
+    path         string                                                          // 升级路径。
+    tlsConf      *tls.Config                                                     // TLS 配置。
+    filters      []ghttp.FilterFunc                                              // HTTP 过滤器。
+    upgrader     websocket.Upgrader                                              // 协议升级器。
+    readLimit    int64                                                           // 单条消息最大字节数。
+    pingInterval time.Duration                                                   // Ping 发送间隔。
+    pongTimeout  time.Duration                                                   // 心跳超时时间。
+    writeTimeout time.Duration                                                   // 写入超时时间。
+    queueSize    int                                                             // 发送队列长度。
+    policy       BackpressurePolicy                                              // 发送队列已满时的处理策略。
+    sessions     *gtcp.SessionManager                                            // 会话管理器。
+    metadata     *metadata.Propagator                                            // 元数据传播器。
+    onConnect    func(ctx context.Context, conn *Conn)                           // 连接建立回调。
+    onMessage    func(ctx context.Context, conn *Conn, messageType int, data []byte) // 消息处理器。
+    onDisconnect func(conn *Conn, err error)                                     // 连接关闭回调。
+
+    handler http.Handler
+    hs      *http.Server
+    lis     net.Listener
+    closed  bool
+    ctx     context.Context
+    cancel  context.CancelFunc
+    done    chan struct{}
+    conns   map[*Conn]struct{}
+    handles sync.WaitGroup
+
+    accepted atomic.Int64
+    drained  atomic.Int64
+    killed   atomic.Int64
+}
+
+// NewServer 新建 WebSocket 服务器。
+func NewServer(opts ...ServerOption) *Server {
+    srv := &Server{
+        path:         "/",
+        readLimit:    4 << 20,
+        pingInterval: 30 * time.Second,
+        pongTimeout:  60 * time.Second,
+        writeTimeout: 10 * time.Second,
+        queueSize:    256,
+        metadata:     metadata.NewPropagator(),
+        done:         make(chan struct{}),
+        conns:        make(map[*Conn]struct{}),
+    }
+    srv.ctx, srv.cancel = context.WithCancel(context.Background())
+    for _, opt := range opts {
+        opt(srv)
+    }
+    if srv.sessions == nil {
+        srv.sessions = gtcp.NewSessionManager(gtcp.SendQueueSize(srv.queueSize), gtcp.Backpressure(srv.policy))
+    }
+    srv.handler = ghttp.FilterChain(srv.filters...)(http.HandlerFunc(srv.upgrade))
+    return srv
+}
+
+// Listen 绑定 WebSocket 服务监听地址，未配置 Address 时不监听。
+func (s *Server) Listen(ctx context.Context) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if s.lis != nil || s.address == "" {
+        return nil
+    }
+    lis, err := net.Listen("tcp", s.address)
+    if err != nil {
+        return fmt.Errorf("[WS] server listen on %s failed: %w", s.address, err)
+    }
+    if s.tlsConf != nil {
+        lis = tls.NewListener(lis, s.tlsConf)
+    }
+    s.lis = lis
+    return nil
+}
+
+// Start 启动 WebSocket 服务器，阻塞直到 Stop 调用。
+//
+// 连接上下文派生自 App 上下文；未配置 Address 时仅等待停止，连接由挂载的 HTTP 服务接入。
+func (s *Server) Start(ctx context.Context) error {
+    if err := s.Listen(ctx); err != nil {
+        return err
+    }
+    s.mu.Lock()
+    if s.closed {
+        s.mu.Unlock()
+        // 启动前已停止时释放监听器。
+        if s.lis != nil {
+            if err := s.lis.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+                return err
+            }
+        }
+        return nil
+    }
+    s.ctx, s.cancel = context.WithCancel(ctx)
+    lis := s.lis
+    if lis != nil {
+        mux := http.NewServeMux()
+        mux.Handle(s.path, s)
+        s.hs = &http.Server{
+            Handler:     mux,
+            BaseContext: func(net.Listener) context.Context { return ctx },
+        }
+    }
+    hs := s.hs
+    s.mu.Unlock()
+    if hs == nil {
+        glog.Infof("[WS] server mounted")
+        <-s.done
+        return nil
+    }
+    glog.Infof("[WS] server listening on %s", lis.Addr().String())
+    if err := hs.Serve(lis); !errors.Is(err, http.ErrServerClosed) {
+        return err
+    }
+    return nil
+}
+
+// ServeHTTP 执行过滤器后将 HTTP 请求升级为 WebSocket 连接，可挂载到任意 HTTP 服务的路径上。
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    s.handler.ServeHTTP(w, r)
+}
+
+// upgrade 将 HTTP 请求升级为 WebSocket 连接并读取消息直到连接关闭。
+func (s *Server) upgrade(w http.ResponseWriter, r *http.Request) {
+    s.mu.Lock()
+    if s.closed {
+        s.mu.Unlock()
+        http.Error(w, "server shutting down", http.StatusServiceUnavailable)
+        return
+    }
+    s.handles.Add(1)
+    ctx := s.ctx
+    s.mu.Unlock()
+    defer s.handles.Done()
+    ws, err := s.upgrader.Upgrade(w, r, nil)
+    if err != nil {
+        // Upgrade 已写入错误响应。
+        glog.Warnf("[WS] server upgrade %s failed: %v", r.RemoteAddr, err)
+        return
+    }
+    c := s.open(s.metadata.ExtractHTTP(ctx, r.Header), ws, r)
+    if c == nil {
+        _ = ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"), time.Now().Add(s.writeTimeout))
+        _ = ws.Close()
+        return
+    }
+    s.serve(c)
+}
+
+// open 登记新连接并创建会话，服务器已停止时返回 nil。
+func (s *Server) open(ctx context.Context, ws *websocket.Conn, r *http.Request) *Conn {
+    s.mu.Lock()
+    if s.closed {
+        s.mu.Unlock()
+        return nil
+    }
+    c := newConn(s, ws, r)
+    c.ctx, c.cancel = context.WithCancel(context.WithValue(ctx, connKey{}, c))
+    s.conns[c] = struct{}{}
+    s.accepted.Add(1)
+    s.mu.Unlock()
+    // 会话创建回调可能访问服务器，不持有锁。
+    c.sess = s.sessions.Open(c.ctx, sessionConn{c: c})
+    return c
+}
+
+// serve 读取连接消息直到连接关闭。
+func (s *Server) serve(c *Conn) {
+    var err error
+    defer func() {
+        if r := recover(); r != nil {
+            glog.Errorf("[WS] server handle connection from %s panic: %v", c.RemoteAddr(), r)
+            err = fmt.Errorf("panic: %v", r)
+            c.abort(websocket.CloseInternalServerErr, "internal error")
+        }
+        s.remove(c)
+        if s.onDisconnect != nil {
+            s.onDisconnect(c, err)
+        }
+    }()
+    c.ws.SetReadLimit(s.readLimit)
+    c.touch()
+    c.ws.SetPongHandler(func(string) error {
+        c.touch()
+        return nil
+    })
+    go c.keepalive()
+    ctx := c.sess.Context()
+    if s.onConnect != nil {
+        s.onConnect(ctx, c)
+    }
+    for {
+        var (
+            typ  int
+            data []byte
+        )
+        typ, data, err = c.ws.ReadMessage()
+        if err != nil {
+            if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway, websocket.CloseNoStatusReceived) &&
+                !errors.Is(err, net.ErrClosed) && c.ctx.Err() == nil {
+                glog.Warnf("[WS] server read from %s failed: %v", c.RemoteAddr(), err)
+            }
+            return
+        }
+        c.touch()
+        if s.onMessage != nil {
+            s.onMessage(ctx, c, typ, data)
+        }
+    }
+}
+
+// remove 注销连接、移除会话并释放资源。
+func (s *Server) remove(c *Conn) {
+    s.sessions.Remove(c.sess)
+    s.mu.Lock()
+    delete(s.conns, c)
+    s.mu.Unlock()
+    c.cancel()
+    _ = c.ws.Close()
+}
+
+// Stop 停止 WebSocket 服务器。
+//
+// 拒绝新的升级请求，向全部连接发送 CloseGoingAway 关闭帧并取消连接上下文；
+// 随后等待对端确认关闭，超过停止超时仍未关闭的连接将被强制关闭。
+func (s *Server) Stop(ctx context.Context) error {
+    glog.Info("[WS] server stopping")
+    s.mu.Lock()
+    if !s.closed {
+        s.closed = true
+        close(s.done)
+    }
+    conns := make([]*Conn, 0, len(s.conns))
+    for c := range s.conns {
+        conns = append(conns, c)
+    }
+    hs, lis, cancel := s.hs, s.lis, s.cancel
+    s.mu.Unlock()
+    var err error
+    if hs != nil {
+        // 已升级的连接不受 Shutdown 影响。
+        err = hs.Shutdown(ctx)
+    } else if lis != nil {
+        if cErr := lis.Close(); cErr != nil && !errors.Is(cErr, net.ErrClosed) {
+            err = cErr
+        }
+    }
+    for _, c := range conns {
+        c.Close(websocket.CloseGoingAway, "server shutting down")
+    }
+    cancel()
+
+    done := make(chan struct{})
+    go func() {
+        s.handles.Wait()
+        close(done)
+    }()
+    killed := 0
+    select {
+    case <-done:
+    case <-ctx.Done():
+        s.mu.Lock()
+        killed = len(s.conns)
+        for c := range s.conns {
+            _ = c.ws.NetConn().Close()
+        }
+        s.mu.Unlock()
+    }
+    s.drained.Store(int64(len(conns) - killed))
+    s.killed.Store(int64(killed))
+    if len(conns) > 0 {
+        glog.Infof("[WS] server stopped: %d connections drained, %d killed", len(conns)-killed, killed)
+    }
+    return err
+}
+
+// Get 按连接 ID 查找本服务器的连接。
+func (s *Server) Get(id uint64) (*Conn, bool) {
+    sess, ok := s.sessions.Get(id)
+    if !ok {
+        return nil, false
+    }
+    return s.conn(sess)
+}
+
+// GetByUID 按绑定的用户 ID 查找本服务器的连接。
+func (s *Server) GetByUID(uid string) (*Conn, bool) {
+    sess, ok := s.sessions.GetByUID(uid)
+    if !ok {
+        return nil, false
+    }
+    return s.conn(sess)
+}
+
+// conn 返回会话对应的本服务器连接，共享会话管理器时其他服务器的会话返回 false。
+func (s *Server) conn(sess *gtcp.Session) (*Conn, bool) {
+    c, ok := FromContext(sess.Context())
+    if !ok || c.s != s {
+        return nil, false
+    }
+    return c, true
+}
+
+// Count 返回当前连接数。
+func (s *Server) Count() int {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return len(s.conns)
+}
+
+// GroupCount 返回分组内的会话数，共享会话管理器时包含其他服务器的会话。
+func (s *Server) GroupCount(group string) int {
+    return s.sessions.GroupCount(group)
+}
+
+// Range 遍历全部连接，fn 返回 false 时停止遍历。
+func (s *Server) Range(fn func(c *Conn) bool) {
+    for _, c := range s.snapshot() {
+        if !fn(c) {
+            return
+        }
+    }
+}
+
+// Broadcast 向分组内全部会话发送消息，返回成功入队的会话数，共享会话管理器时包含其他服务器的会话。
+func (s *Server) Broadcast(group string, messageType int, data []byte) int {
+    return s.sessions.BroadcastMessage(group, messageType, data)
+}
+
+// BroadcastAll 向本服务器的全部连接发送消息，返回成功入队的连接数。
+func (s *Server) BroadcastAll(messageType int, data []byte) int {
+    n := 0
+    for _, c := range s.snapshot() {
+        if c.Send(messageType, data) == nil {
+            n++
+        }
+    }
+    return n
+}
+
+// snapshot 返回全部连接的快照，广播时不持有锁。
+func (s *Server) snapshot() []*Conn {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    list := make([]*Conn, 0, len(s.conns))
+    for c := range s.conns {
+        list = append(list, c)
+    }
+    return list
+}
+
+// Stats WebSocket 服务器连接统计。
+type Stats struct {
+    Active   int   // 当前活动连接数。
+    Accepted int64 // 累计接受的连接数。
+    Drained  int64 // 停止时正常关闭的连接数。
+    Killed   int64 // 停止时被强制关闭的连接数。
+}
+
+// Stats 返回连接统计。
+func (s *Server) Stats() Stats {
+    return Stats{
+        Active:   s.Count(),
+        Accepted: s.accepted.Load(),
+        Drained:  s.drained.Load(),
+        Killed:   s.killed.Load(),
+    }
+}
+
+// ListenAddr 返回实际监听地址，未监听时返回 nil。
+func (s *Server) ListenAddr() net.Addr {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if s.lis == nil {
+        return nil
+    }
+    return s.lis.Addr()
+}
@@ -0,0 +1,201 @@
+package gws
+
+import (
+    "context"
+    "net/http"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/gorilla/websocket"
+
+    "github.com/camry/dove/v2/server/ghttp"
+    "github.com/camry/dove/v2/server/gtcp"
+)
+
+// dial 连接 WebSocket 服务器。
+func dial(t *testing.T, url string) *websocket.Conn {
+    c, _, err := websocket.DefaultDialer.Dial(url, nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+    t.Cleanup(func() { _ = c.Close() })
+    return c
+}
+
+// expectMessage 读取一条文本消息并校验内容。
+func expectMessage(t *testing.T, c *websocket.Conn, want string) {
+    _ = c.SetReadDeadline(time.Now().Add(time.Second))
+    _, data, err := c.ReadMessage()
+    if err != nil || string(data) != want {
+        t.Fatalf("expect %q, got %q %v", want, data, err)
+    }
+}
+
+func TestServer_Broadcast(t *testing.T) {
+    s := NewServer(Address("127.0.0.1:0"), Path("/ws"), MessageHandler(func(ctx context.Context, conn *Conn, messageType int, data []byte) {
+        c, _ := FromContext(ctx)
+        c.Bind(string(data))
+        c.Join("room")
+        _ = c.SendText("joined")
+    }))
+    if err := s.Listen(context.Background()); err != nil {
+        t.Fatal(err)
+    }
+    go func() { _ = s.Start(context.Background()) }()
+    url := "ws://" + s.ListenAddr().String() + "/ws"
+    clients := make(map[string]*websocket.Conn)
+    for _, uid := range []string{"u1", "u2"} {
+        c := dial(t, url)
+        _ = c.WriteMessage(websocket.TextMessage, []byte(uid))
+        expectMessage(t, c, "joined")
+        clients[uid] = c
+    }
+    if n := s.Broadcast("room", TextMessage, []byte("hello")); n != 2 {
+        t.Fatalf("expect broadcast to 2 connections, got %d", n)
+    }
+    for _, c := range clients {
+        expectMessage(t, c, "hello")
+    }
+    if c, ok := s.GetByUID("u1"); !ok {
+        t.Fatal("expect connection u1 found")
+    } else {
+        _ = c.SendText("private")
+    }
+    expectMessage(t, clients["u1"], "private")
+
+    // 停止时发送 CloseGoingAway 关闭帧，客户端确认后连接排空。
+    for _, c := range clients {
+        go func() {
+            for {
+                if _, _, err := c.ReadMessage(); err != nil {
+                    if !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+                        t.Errorf("expect going away, got %v", err)
+                    }
+                    return
+                }
+            }
+        }()
+    }
+    ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+    defer cancel()
+    if err := s.Stop(ctx); err != nil {
+        t.Fatal(err)
+    }
+    if st := s.Stats(); st.Drained != 2 || st.Killed != 0 || st.Active != 0 {
+        t.Fatalf("expect 2 drained, got %+v", st)
+    }
+}
+
+func TestServer_Mount(t *testing.T) {
+    ws := NewServer(OnConnect(func(ctx context.Context, conn *Conn) {
+        _ = conn.SendText("hi " + conn.Request().URL.Query().Get("name"))
+    }))
+    mux := http.NewServeMux()
+    mux.Handle("/ws", ws)
+    hs := ghttp.NewServer(ghttp.Address("127.0.0.1:0"), ghttp.Handler(mux))
+    if err := hs.Listen(context.Background()); err != nil {
+        t.Fatal(err)
+    }
+    go func() { _ = hs.Start(context.Background()) }()
+    go func() { _ = ws.Start(context.Background()) }()
+    defer func() { _ = hs.Stop(context.Background()) }()
+    defer func() { _ = ws.Stop(context.Background()) }()
+    c := dial(t, "ws://"+hs.ListenAddr().String()+"/ws?name=dove")
+    expectMessage(t, c, "hi dove")
+    // 客户端读取时自动回复关闭帧。
+    go func() {
+        for {
+            if _, _, err := c.ReadMessage(); err != nil {
+                return
+            }
+        }
+    }()
+}
+
+func TestServer_PongTimeout(t *testing.T) {
+    closed := make(chan error, 1)
+    s := NewServer(Address("127.0.0.1:0"), PingInterval(0), PongTimeout(50*time.Millisecond), OnDisconnect(func(conn *Conn, err error) {
+        closed <- err
+    }))
+    if err := s.Listen(context.Background()); err != nil {
+        t.Fatal(err)
+    }
+    go func() { _ = s.Start(context.Background()) }()
+    defer func() { _ = s.Stop(context.Background()) }()
+    dial(t, "ws://"+s.ListenAddr().String())
+    select {
+    case err := <-closed:
+        if err == nil || !strings.Contains(err.Error(), "timeout") {
+            t.Fatalf("expect timeout, got %v", err)
+        }
+    case <-time.After(time.Second):
+        t.Fatal("expect connection closed by pong timeout")
+    }
+}
+
+func TestServer_PingInterval(t *testing.T) {
+    s := NewServer(Address("127.0.0.1:0"), PingInterval(20*time.Millisecond))
+    if err := s.Listen(context.Background()); err != nil {
+        t.Fatal(err)
+    }
+    go func() { _ = s.Start(context.Background()) }()
+    defer func() { _ = s.Stop(context.Background()) }()
+    c := dial(t, "ws://"+s.ListenAddr().String())
+    pinged := make(chan struct{}, 1)
+    c.SetPingHandler(func(string) error {
+        select {
+        case pinged <- struct{}{}:
+        default:
+        }
+        return nil
+    })
+    // 控制帧在读取时处理。
+    go func() {
+        for {
+            if _, _, err := c.ReadMessage(); err != nil {
+                return
+            }
+        }
+    }()
+    select {
+    case <-pinged:
+    case <-time.After(time.Second):
+        t.Fatal("expect ping from server")
+    }
+}
+
+func TestServer_Sessions(t *testing.T) {
+    m := gtcp.NewSessionManager()
+    s := NewServer(Address("127.0.0.1:0"), Sessions(m), MessageHandler(func(ctx context.Context, conn *Conn, messageType int, data []byte) {
+        sess, _ := gtcp.SessionFromContext(ctx)
+        sess.Bind(string(data))
+        sess.Join("room")
+        _ = conn.SendText("joined")
+    }))
+    if err := s.Listen(context.Background()); err != nil {
+        t.Fatal(err)
+    }
+    go func() { _ = s.Start(context.Background()) }()
+    defer func() { _ = s.Stop(context.Background()) }()
+    c := dial(t, "ws://"+s.ListenAddr().String())
+    _ = c.WriteMessage(websocket.TextMessage, []byte("u1"))
+    expectMessage(t, c, "joined")
+    if conn, ok := s.GetByUID("u1"); !ok || conn.UID() != "u1" {
+        t.Fatal("expect connection u1 found")
+    }
+    // 通过共享的会话管理器广播，未指定类型的消息按二进制消息写出。
+    if n := m.Broadcast("room", []byte("hello")); n != 1 {
+        t.Fatalf("expect broadcast to 1 session, got %d", n)
+    }
+    _ = c.SetReadDeadline(time.Now().Add(time.Second))
+    if typ, data, err := c.ReadMessage(); err != nil || typ != websocket.BinaryMessage || string(data) != "hello" {
+        t.Fatalf("expect binary hello, got %d %q %v", typ, data, err)
+    }
+    if !m.Kick("u1", nil) {
+        t.Fatal("expect session u1 kicked")
+    }
+    if _, _, err := c.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+        t.Fatalf("expect normal closure, got %v", err)
+    }
+}
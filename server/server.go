@@ -7,3 +7,11 @@ type Server interface {
     Start(context.Context) error
     Stop(context.Context) error
 }
+
+// Listener 定义可选的监听接口。
+//
+// 实现该接口的服务器由 App.Run 在 BeforeStart 之前统一绑定端口，
+// 端口冲突等错误将在启动任何服务器之前立即返回。
+type Listener interface {
+    Listen(context.Context) error
+}
@@ -0,0 +1,134 @@
+package tlsutil
+
+import (
+    "context"
+    "os"
+    "sync"
+    "time"
+
+    "github.com/camry/g/v2/glog"
+)
+
+// Option 定义一个证书重载选项类型。
+type Option func(o *option)
+
+// option 证书重载选项实体对象。
+type option struct {
+    interval time.Duration
+    onReload func(err error)
+}
+
+// Interval 配置文件变更检测间隔。
+func Interval(d time.Duration) Option {
+    return func(o *option) { o.interval = d }
+}
+
+// OnReload 配置重载回调，err 为 nil 表示重载成功。
+func OnReload(fn func(err error)) Option {
+    return func(o *option) { o.onReload = fn }
+}
+
+// fileStat 文件变更标识。
+type fileStat struct {
+    modTime time.Time
+    size    int64
+}
+
+// watcher 基于轮询的文件监视器。
+type watcher struct {
+    name  string
+    files []string
+    opt   option
+    load  func() error
+    stats []fileStat
+
+    done     chan struct{}
+    stopOnce sync.Once
+}
+
+func newWatcher(name string, load func() error, files []string, opts ...Option) *watcher {
+    o := option{
+        interval: 10 * time.Second,
+    }
+    for _, opt := range opts {
+        opt(&o)
+    }
+    w := &watcher{
+        name:  name,
+        files: files,
+        opt:   o,
+        load:  load,
+        done:  make(chan struct{}),
+    }
+    w.stats, _ = w.stat()
+    return w
+}
+
+// stat 读取所有文件的变更标识。
+func (w *watcher) stat() ([]fileStat, error) {
+    stats := make([]fileStat, len(w.files))
+    for i, f := range w.files {
+        fi, err := os.Stat(f)
+        if err != nil {
+            return nil, err
+        }
+        stats[i] = fileStat{modTime: fi.ModTime(), size: fi.Size()}
+    }
+    return stats, nil
+}
+
+// check 检测文件变更并在变更时重新加载。
+func (w *watcher) check() {
+    stats, err := w.stat()
+    if err != nil {
+        glog.Warnf("[TLS] %s stat failed: %v", w.name, err)
+        return
+    }
+    changed := false
+    for i := range stats {
+        if i >= len(w.stats) || stats[i] != w.stats[i] {
+            changed = true
+            break
+        }
+    }
+    if !changed {
+        return
+    }
+    err = w.load()
+    if err != nil {
+        glog.Errorf("[TLS] %s reload failed: %v", w.name, err)
+    } else {
+        w.stats = stats
+        glog.Infof("[TLS] %s reloaded", w.name)
+    }
+    if w.opt.onReload != nil {
+        w.opt.onReload(err)
+    }
+}
+
+// run 轮询文件变更，直到上下文结束或调用 stop。
+func (w *watcher) run(ctx context.Context) error {
+    if w.opt.interval <= 0 {
+        select {
+        case <-ctx.Done():
+        case <-w.done:
+        }
+        return nil
+    }
+    ticker := time.NewTicker(w.opt.interval)
+    defer ticker.Stop()
+    for {
+        select {
+        case <-ctx.Done():
+            return nil
+        case <-w.done:
+            return nil
+        case <-ticker.C:
+            w.check()
+        }
+    }
+}
+
+func (w *watcher) stop() {
+    w.stopOnce.Do(func() { close(w.done) })
+}
@@ -0,0 +1,76 @@
+package tlsutil
+
+import (
+    "net/http"
+    "time"
+
+    "golang.org/x/crypto/acme"
+    "golang.org/x/crypto/acme/autocert"
+)
+
+// Cache 定义 ACME 证书缓存接口。
+type Cache = autocert.Cache
+
+// DirCache 基于本地目录的 ACME 证书缓存。
+type DirCache = autocert.DirCache
+
+// ErrCacheMiss 缓存未命中错误。
+var ErrCacheMiss = autocert.ErrCacheMiss
+
+// ACMEOption 定义一个 ACME 选项类型。
+type ACMEOption func(a *ACME)
+
+// ACMEHosts 配置允许签发证书的域名白名单。
+func ACMEHosts(hosts ...string) ACMEOption {
+    return func(a *ACME) { a.HostPolicy = autocert.HostWhitelist(hosts...) }
+}
+
+// ACMEEmail 配置 ACME 账户联系邮箱。
+func ACMEEmail(email string) ACMEOption {
+    return func(a *ACME) { a.Email = email }
+}
+
+// ACMECache 配置证书缓存。
+func ACMECache(c Cache) ACMEOption {
+    return func(a *ACME) { a.Cache = c }
+}
+
+// ACMEDirectoryURL 配置 ACME 目录地址，默认为 Let's Encrypt 生产环境。
+//
+// 测试时可指向 pebble 等本地 ACME 服务，如 https://127.0.0.1:14000/dir。
+func ACMEDirectoryURL(url string) ACMEOption {
+    return func(a *ACME) { a.Client.DirectoryURL = url }
+}
+
+// ACMEHTTPClient 配置访问 ACME 服务的 HTTP 客户端，用于信任本地 ACME 服务的根证书。
+func ACMEHTTPClient(c *http.Client) ACMEOption {
+    return func(a *ACME) { a.Client.HTTPClient = c }
+}
+
+// ACMERenewBefore 配置证书到期前多久续签。
+func ACMERenewBefore(d time.Duration) ACMEOption {
+    return func(a *ACME) { a.RenewBefore = d }
+}
+
+// ACME 基于 autocert 的自动证书管理器。
+//
+// 通过 TLSConfig 获取支持 tls-alpn-01 验证的 TLS 配置，通过 HTTPHandler 处理 http-01 验证请求。
+type ACME struct {
+    *autocert.Manager
+}
+
+// NewACME 新建 ACME 自动证书管理器，默认同意服务条款。
+func NewACME(opts ...ACMEOption) *ACME {
+    a := &ACME{
+        Manager: &autocert.Manager{
+            Prompt: autocert.AcceptTOS,
+            Client: &acme.Client{
+                DirectoryURL: autocert.DefaultACMEDirectory,
+            },
+        },
+    }
+    for _, opt := range opts {
+        opt(a)
+    }
+    return a
+}
@@ -0,0 +1,196 @@
+package tlsutil
+
+import (
+    "context"
+    "crypto/tls"
+    "crypto/x509"
+    "errors"
+    "fmt"
+    "os"
+    "sync/atomic"
+
+    "github.com/camry/g/v2/glog"
+
+    "github.com/camry/dove/v2/server"
+)
+
+var (
+    _ server.Server = (*CertReloader)(nil)
+    _ server.Server = (*CAReloader)(nil)
+)
+
+// CertReloader 证书文件重载器，通过 GetCertificate 热替换证书。
+//
+// CertReloader 实现了 server.Server 接口，注册到 dove.Server() 中即可随应用启停监视文件变更。
+type CertReloader struct {
+    certFile string
+    keyFile  string
+    cert     atomic.Pointer[tls.Certificate]
+    w        *watcher
+}
+
+// NewCertReloader 新建证书文件重载器，并立即加载一次证书。
+func NewCertReloader(certFile, keyFile string, opts ...Option) (*CertReloader, error) {
+    r := &CertReloader{
+        certFile: certFile,
+        keyFile:  keyFile,
+    }
+    if err := r.Reload(); err != nil {
+        return nil, err
+    }
+    r.w = newWatcher("certificate "+certFile, r.Reload, []string{certFile, keyFile}, opts...)
+    return r, nil
+}
+
+// Reload 重新加载证书文件。
+func (r *CertReloader) Reload() error {
+    cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+    if err != nil {
+        return fmt.Errorf("tlsutil: load key pair %s failed: %w", r.certFile, err)
+    }
+    r.cert.Store(&cert)
+    return nil
+}
+
+// Certificate 返回当前证书。
+func (r *CertReloader) Certificate() *tls.Certificate {
+    return r.cert.Load()
+}
+
+// GetCertificate 实现 tls.Config.GetCertificate。
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+    return r.cert.Load(), nil
+}
+
+// GetClientCertificate 实现 tls.Config.GetClientCertificate。
+func (r *CertReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+    return r.cert.Load(), nil
+}
+
+// TLSConfig 返回使用当前重载器的服务端 TLS 配置。
+func (r *CertReloader) TLSConfig() *tls.Config {
+    return &tls.Config{
+        MinVersion:     tls.VersionTLS12,
+        GetCertificate: r.GetCertificate,
+    }
+}
+
+// Start 启动证书文件监视。
+func (r *CertReloader) Start(ctx context.Context) error {
+    glog.Infof("[TLS] watching certificate %s", r.certFile)
+    return r.w.run(ctx)
+}
+
+// Stop 停止证书文件监视。
+func (r *CertReloader) Stop(context.Context) error {
+    r.w.stop()
+    return nil
+}
+
+// CAReloader CA 证书包文件重载器。
+type CAReloader struct {
+    caFile string
+    pool   atomic.Pointer[x509.CertPool]
+    w      *watcher
+}
+
+// NewCAReloader 新建 CA 证书包重载器，并立即加载一次证书包。
+func NewCAReloader(caFile string, opts ...Option) (*CAReloader, error) {
+    r := &CAReloader{caFile: caFile}
+    if err := r.Reload(); err != nil {
+        return nil, err
+    }
+    r.w = newWatcher("CA bundle "+caFile, r.Reload, []string{caFile}, opts...)
+    return r, nil
+}
+
+// Reload 重新加载 CA 证书包。
+func (r *CAReloader) Reload() error {
+    data, err := os.ReadFile(r.caFile)
+    if err != nil {
+        return fmt.Errorf("tlsutil: read CA bundle %s failed: %w", r.caFile, err)
+    }
+    pool := x509.NewCertPool()
+    if !pool.AppendCertsFromPEM(data) {
+        return errors.New("tlsutil: no valid certificate found in " + r.caFile)
+    }
+    r.pool.Store(pool)
+    return nil
+}
+
+// Pool 返回当前 CA 证书池。
+func (r *CAReloader) Pool() *x509.CertPool {
+    return r.pool.Load()
+}
+
+// Start 启动 CA 证书包文件监视。
+func (r *CAReloader) Start(ctx context.Context) error {
+    glog.Infof("[TLS] watching CA bundle %s", r.caFile)
+    return r.w.run(ctx)
+}
+
+// Stop 停止 CA 证书包文件监视。
+func (r *CAReloader) Stop(context.Context) error {
+    r.w.stop()
+    return nil
+}
+
+// MutualTLSConfig 返回双向 TLS 服务端配置，每次握手使用最新的证书与 CA 证书包校验客户端证书。
+//
+// 客户端证书在 VerifyConnection 中按当前 CA 证书池校验，不使用 GetConfigForClient 替换配置，
+// 因此 http.Server 等对配置追加的 NextProtos（如 h2）在握手时依然生效。
+// 由于 ClientCAs 为空，证书请求中不携带可接受的 CA 列表，客户端需明确配置客户端证书。
+// 校验结果不会写入 tls.ConnectionState.VerifiedChains，使用 auth.MTLS 认证时需配置 auth.ClientCAs(ca.Pool)。
+func MutualTLSConfig(cert *CertReloader, ca *CAReloader) *tls.Config {
+    return &tls.Config{
+        MinVersion:     tls.VersionTLS12,
+        GetCertificate: cert.GetCertificate,
+        ClientAuth:     tls.RequireAnyClientCert,
+        VerifyConnection: func(cs tls.ConnectionState) error {
+            return verifyPeer(cs, ca.Pool(), "", x509.ExtKeyUsageClientAuth)
+        },
+    }
+}
+
+// ClientTLSConfig 返回客户端 TLS 配置，使用 cert 作为客户端证书，ca 校验服务端证书。
+//
+// cert 或 ca 为 nil 时忽略对应配置。ca 不为 nil 时关闭内置校验，改为在 VerifyConnection 中
+// 按当前 CA 证书池与 ServerName 校验服务端证书，CA 证书包重载后新建的连接即使用新证书池；
+// ServerName 为空时握手失败，tls.Dial 与 http.Transport 会按拨号地址自动填充。
+func ClientTLSConfig(cert *CertReloader, ca *CAReloader) *tls.Config {
+    c := &tls.Config{
+        MinVersion: tls.VersionTLS12,
+    }
+    if cert != nil {
+        c.GetClientCertificate = cert.GetClientCertificate
+    }
+    if ca != nil {
+        c.InsecureSkipVerify = true
+        c.VerifyConnection = func(cs tls.ConnectionState) error {
+            // 未设置 ServerName 时无法校验主机名，拒绝握手。
+            if cs.ServerName == "" {
+                return errors.New("tlsutil: server name is required to verify server certificate")
+            }
+            return verifyPeer(cs, ca.Pool(), cs.ServerName, x509.ExtKeyUsageServerAuth)
+        }
+    }
+    return c
+}
+
+// verifyPeer 按 CA 证书池校验对端证书链，dnsName 不为空时同时校验主机名。
+func verifyPeer(cs tls.ConnectionState, roots *x509.CertPool, dnsName string, usage x509.ExtKeyUsage) error {
+    if len(cs.PeerCertificates) == 0 {
+        return errors.New("tlsutil: no peer certificate")
+    }
+    opts := x509.VerifyOptions{
+        Roots:         roots,
+        DNSName:       dnsName,
+        Intermediates: x509.NewCertPool(),
+        KeyUsages:     []x509.ExtKeyUsage{usage},
+    }
+    for _, c := range cs.PeerCertificates[1:] {
+        opts.Intermediates.AddCert(c)
+    }
+    _, err := cs.PeerCertificates[0].Verify(opts)
+    return err
+}
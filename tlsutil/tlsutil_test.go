@@ -0,0 +1,569 @@
+package tlsutil
+
+import (
+    "bytes"
+    "context"
+    "crypto/ecdsa"
+    "crypto/elliptic"
+    "crypto/rand"
+    "crypto/tls"
+    "crypto/x509"
+    "crypto/x509/pkix"
+    "encoding/base64"
+    "encoding/json"
+    "encoding/pem"
+    "fmt"
+    "io"
+    "math/big"
+    "net"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "path/filepath"
+    "sync"
+    "testing"
+    "time"
+)
+
+// newCert 生成自签名证书，返回证书与私钥的 PEM 编码。
+func newCert(t *testing.T, cn string, serial int64, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (certPEM, keyPEM []byte, cert *x509.Certificate, key *ecdsa.PrivateKey) {
+    t.Helper()
+    key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    if err != nil {
+        t.Fatal(err)
+    }
+    tpl := &x509.Certificate{
+        SerialNumber:          big.NewInt(serial),
+        Subject:               pkix.Name{CommonName: cn},
+        DNSNames:              []string{cn},
+        IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+        NotBefore:             time.Now().Add(-time.Hour),
+        NotAfter:              time.Now().Add(24 * time.Hour),
+        KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+        ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+        BasicConstraintsValid: true,
+        IsCA:                  parent == nil,
+    }
+    if parent == nil {
+        parent, parentKey = tpl, key
+    }
+    der, err := x509.CreateCertificate(rand.Reader, tpl, parent, &key.PublicKey, parentKey)
+    if err != nil {
+        t.Fatal(err)
+    }
+    cert, _ = x509.ParseCertificate(der)
+    keyDER, err := x509.MarshalECPrivateKey(key)
+    if err != nil {
+        t.Fatal(err)
+    }
+    certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+    keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+    return
+}
+
+func writeFile(t *testing.T, name string, data []byte) {
+    t.Helper()
+    if err := os.WriteFile(name, data, 0o600); err != nil {
+        t.Fatal(err)
+    }
+    // 确保修改时间变化可被检测。
+    mt := time.Now().Add(time.Duration(len(data)) * time.Millisecond)
+    _ = os.Chtimes(name, mt, mt)
+}
+
+func TestCertReloader(t *testing.T) {
+    dir := t.TempDir()
+    certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+    c1, k1, _, _ := newCert(t, "localhost", 1, nil, nil)
+    writeFile(t, certFile, c1)
+    writeFile(t, keyFile, k1)
+
+    var mu sync.Mutex
+    reloaded := make(chan error, 1)
+    r, err := NewCertReloader(certFile, keyFile, Interval(10*time.Millisecond), OnReload(func(err error) {
+        mu.Lock()
+        defer mu.Unlock()
+        select {
+        case reloaded <- err:
+        default:
+        }
+    }))
+    if err != nil {
+        t.Fatal(err)
+    }
+    go func() { _ = r.Start(context.Background()) }()
+    defer r.Stop(context.Background())
+
+    got, _ := r.GetCertificate(nil)
+    if leaf, _ := x509.ParseCertificate(got.Certificate[0]); leaf.SerialNumber.Int64() != 1 {
+        t.Fatalf("expect serial 1, got %v", leaf.SerialNumber)
+    }
+
+    c2, k2, _, _ := newCert(t, "localhost", 2, nil, nil)
+    writeFile(t, certFile, c2)
+    writeFile(t, keyFile, k2)
+    select {
+    case err = <-reloaded:
+        if err != nil {
+            t.Fatal(err)
+        }
+    case <-time.After(2 * time.Second):
+        t.Fatal("certificate is not reloaded")
+    }
+    got, _ = r.GetCertificate(nil)
+    if leaf, _ := x509.ParseCertificate(got.Certificate[0]); leaf.SerialNumber.Int64() != 2 {
+        t.Fatalf("expect serial 2, got %v", leaf.SerialNumber)
+    }
+}
+
+func TestMutualTLSConfig(t *testing.T) {
+    dir := t.TempDir()
+    caPEM, _, ca, caKey := newCert(t, "ca", 1, nil, nil)
+    srvPEM, srvKey, _, _ := newCert(t, "localhost", 2, ca, caKey)
+    cliPEM, cliKey, _, _ := newCert(t, "client", 3, ca, caKey)
+    files := map[string][]byte{
+        "ca.crt": caPEM, "srv.crt": srvPEM, "srv.key": srvKey, "cli.crt": cliPEM, "cli.key": cliKey,
+    }
+    for name, data := range files {
+        writeFile(t, filepath.Join(dir, name), data)
+    }
+    srvCert, err := NewCertReloader(filepath.Join(dir, "srv.crt"), filepath.Join(dir, "srv.key"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    cliCert, err := NewCertReloader(filepath.Join(dir, "cli.crt"), filepath.Join(dir, "cli.key"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    caBundle, err := NewCAReloader(filepath.Join(dir, "ca.crt"))
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    lis, err := tls.Listen("tcp", "127.0.0.1:0", MutualTLSConfig(srvCert, caBundle))
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer lis.Close()
+    go func() {
+        for {
+            conn, err := lis.Accept()
+            if err != nil {
+                return
+            }
+            _ = conn.(*tls.Conn).Handshake()
+            _, _ = conn.Write([]byte("ok"))
+            _ = conn.Close()
+        }
+    }()
+
+    cc := ClientTLSConfig(cliCert, caBundle)
+    cc.ServerName = "localhost"
+    conn, err := tls.Dial("tcp", lis.Addr().String(), cc)
+    if err != nil {
+        t.Fatal(err)
+    }
+    buf := make([]byte, 2)
+    if _, err = conn.Read(buf); err != nil || !bytes.Equal(buf, []byte("ok")) {
+        t.Fatalf("expect ok, got %q, err %v", buf, err)
+    }
+    _ = conn.Close()
+
+    // 不带客户端证书的连接应被拒绝。
+    cc = ClientTLSConfig(nil, caBundle)
+    cc.ServerName = "localhost"
+    if conn, err = tls.Dial("tcp", lis.Addr().String(), cc); err == nil {
+        _, err = conn.Read(buf)
+        _ = conn.Close()
+    }
+    if err == nil {
+        t.Fatal("expect handshake error without client certificate")
+    }
+
+    // 未设置 ServerName 时无法校验主机名，客户端应拒绝握手。
+    nc, err := net.Dial("tcp", lis.Addr().String())
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer nc.Close()
+    if err = tls.Client(nc, ClientTLSConfig(cliCert, caBundle)).Handshake(); err == nil {
+        t.Fatal("expect handshake error without server name")
+    }
+}
+
+func TestMutualTLSConfig_HTTP2(t *testing.T) {
+    dir := t.TempDir()
+    caPEM, _, ca, caKey := newCert(t, "ca", 1, nil, nil)
+    srvPEM, srvKey, _, _ := newCert(t, "localhost", 2, ca, caKey)
+    cliPEM, cliKey, _, _ := newCert(t, "client", 3, ca, caKey)
+    files := map[string][]byte{
+        "ca.crt": caPEM, "srv.crt": srvPEM, "srv.key": srvKey, "cli.crt": cliPEM, "cli.key": cliKey,
+    }
+    for name, data := range files {
+        writeFile(t, filepath.Join(dir, name), data)
+    }
+    srvCert, err := NewCertReloader(filepath.Join(dir, "srv.crt"), filepath.Join(dir, "srv.key"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    cliCert, err := NewCertReloader(filepath.Join(dir, "cli.crt"), filepath.Join(dir, "cli.key"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    caBundle, err := NewCAReloader(filepath.Join(dir, "ca.crt"))
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    lis, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatal(err)
+    }
+    hs := &http.Server{
+        TLSConfig: MutualTLSConfig(srvCert, caBundle),
+        Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            _, _ = w.Write([]byte(r.Proto))
+        }),
+    }
+    go func() { _ = hs.ServeTLS(lis, "", "") }()
+    defer hs.Close()
+
+    cc := ClientTLSConfig(cliCert, caBundle)
+    cc.ServerName = "localhost"
+    hc := &http.Client{Transport: &http.Transport{TLSClientConfig: cc, ForceAttemptHTTP2: true}}
+    defer hc.CloseIdleConnections()
+    resp, err := hc.Get("https://" + lis.Addr().String())
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer resp.Body.Close()
+    if resp.ProtoMajor != 2 {
+        t.Fatalf("expect HTTP/2, got %s", resp.Proto)
+    }
+}
+
+func TestMutualTLSConfig_RotateCA(t *testing.T) {
+    dir := t.TempDir()
+    ca1PEM, _, ca1, ca1Key := newCert(t, "ca1", 1, nil, nil)
+    ca2PEM, _, ca2, ca2Key := newCert(t, "ca2", 2, nil, nil)
+    srvPEM, srvKey, _, _ := newCert(t, "localhost", 3, ca1, ca1Key)
+    cliPEM, cliKey, _, _ := newCert(t, "client", 4, ca2, ca2Key)
+    files := map[string][]byte{
+        "ca.crt": ca1PEM, "client-ca.crt": ca1PEM,
+        "srv.crt": srvPEM, "srv.key": srvKey, "cli.crt": cliPEM, "cli.key": cliKey,
+    }
+    for name, data := range files {
+        writeFile(t, filepath.Join(dir, name), data)
+    }
+    srvCert, err := NewCertReloader(filepath.Join(dir, "srv.crt"), filepath.Join(dir, "srv.key"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    cliCert, err := NewCertReloader(filepath.Join(dir, "cli.crt"), filepath.Join(dir, "cli.key"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    srvCA, err := NewCAReloader(filepath.Join(dir, "ca.crt"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    reloaded := make(chan error, 1)
+    clientCA, err := NewCAReloader(filepath.Join(dir, "client-ca.crt"), Interval(10*time.Millisecond), OnReload(func(err error) {
+        select {
+        case reloaded <- err:
+        default:
+        }
+    }))
+    if err != nil {
+        t.Fatal(err)
+    }
+    go func() { _ = clientCA.Start(context.Background()) }()
+    defer clientCA.Stop(context.Background())
+
+    lis, err := tls.Listen("tcp", "127.0.0.1:0", MutualTLSConfig(srvCert, clientCA))
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer lis.Close()
+    go func() {
+        for {
+            conn, err := lis.Accept()
+            if err != nil {
+                return
+            }
+            if conn.(*tls.Conn).Handshake() == nil {
+                _, _ = conn.Write([]byte("ok"))
+            }
+            _ = conn.Close()
+        }
+    }()
+    dial := func() error {
+        cc := ClientTLSConfig(cliCert, srvCA)
+        cc.ServerName = "localhost"
+        conn, err := tls.Dial("tcp", lis.Addr().String(), cc)
+        if err != nil {
+            return err
+        }
+        defer conn.Close()
+        buf := make([]byte, 2)
+        _, err = io.ReadFull(conn, buf)
+        return err
+    }
+
+    // 客户端证书由 ca2 签发，服务端仅信任 ca1 时应被拒绝。
+    if err = dial(); err == nil {
+        t.Fatal("expect client certificate rejected before CA rotation")
+    }
+    writeFile(t, filepath.Join(dir, "client-ca.crt"), ca2PEM)
+    select {
+    case err = <-reloaded:
+        if err != nil {
+            t.Fatal(err)
+        }
+    case <-time.After(2 * time.Second):
+        t.Fatal("CA bundle is not reloaded")
+    }
+    if err = dial(); err != nil {
+        t.Fatalf("expect handshake ok after CA rotation, got %v", err)
+    }
+
+    // 服务端证书的签发 CA 被替换后，客户端应拒绝服务端证书。
+    writeFile(t, filepath.Join(dir, "ca.crt"), ca2PEM)
+    if err = srvCA.Reload(); err != nil {
+        t.Fatal(err)
+    }
+    if err = dial(); err == nil {
+        t.Fatal("expect server certificate rejected after client CA rotation")
+    }
+}
+
+type memCache struct {
+    sync.Mutex
+    m map[string][]byte
+}
+
+func (c *memCache) Get(_ context.Context, key string) ([]byte, error) {
+    c.Lock()
+    defer c.Unlock()
+    if v, ok := c.m[key]; ok {
+        return v, nil
+    }
+    return nil, ErrCacheMiss
+}
+
+func (c *memCache) Put(_ context.Context, key string, data []byte) error {
+    c.Lock()
+    defer c.Unlock()
+    c.m[key] = data
+    return nil
+}
+
+func (c *memCache) Delete(_ context.Context, key string) error {
+    c.Lock()
+    defer c.Unlock()
+    delete(c.m, key)
+    return nil
+}
+
+func TestACMECache(t *testing.T) {
+    certPEM, keyPEM, _, _ := newCert(t, "example.com", 1, nil, nil)
+    cache := &memCache{m: map[string][]byte{
+        "example.com": append(keyPEM, certPEM...),
+    }}
+    a := NewACME(
+        ACMEHosts("example.com"),
+        ACMECache(cache),
+        ACMEDirectoryURL("https://127.0.0.1:14000/dir"),
+        ACMERenewBefore(time.Hour),
+    )
+    if a.Client.DirectoryURL != "https://127.0.0.1:14000/dir" {
+        t.Fatalf("unexpected directory url %s", a.Client.DirectoryURL)
+    }
+    cert, err := a.GetCertificate(&tls.ClientHelloInfo{
+        ServerName:   "example.com",
+        CipherSuites: []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256},
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+    if cert.Leaf == nil || cert.Leaf.Subject.CommonName != "example.com" {
+        t.Fatalf("unexpected certificate %+v", cert.Leaf)
+    }
+    if _, err = a.GetCertificate(&tls.ClientHelloInfo{ServerName: "other.com"}); err == nil {
+        t.Fatal("expect host policy error")
+    }
+}
+
+// fakeACME 最小化的 ACME 服务，通过回调校验 tls-alpn-01 挑战并使用测试 CA 签发证书。
+type fakeACME struct {
+    t        *testing.T
+    ca       *x509.Certificate
+    caKey    *ecdsa.PrivateKey
+    validate func(domain string) error
+
+    mu     sync.Mutex
+    domain string
+    authz  string
+    cert   []byte
+}
+
+func (f *fakeACME) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    w.Header().Set("Replay-Nonce", fmt.Sprintf("nonce-%d", time.Now().UnixNano()))
+    base := "http://" + r.Host
+    var req struct {
+        Payload string `json:"payload"`
+    }
+    if r.Method == http.MethodPost {
+        _ = json.NewDecoder(r.Body).Decode(&req)
+    }
+    payload, _ := base64.RawURLEncoding.DecodeString(req.Payload)
+    reply := func(code int, v any) {
+        w.Header().Set("Content-Type", "application/json")
+        w.WriteHeader(code)
+        _ = json.NewEncoder(w).Encode(v)
+    }
+    order := func() map[string]any {
+        status := "pending"
+        switch {
+        case f.cert != nil:
+            status = "valid"
+        case f.authz == "valid":
+            status = "ready"
+        }
+        return map[string]any{
+            "status":         status,
+            "identifiers":    []map[string]string{{"type": "dns", "value": f.domain}},
+            "authorizations": []string{base + "/authz/1"},
+            "finalize":       base + "/finalize/1",
+            "certificate":    base + "/cert/1",
+        }
+    }
+    switch r.URL.Path {
+    case "/dir":
+        reply(http.StatusOK, map[string]string{
+            "newNonce":   base + "/nonce",
+            "newAccount": base + "/account",
+            "newOrder":   base + "/order",
+            "revokeCert": base + "/revoke",
+            "keyChange":  base + "/key-change",
+        })
+    case "/nonce":
+        w.WriteHeader(http.StatusOK)
+    case "/account":
+        w.Header().Set("Location", base+"/account/1")
+        reply(http.StatusCreated, map[string]string{"status": "valid"})
+    case "/order":
+        var v struct {
+            Identifiers []struct{ Value string }
+        }
+        _ = json.Unmarshal(payload, &v)
+        f.domain, f.authz = v.Identifiers[0].Value, "pending"
+        w.Header().Set("Location", base+"/order/1")
+        reply(http.StatusCreated, order())
+    case "/order/1":
+        w.Header().Set("Location", base+"/order/1")
+        reply(http.StatusOK, order())
+    case "/authz/1":
+        reply(http.StatusOK, map[string]any{
+            "status":     f.authz,
+            "identifier": map[string]string{"type": "dns", "value": f.domain},
+            "challenges": []map[string]string{{
+                "type": "tls-alpn-01", "url": base + "/chal/1", "token": "token", "status": f.authz,
+            }},
+        })
+    case "/chal/1":
+        if err := f.validate(f.domain); err != nil {
+            f.t.Errorf("tls-alpn-01 validation failed: %v", err)
+            f.authz = "invalid"
+        } else {
+            f.authz = "valid"
+        }
+        reply(http.StatusOK, map[string]string{
+            "type": "tls-alpn-01", "url": base + "/chal/1", "token": "token", "status": f.authz,
+        })
+    case "/finalize/1":
+        var v struct {
+            CSR string `json:"csr"`
+        }
+        _ = json.Unmarshal(payload, &v)
+        der, _ := base64.RawURLEncoding.DecodeString(v.CSR)
+        csr, err := x509.ParseCertificateRequest(der)
+        if err != nil {
+            f.t.Errorf("invalid csr: %v", err)
+            w.WriteHeader(http.StatusBadRequest)
+            return
+        }
+        tpl := &x509.Certificate{
+            SerialNumber: big.NewInt(100),
+            Subject:      pkix.Name{CommonName: csr.DNSNames[0]},
+            DNSNames:     csr.DNSNames,
+            NotBefore:    time.Now().Add(-time.Hour),
+            NotAfter:     time.Now().Add(90 * 24 * time.Hour),
+            KeyUsage:     x509.KeyUsageDigitalSignature,
+            ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+        }
+        if f.cert, err = x509.CreateCertificate(rand.Reader, tpl, f.ca, csr.PublicKey, f.caKey); err != nil {
+            f.t.Error(err)
+        }
+        w.Header().Set("Location", base+"/order/1")
+        reply(http.StatusOK, order())
+    case "/cert/1":
+        w.Header().Set("Content-Type", "application/pem-certificate-chain")
+        _ = pem.Encode(w, &pem.Block{Type: "CERTIFICATE", Bytes: f.cert})
+        _ = pem.Encode(w, &pem.Block{Type: "CERTIFICATE", Bytes: f.ca.Raw})
+    default:
+        w.WriteHeader(http.StatusNotFound)
+    }
+}
+
+func TestACMEIssue(t *testing.T) {
+    _, _, ca, caKey := newCert(t, "acme-ca", 1, nil, nil)
+    cache := &memCache{m: map[string][]byte{}}
+    var a *ACME
+    f := &fakeACME{t: t, ca: ca, caKey: caKey}
+    f.validate = func(domain string) error {
+        // 模拟 CA 以 acme-tls/1 协议发起握手取得挑战证书。
+        cert, err := a.GetCertificate(&tls.ClientHelloInfo{ServerName: domain, SupportedProtos: []string{"acme-tls/1"}})
+        if err != nil {
+            return err
+        }
+        if cert.Leaf == nil {
+            if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+                return err
+            }
+        }
+        return cert.Leaf.VerifyHostname(domain)
+    }
+    srv := httptest.NewServer(f)
+    defer srv.Close()
+
+    a = NewACME(
+        ACMEHosts("example.com"),
+        ACMECache(cache),
+        ACMEDirectoryURL(srv.URL+"/dir"),
+        ACMEHTTPClient(srv.Client()),
+    )
+    cert, err := a.GetCertificate(&tls.ClientHelloInfo{
+        ServerName:   "example.com",
+        CipherSuites: []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256},
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+    if cert.Leaf == nil || cert.Leaf.SerialNumber.Int64() != 100 || cert.Leaf.VerifyHostname("example.com") != nil {
+        t.Fatalf("unexpected certificate %+v", cert.Leaf)
+    }
+    pool := x509.NewCertPool()
+    pool.AddCert(ca)
+    if _, err = cert.Leaf.Verify(x509.VerifyOptions{Roots: pool, DNSName: "example.com"}); err != nil {
+        t.Fatalf("expect certificate issued by test CA, got %v", err)
+    }
+    cache.Lock()
+    _, ok := cache.m["example.com"]
+    cache.Unlock()
+    if !ok {
+        t.Fatal("expect issued certificate cached")
+    }
+}
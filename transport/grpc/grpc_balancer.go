@@ -0,0 +1,79 @@
+package grpc
+
+import (
+    "math/rand/v2"
+    "sync/atomic"
+    "time"
+
+    "google.golang.org/grpc/balancer"
+    "google.golang.org/grpc/balancer/base"
+)
+
+func init() {
+    balancer.Register(base.NewBalancerBuilder(P2C, &p2cPickerBuilder{}, base.Config{HealthCheck: true}))
+}
+
+// p2cPickerBuilder P2C 选择器构建器。
+type p2cPickerBuilder struct{}
+
+func (*p2cPickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+    if len(info.ReadySCs) == 0 {
+        return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+    }
+    conns := make([]*p2cConn, 0, len(info.ReadySCs))
+    for sc := range info.ReadySCs {
+        conns = append(conns, &p2cConn{sc: sc})
+    }
+    return &p2cPicker{conns: conns}
+}
+
+// p2cConn 带负载统计的子连接。
+type p2cConn struct {
+    sc       balancer.SubConn
+    inflight atomic.Int64
+    lag      atomic.Int64 // 延迟的指数加权移动平均值（纳秒）。
+}
+
+// score 返回连接负载评分，越小越优先。
+func (c *p2cConn) score() int64 {
+    return (c.lag.Load() + 1) * (c.inflight.Load() + 1)
+}
+
+// p2cPicker P2C 选择器。
+type p2cPicker struct {
+    conns []*p2cConn
+}
+
+func (p *p2cPicker) Pick(balancer.PickInfo) (balancer.PickResult, error) {
+    c := p.conns[0]
+    if n := len(p.conns); n > 1 {
+        a := rand.IntN(n)
+        b := rand.IntN(n - 1)
+        if b >= a {
+            b++
+        }
+        c = p.conns[a]
+        if p.conns[b].score() < c.score() {
+            c = p.conns[b]
+        }
+    }
+    c.inflight.Add(1)
+    start := time.Now()
+    return balancer.PickResult{
+        SubConn: c.sc,
+        Done: func(balancer.DoneInfo) {
+            c.inflight.Add(-1)
+            rtt := int64(time.Since(start))
+            for {
+                old := c.lag.Load()
+                lag := rtt
+                if old > 0 {
+                    lag = (old*7 + rtt*3) / 10
+                }
+                if c.lag.CompareAndSwap(old, lag) {
+                    return
+                }
+            }
+        },
+    }, nil
+}
@@ -0,0 +1,169 @@
+package grpc
+
+import (
+    "context"
+    "crypto/tls"
+    "fmt"
+    "strings"
+    "time"
+
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/connectivity"
+    "google.golang.org/grpc/credentials"
+    "google.golang.org/grpc/credentials/insecure"
+
+    "github.com/camry/dove/v2/metadata"
+    "github.com/camry/dove/v2/registry"
+)
+
+const (
+    // RoundRobin 轮询负载均衡。
+    RoundRobin = "round_robin"
+    // P2C 两次随机选择（Power of Two Choices）负载均衡，选择在途请求与延迟综合评分较低的连接。
+    P2C = "p2c"
+)
+
+// ClientOption 定义一个 gRPC 客户端选项类型。
+type ClientOption func(o *clientOption)
+
+// Endpoint 配置服务地址，形如 127.0.0.1:9000 或 discovery:///service-name。
+func Endpoint(endpoint string) ClientOption {
+    return func(o *clientOption) { o.endpoint = endpoint }
+}
+
+// Discovery 配置服务发现，用于解析 discovery:///service-name 形式的地址。
+func Discovery(d registry.Discovery) ClientOption {
+    return func(o *clientOption) { o.discovery = d }
+}
+
+// Balancer 配置负载均衡策略，默认 RoundRobin。
+func Balancer(name string) ClientOption {
+    return func(o *clientOption) { o.balancer = name }
+}
+
+// Timeout 配置一元调用超时时间，调用方携带更短的截止时间时以调用方为准，0 表示不限制。
+func Timeout(timeout time.Duration) ClientOption {
+    return func(o *clientOption) { o.timeout = timeout }
+}
+
+// Retry 配置一元调用重试，attempts 为最大尝试次数，backoff 为首次重试等待时间（此后指数增长），
+// retryCodes 为可重试的状态码，默认仅重试 codes.Unavailable。
+func Retry(attempts int, backoff time.Duration, retryCodes ...codes.Code) ClientOption {
+    return func(o *clientOption) {
+        o.retryAttempts = attempts
+        o.retryBackoff = backoff
+        if len(retryCodes) > 0 {
+            o.retryCodes = retryCodes
+        }
+    }
+}
+
+// Metadata 配置随每次调用发送的固定元数据。
+func Metadata(md map[string]string) ClientOption {
+    return func(o *clientOption) { o.metadata = md }
+}
+
+// MetadataOptions 配置出站元数据传播选项，默认透传上下文中的 x-md-global-* 元数据。
+func MetadataOptions(opts ...metadata.Option) ClientOption {
+    return func(o *clientOption) { o.propagator = metadata.NewPropagator(opts...) }
+}
+
+// TLSConfig 配置 TLS，未配置时使用明文连接。
+func TLSConfig(c *tls.Config) ClientOption {
+    return func(o *clientOption) { o.tlsConf = c }
+}
+
+// UnaryInterceptor 配置一元拦截器，可多次调用累加，位于内置拦截器之后、每次尝试时执行。
+func UnaryInterceptor(in ...grpc.UnaryClientInterceptor) ClientOption {
+    return func(o *clientOption) { o.unaryInterceptors = append(o.unaryInterceptors, in...) }
+}
+
+// StreamInterceptor 配置流拦截器，可多次调用累加，位于内置拦截器之后。
+func StreamInterceptor(in ...grpc.StreamClientInterceptor) ClientOption {
+    return func(o *clientOption) { o.streamInterceptors = append(o.streamInterceptors, in...) }
+}
+
+// Block 配置 Dial 阻塞直到连接就绪或上下文结束。
+func Block() ClientOption {
+    return func(o *clientOption) { o.block = true }
+}
+
+// Options 配置 gRPC 拨号选项。
+func Options(grpcOpts ...grpc.DialOption) ClientOption {
+    return func(o *clientOption) { o.grpcOpts = append(o.grpcOpts, grpcOpts...) }
+}
+
+// clientOption gRPC 客户端选项实体对象。
+type clientOption struct {
+    endpoint           string
+    discovery          registry.Discovery
+    balancer           string
+    timeout            time.Duration
+    retryAttempts      int
+    retryBackoff       time.Duration
+    retryCodes         []codes.Code
+    metadata           map[string]string
+    propagator         *metadata.Propagator
+    tlsConf            *tls.Config
+    unaryInterceptors  []grpc.UnaryClientInterceptor
+    streamInterceptors []grpc.StreamClientInterceptor
+    block              bool
+    grpcOpts           []grpc.DialOption
+}
+
+// Dial 新建 gRPC 客户端连接。
+//
+// 地址为 discovery:///service-name 时通过 Discovery 解析服务实例的 grpc:// 地址并持续监听变更。
+func Dial(ctx context.Context, opts ...ClientOption) (*grpc.ClientConn, error) {
+    o := clientOption{
+        balancer:   RoundRobin,
+        timeout:    2 * time.Second,
+        retryCodes: []codes.Code{codes.Unavailable},
+        propagator: metadata.NewPropagator(),
+    }
+    for _, opt := range opts {
+        opt(&o)
+    }
+    unary := []grpc.UnaryClientInterceptor{
+        propagationUnaryInterceptor(o.propagator, o.metadata),
+        timeoutUnaryInterceptor(o.timeout),
+    }
+    if o.retryAttempts > 1 {
+        unary = append(unary, retryUnaryInterceptor(o.retryAttempts, o.retryBackoff, o.retryCodes))
+    }
+    stream := []grpc.StreamClientInterceptor{
+        propagationStreamInterceptor(o.propagator, o.metadata),
+    }
+    grpcOpts := []grpc.DialOption{
+        grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"loadBalancingConfig": [{"%s":{}}]}`, o.balancer)),
+        grpc.WithChainUnaryInterceptor(append(unary, o.unaryInterceptors...)...),
+        grpc.WithChainStreamInterceptor(append(stream, o.streamInterceptors...)...),
+    }
+    if o.tlsConf != nil {
+        grpcOpts = append(grpcOpts, grpc.WithTransportCredentials(credentials.NewTLS(o.tlsConf)))
+    } else {
+        grpcOpts = append(grpcOpts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+    }
+    if strings.HasPrefix(o.endpoint, discoveryScheme+":") {
+        if o.discovery == nil {
+            return nil, fmt.Errorf("[gRPC] client endpoint %s requires a discovery", o.endpoint)
+        }
+        grpcOpts = append(grpcOpts, grpc.WithResolvers(&resolverBuilder{discovery: o.discovery}))
+    }
+    grpcOpts = append(grpcOpts, o.grpcOpts...)
+    conn, err := grpc.NewClient(o.endpoint, grpcOpts...)
+    if err != nil {
+        return nil, fmt.Errorf("[gRPC] client dial %s failed: %w", o.endpoint, err)
+    }
+    conn.Connect()
+    if o.block {
+        for state := conn.GetState(); state != connectivity.Ready; state = conn.GetState() {
+            if !conn.WaitForStateChange(ctx, state) {
+                _ = conn.Close()
+                return nil, fmt.Errorf("[gRPC] client dial %s failed: %w", o.endpoint, ctx.Err())
+            }
+        }
+    }
+    return conn, nil
+}
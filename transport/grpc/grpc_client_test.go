@@ -0,0 +1,106 @@
+package grpc
+
+import (
+    "context"
+    "fmt"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/health/grpc_health_v1"
+    "google.golang.org/grpc/metadata"
+    "google.golang.org/grpc/status"
+
+    dmd "github.com/camry/dove/v2/metadata"
+    "github.com/camry/dove/v2/registry"
+    "github.com/camry/dove/v2/requestid"
+    sgrpc "github.com/camry/dove/v2/server/grpc"
+)
+
+// startServer 启动进程内 gRPC 服务器并返回其服务实例。
+func startServer(t *testing.T, id string, in grpc.UnaryServerInterceptor) *registry.ServiceInstance {
+    s := sgrpc.NewServer(sgrpc.Address("127.0.0.1:0"), sgrpc.UnaryInterceptor(in))
+    if err := s.Listen(context.Background()); err != nil {
+        t.Fatal(err)
+    }
+    go func() { _ = s.Start(context.Background()) }()
+    t.Cleanup(func() { _ = s.Stop(context.Background()) })
+    return &registry.ServiceInstance{
+        ID:        id,
+        Name:      "helloworld",
+        Endpoints: []string{"http://127.0.0.1:1", "grpc://" + s.ListenAddr().String()},
+    }
+}
+
+func TestDial(t *testing.T) {
+    for _, lb := range []string{RoundRobin, P2C} {
+        t.Run(lb, func(t *testing.T) {
+            r := registry.NewMemory()
+            var counts [2]atomic.Int64
+            for i := range counts {
+                si := startServer(t, fmt.Sprint(i), func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+                    counts[i].Add(1)
+                    return handler(ctx, req)
+                })
+                _ = r.Register(context.Background(), si)
+            }
+            ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+            defer cancel()
+            conn, err := Dial(ctx, Endpoint("discovery:///helloworld"), Discovery(r), Balancer(lb), Block())
+            if err != nil {
+                t.Fatal(err)
+            }
+            defer conn.Close()
+            client := grpc_health_v1.NewHealthClient(conn)
+            // 等待全部实例连接就绪。
+            time.Sleep(100 * time.Millisecond)
+            for range 100 {
+                if _, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{}); err != nil {
+                    t.Fatal(err)
+                }
+            }
+            if counts[0].Load() == 0 || counts[1].Load() == 0 {
+                t.Fatalf("expect calls on both instances, got %d and %d", counts[0].Load(), counts[1].Load())
+            }
+        })
+    }
+}
+
+func TestDial_Interceptors(t *testing.T) {
+    var calls atomic.Int64
+    si := startServer(t, "0", func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+        md, _ := metadata.FromIncomingContext(ctx)
+        if got := md.Get(requestid.MetadataKey); len(got) != 1 || got[0] != "req-1" {
+            return nil, status.Errorf(codes.InvalidArgument, "unexpected request id %v", got)
+        }
+        if got := md.Get("x-tenant"); len(got) != 1 || got[0] != "t1" {
+            return nil, status.Errorf(codes.InvalidArgument, "unexpected tenant %v", got)
+        }
+        if smd, _ := dmd.FromServerContext(ctx); smd.Get("x-md-global-uid") != "1" {
+            return nil, status.Errorf(codes.InvalidArgument, "unexpected server metadata %v", smd)
+        }
+        if calls.Add(1) < 3 {
+            return nil, status.Error(codes.Unavailable, "try again")
+        }
+        return handler(ctx, req)
+    })
+    conn, err := Dial(context.Background(),
+        Endpoint(si.Endpoint("grpc")),
+        Metadata(map[string]string{"x-tenant": "t1"}),
+        Retry(3, 10*time.Millisecond),
+    )
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer conn.Close()
+    ctx := requestid.NewContext(context.Background(), "req-1")
+    ctx = dmd.AppendToClientContext(ctx, "x-md-global-uid", "1")
+    if _, err := grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{}); err != nil {
+        t.Fatal(err)
+    }
+    if calls.Load() != 3 {
+        t.Fatalf("expect 3 attempts, got %d", calls.Load())
+    }
+}
@@ -0,0 +1,94 @@
+package grpc
+
+import (
+    "context"
+    "slices"
+    "time"
+
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/metadata"
+    "google.golang.org/grpc/status"
+
+    dmd "github.com/camry/dove/v2/metadata"
+    "github.com/camry/dove/v2/requestid"
+)
+
+// propagationKeys 从入站元数据透传到出站元数据的键，包括 W3C Trace Context 追踪头。
+var propagationKeys = []string{requestid.MetadataKey, "traceparent", "tracestate"}
+
+// outgoingContext 返回携带固定元数据、传播元数据、请求 ID 与追踪信息的出站上下文，已存在的出站元数据不会被覆盖。
+func outgoingContext(ctx context.Context, p *dmd.Propagator, md map[string]string) context.Context {
+    ctx = p.InjectGRPC(ctx)
+    out, _ := metadata.FromOutgoingContext(ctx)
+    out = out.Copy()
+    for k, v := range md {
+        if len(out.Get(k)) == 0 {
+            out.Set(k, v)
+        }
+    }
+    in, _ := metadata.FromIncomingContext(ctx)
+    for _, k := range propagationKeys {
+        if len(out.Get(k)) > 0 {
+            continue
+        }
+        if vs := in.Get(k); len(vs) > 0 {
+            out.Set(k, vs...)
+        }
+    }
+    if len(out.Get(requestid.MetadataKey)) == 0 {
+        if id, ok := requestid.FromContext(ctx); ok && id != "" {
+            out.Set(requestid.MetadataKey, id)
+        }
+    }
+    return metadata.NewOutgoingContext(ctx, out)
+}
+
+// propagationUnaryInterceptor 元数据透传一元拦截器。
+func propagationUnaryInterceptor(p *dmd.Propagator, md map[string]string) grpc.UnaryClientInterceptor {
+    return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+        return invoker(outgoingContext(ctx, p, md), method, req, reply, cc, opts...)
+    }
+}
+
+// propagationStreamInterceptor 元数据透传流拦截器。
+func propagationStreamInterceptor(p *dmd.Propagator, md map[string]string) grpc.StreamClientInterceptor {
+    return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+        return streamer(outgoingContext(ctx, p, md), desc, cc, method, opts...)
+    }
+}
+
+// timeoutUnaryInterceptor 一元调用超时拦截器。
+func timeoutUnaryInterceptor(timeout time.Duration) grpc.UnaryClientInterceptor {
+    return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+        if timeout > 0 {
+            var cancel context.CancelFunc
+            ctx, cancel = context.WithTimeout(ctx, timeout)
+            defer cancel()
+        }
+        return invoker(ctx, method, req, reply, cc, opts...)
+    }
+}
+
+// retryUnaryInterceptor 一元调用重试拦截器，在上下文截止时间内按指数退避重试。
+func retryUnaryInterceptor(attempts int, backoff time.Duration, retryCodes []codes.Code) grpc.UnaryClientInterceptor {
+    return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+        var err error
+        for i := range attempts {
+            if i > 0 {
+                timer := time.NewTimer(backoff << (i - 1))
+                select {
+                case <-ctx.Done():
+                    timer.Stop()
+                    return err
+                case <-timer.C:
+                }
+            }
+            err = invoker(ctx, method, req, reply, cc, opts...)
+            if err == nil || !slices.Contains(retryCodes, status.Code(err)) {
+                return err
+            }
+        }
+        return err
+    }
+}
@@ -0,0 +1,99 @@
+package grpc
+
+import (
+    "context"
+    "strings"
+    "time"
+
+    "github.com/camry/g/v2/glog"
+    "google.golang.org/grpc/attributes"
+    "google.golang.org/grpc/resolver"
+
+    "github.com/camry/dove/v2/registry"
+)
+
+// discoveryScheme 服务发现地址协议。
+const discoveryScheme = "discovery"
+
+// resolverBuilder 基于服务发现的解析器构建器。
+type resolverBuilder struct {
+    discovery registry.Discovery
+}
+
+func (b *resolverBuilder) Scheme() string {
+    return discoveryScheme
+}
+
+func (b *resolverBuilder) Build(target resolver.Target, cc resolver.ClientConn, opts resolver.BuildOptions) (resolver.Resolver, error) {
+    name := strings.TrimPrefix(target.URL.Path, "/")
+    ctx, cancel := context.WithCancel(context.Background())
+    w, err := b.discovery.Watch(ctx, name)
+    if err != nil {
+        cancel()
+        return nil, err
+    }
+    r := &discoveryResolver{name: name, w: w, cc: cc, ctx: ctx, cancel: cancel}
+    go r.watch()
+    return r, nil
+}
+
+// discoveryResolver 基于服务发现的解析器。
+type discoveryResolver struct {
+    name   string
+    w      registry.Watcher
+    cc     resolver.ClientConn
+    ctx    context.Context
+    cancel context.CancelFunc
+}
+
+// watch 持续监听服务实例变更并更新连接地址。
+func (r *discoveryResolver) watch() {
+    for {
+        ins, err := r.w.Next()
+        if err != nil {
+            if r.ctx.Err() != nil {
+                return
+            }
+            glog.Warnf("[gRPC] client watch service %s failed: %v", r.name, err)
+            select {
+            case <-r.ctx.Done():
+                return
+            case <-time.After(time.Second):
+            }
+            continue
+        }
+        r.update(ins)
+    }
+}
+
+// update 更新连接地址，无可用实例时保留原地址。
+func (r *discoveryResolver) update(ins []*registry.ServiceInstance) {
+    addrs := make([]resolver.Address, 0, len(ins))
+    for _, in := range ins {
+        ep := in.Endpoint("grpc")
+        if ep == "" {
+            continue
+        }
+        addrs = append(addrs, resolver.Address{
+            Addr:       ep,
+            ServerName: r.name,
+            Attributes: attributes.New("instance", in.ID),
+        })
+    }
+    if len(addrs) == 0 {
+        glog.Warnf("[gRPC] client service %s has no available grpc endpoint", r.name)
+        return
+    }
+    if err := r.cc.UpdateState(resolver.State{Addresses: addrs}); err != nil {
+        glog.Errorf("[gRPC] client update service %s state failed: %v", r.name, err)
+    }
+}
+
+func (r *discoveryResolver) ResolveNow(resolver.ResolveNowOptions) {}
+
+func (r *discoveryResolver) Close() {
+    r.cancel()
+    if err := r.w.Stop(); err != nil {
+        glog.Errorf("[gRPC] client stop watcher of service %s failed: %v", r.name, err)
+    }
+}
@@ -0,0 +1,270 @@
+package http
+
+import (
+    "bytes"
+    "context"
+    "crypto/tls"
+    "encoding/json"
+    "fmt"
+    "io"
+    "net/http"
+    "net/url"
+    "strings"
+    "time"
+
+    "github.com/camry/dove/v2/circuitbreaker"
+    "github.com/camry/dove/v2/errors"
+    "github.com/camry/dove/v2/metadata"
+    "github.com/camry/dove/v2/registry"
+)
+
+// discoveryScheme 服务发现地址协议。
+const discoveryScheme = "discovery"
+
+// ClientOption 定义一个 HTTP 客户端选项类型。
+type ClientOption func(c *Client)
+
+// Endpoint 配置服务地址，形如 http://127.0.0.1:8000 或 discovery:///service-name。
+func Endpoint(endpoint string) ClientOption {
+    return func(c *Client) { c.endpoint = endpoint }
+}
+
+// Discovery 配置服务发现，用于解析 discovery:///service-name 形式的地址。
+func Discovery(d registry.Discovery) ClientOption {
+    return func(c *Client) { c.discovery = d }
+}
+
+// Timeout 配置单次请求（含重试）的超时时间，调用方携带更短的截止时间时以调用方为准，0 表示不限制。
+func Timeout(timeout time.Duration) ClientOption {
+    return func(c *Client) { c.timeout = timeout }
+}
+
+// Retry 配置请求重试，attempts 为最大尝试次数，backoff 为首次重试等待时间（此后指数增长），
+// statuses 为可重试的响应状态码，默认重试 502、503 与 504，传输错误总是重试。
+func Retry(attempts int, backoff time.Duration, statuses ...int) ClientOption {
+    return func(c *Client) {
+        c.retryAttempts = attempts
+        c.retryBackoff = backoff
+        if len(statuses) > 0 {
+            c.retryStatuses = statuses
+        }
+    }
+}
+
+// Breaker 配置按目标主机熔断的熔断器组，熔断时返回 ErrBreakerOpen。
+func Breaker(g *circuitbreaker.Group) ClientOption {
+    return func(c *Client) { c.breaker = g }
+}
+
+// Header 配置随每次请求发送的固定请求头。
+func Header(header http.Header) ClientOption {
+    return func(c *Client) { c.header = header }
+}
+
+// MetadataOptions 配置出站元数据传播选项，默认透传上下文中的 x-md-global-* 元数据。
+func MetadataOptions(opts ...metadata.Option) ClientOption {
+    return func(c *Client) { c.propagator = metadata.NewPropagator(opts...) }
+}
+
+// Middleware 配置客户端中间件，可多次调用累加，位于内置中间件之后、每次尝试时执行。
+func Middleware(middlewares ...MiddlewareFunc) ClientOption {
+    return func(c *Client) { c.middlewares = append(c.middlewares, middlewares...) }
+}
+
+// TLSConfig 配置 TLS，服务发现时选择实例的 https:// 地址。
+func TLSConfig(conf *tls.Config) ClientOption {
+    return func(c *Client) { c.tlsConf = conf }
+}
+
+// Transport 配置底层传输，默认克隆 http.DefaultTransport。
+func Transport(rt http.RoundTripper) ClientOption {
+    return func(c *Client) { c.transport = rt }
+}
+
+// ErrorDecoder 配置非 2xx 响应的错误解码函数，默认使用 errors.FromResponse。
+func ErrorDecoder(fn func(*http.Response) error) ClientOption {
+    return func(c *Client) { c.errorDecoder = fn }
+}
+
+// Client HTTP 客户端。
+type Client struct {
+    endpoint      string
+    discovery     registry.Discovery
+    timeout       time.Duration
+    retryAttempts int
+    retryBackoff  time.Duration
+    retryStatuses []int
+    breaker       *circuitbreaker.Group
+    header        http.Header
+    propagator    *metadata.Propagator
+    middlewares   []MiddlewareFunc
+    tlsConf       *tls.Config
+    transport     http.RoundTripper
+    errorDecoder  func(*http.Response) error
+
+    base     *url.URL
+    resolver *resolver
+    cc       *http.Client
+}
+
+// NewClient 新建 HTTP 客户端。
+//
+// 地址为 discovery:///service-name 时通过 Discovery 解析服务实例的 http:// 地址（配置 TLS 时为 https://），
+// 每次尝试按轮询选择实例。
+func NewClient(ctx context.Context, opts ...ClientOption) (*Client, error) {
+    c := &Client{
+        timeout:       2 * time.Second,
+        retryStatuses: []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+        propagator:    metadata.NewPropagator(),
+        errorDecoder: func(resp *http.Response) error {
+            return errors.FromResponse(resp)
+        },
+    }
+    for _, o := range opts {
+        o(c)
+    }
+    base, err := url.Parse(c.endpoint)
+    if err != nil {
+        return nil, fmt.Errorf("[HTTP] client endpoint %s is invalid: %w", c.endpoint, err)
+    }
+    c.base = base
+    scheme := "http"
+    if c.tlsConf != nil {
+        scheme = "https"
+    }
+    if base.Scheme == discoveryScheme {
+        if c.discovery == nil {
+            return nil, fmt.Errorf("[HTTP] client endpoint %s requires a discovery", c.endpoint)
+        }
+        if c.resolver, err = newResolver(c.discovery, strings.TrimPrefix(base.Path, "/"), scheme); err != nil {
+            return nil, fmt.Errorf("[HTTP] client watch %s failed: %w", c.endpoint, err)
+        }
+        c.base = &url.URL{Scheme: scheme}
+    }
+    if c.transport == nil {
+        t := http.DefaultTransport.(*http.Transport).Clone()
+        t.TLSClientConfig = c.tlsConf
+        c.transport = t
+    }
+    var rt http.RoundTripper = RoundTripperFunc(c.roundTrip)
+    if c.breaker != nil {
+        rt = breaker(c.breaker)(rt)
+    }
+    rt = RoundTripperFunc(c.selectHost(rt))
+    if c.retryAttempts > 1 {
+        rt = retry(c.retryAttempts, c.retryBackoff, c.retryStatuses)(rt)
+    }
+    rt = propagation(c.propagator, c.header)(rt)
+    c.cc = &http.Client{Transport: rt}
+    return c, nil
+}
+
+// roundTrip 执行用户中间件与底层传输。
+func (c *Client) roundTrip(r *http.Request) (*http.Response, error) {
+    return MiddlewareChain(c.middlewares...)(c.transport).RoundTrip(r)
+}
+
+// selectHost 为每次尝试补全目标地址。
+func (c *Client) selectHost(next http.RoundTripper) func(*http.Request) (*http.Response, error) {
+    return func(r *http.Request) (*http.Response, error) {
+        if c.resolver == nil && r.URL.Host != "" {
+            return next.RoundTrip(r)
+        }
+        r = r.Clone(r.Context())
+        r.URL.Scheme = c.base.Scheme
+        r.URL.Host = c.base.Host
+        if c.resolver != nil {
+            host, err := c.resolver.pick(r.Context())
+            if err != nil {
+                return nil, fmt.Errorf("[HTTP] client resolve %s failed: %w", c.endpoint, err)
+            }
+            r.URL.Host = host
+        }
+        r.Host = r.URL.Host
+        return next.RoundTrip(r)
+    }
+}
+
+// Do 发送请求并返回原始响应，请求地址为相对路径时基于客户端地址补全。
+//
+// 与 http.Client 一致，非 2xx 响应不返回错误，调用方负责关闭响应体。
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+    if !req.URL.IsAbs() {
+        req = req.Clone(req.Context())
+        req.URL = c.base.ResolveReference(req.URL)
+        if c.resolver != nil {
+            req.URL.Host = ""
+        }
+    }
+    if c.timeout <= 0 {
+        return c.cc.Do(req)
+    }
+    ctx, cancel := context.WithTimeout(req.Context(), c.timeout)
+    resp, err := c.cc.Do(req.WithContext(ctx))
+    if err != nil {
+        cancel()
+        return nil, err
+    }
+    resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+    return resp, nil
+}
+
+// Invoke 以 JSON 编码 in 发送请求，并将 2xx 响应解码到 out，非 2xx 响应解码为结构化错误。
+//
+// in 为 nil 时不发送请求体，out 为 nil 时丢弃响应体。
+func (c *Client) Invoke(ctx context.Context, method, path string, in, out any) error {
+    var body io.Reader
+    var data []byte
+    if in != nil {
+        var err error
+        if data, err = json.Marshal(in); err != nil {
+            return err
+        }
+        body = bytes.NewReader(data)
+    }
+    req, err := http.NewRequestWithContext(ctx, method, path, body)
+    if err != nil {
+        return err
+    }
+    if in != nil {
+        req.Header.Set("Content-Type", "application/json")
+    }
+    req.Header.Set("Accept", "application/json")
+    resp, err := c.Do(req)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+        return c.errorDecoder(resp)
+    }
+    if out == nil {
+        _, _ = io.Copy(io.Discard, resp.Body)
+        return nil
+    }
+    return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// Close 关闭客户端，停止服务发现监听并释放空闲连接。
+func (c *Client) Close() error {
+    c.cc.CloseIdleConnections()
+    if t, ok := c.transport.(interface{ CloseIdleConnections() }); ok {
+        t.CloseIdleConnections()
+    }
+    if c.resolver != nil {
+        return c.resolver.close()
+    }
+    return nil
+}
+
+// cancelBody 关闭响应体时取消请求上下文。
+type cancelBody struct {
+    io.ReadCloser
+    cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+    err := b.ReadCloser.Close()
+    b.cancel()
+    return err
+}
@@ -0,0 +1,87 @@
+package http
+
+import (
+    "context"
+    stderrors "errors"
+    "net/http"
+    "net/http/httptest"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    "github.com/camry/dove/v2/circuitbreaker"
+    "github.com/camry/dove/v2/errors"
+    "github.com/camry/dove/v2/registry"
+    "github.com/camry/dove/v2/requestid"
+)
+
+func TestClient_Discovery(t *testing.T) {
+    r := registry.NewMemory()
+    var counts [2]atomic.Int64
+    for i := range counts {
+        srv := httptest.NewServer(errors.HandlerFunc(func(w http.ResponseWriter, req *http.Request) error {
+            counts[i].Add(1)
+            if req.Header.Get(requestid.Header) != "req-1" {
+                return errors.BadRequest("MISSING_REQUEST_ID", "missing request id")
+            }
+            _, err := w.Write([]byte(`{"name":"dove"}`))
+            return err
+        }))
+        defer srv.Close()
+        _ = r.Register(context.Background(), &registry.ServiceInstance{
+            ID: srv.URL, Name: "helloworld", Endpoints: []string{srv.URL},
+        })
+    }
+    c, err := NewClient(context.Background(), Endpoint("discovery:///helloworld"), Discovery(r))
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer c.Close()
+    ctx := requestid.NewContext(context.Background(), "req-1")
+    for range 10 {
+        var out struct{ Name string }
+        if err := c.Invoke(ctx, http.MethodGet, "/hello", nil, &out); err != nil {
+            t.Fatal(err)
+        }
+        if out.Name != "dove" {
+            t.Fatalf("unexpected reply %+v", out)
+        }
+    }
+    if counts[0].Load() != 5 || counts[1].Load() != 5 {
+        t.Fatalf("expect round robin, got %d and %d", counts[0].Load(), counts[1].Load())
+    }
+    err = c.Invoke(context.Background(), http.MethodGet, "/hello", nil, nil)
+    if !errors.IsBadRequest(err) || errors.Reason(err) != "MISSING_REQUEST_ID" {
+        t.Fatalf("expect structured error, got %v", err)
+    }
+}
+
+func TestClient_RetryAndBreaker(t *testing.T) {
+    var calls atomic.Int64
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if calls.Add(1)%3 != 0 {
+            w.WriteHeader(http.StatusServiceUnavailable)
+            return
+        }
+        w.WriteHeader(http.StatusNoContent)
+    }))
+    defer srv.Close()
+    g := circuitbreaker.NewGroup(circuitbreaker.MinRequests(4), circuitbreaker.FailureRatio(0.5), circuitbreaker.OpenTimeout(time.Minute))
+    c, err := NewClient(context.Background(), Endpoint(srv.URL), Retry(3, time.Millisecond), Breaker(g))
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer c.Close()
+    if err := c.Invoke(context.Background(), http.MethodPost, "/", map[string]string{"a": "b"}, nil); err != nil {
+        t.Fatal(err)
+    }
+    if calls.Load() != 3 {
+        t.Fatalf("expect 3 attempts, got %d", calls.Load())
+    }
+    // 连续失败后熔断。
+    _ = c.Invoke(context.Background(), http.MethodGet, "/", nil, nil)
+    err = c.Invoke(context.Background(), http.MethodGet, "/", nil, nil)
+    if !stderrors.Is(err, ErrBreakerOpen) {
+        t.Fatalf("expect breaker open, got %v", err)
+    }
+}
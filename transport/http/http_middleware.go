@@ -0,0 +1,144 @@
+package http
+
+import (
+    "errors"
+    "fmt"
+    "net/http"
+    "time"
+
+    "google.golang.org/grpc/metadata"
+
+    "github.com/camry/dove/v2/circuitbreaker"
+    dmd "github.com/camry/dove/v2/metadata"
+    "github.com/camry/dove/v2/requestid"
+)
+
+// RoundTripperFunc 函数形式的 http.RoundTripper。
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+    return f(r)
+}
+
+// MiddlewareFunc 定义 HTTP 客户端中间件类型，与 ghttp.FilterFunc 对称。
+type MiddlewareFunc func(http.RoundTripper) http.RoundTripper
+
+// MiddlewareChain 将多个中间件组合为一个，第一个中间件位于最外层。
+func MiddlewareChain(middlewares ...MiddlewareFunc) MiddlewareFunc {
+    return func(next http.RoundTripper) http.RoundTripper {
+        for i := len(middlewares) - 1; i >= 0; i-- {
+            next = middlewares[i](next)
+        }
+        return next
+    }
+}
+
+// propagationKeys 从入站 gRPC 元数据透传到出站请求头的键，包括 W3C Trace Context 追踪头。
+var propagationKeys = []string{"traceparent", "tracestate"}
+
+// propagation 透传固定请求头、传播元数据、请求 ID 与追踪信息，已存在的请求头不会被覆盖。
+func propagation(p *dmd.Propagator, header http.Header) MiddlewareFunc {
+    return func(next http.RoundTripper) http.RoundTripper {
+        return RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+            r = r.Clone(r.Context())
+            for k, vs := range header {
+                if r.Header.Get(k) == "" {
+                    r.Header[k] = vs
+                }
+            }
+            if r.Header.Get(requestid.Header) == "" {
+                if id, ok := requestid.FromContext(r.Context()); ok && id != "" {
+                    r.Header.Set(requestid.Header, id)
+                }
+            }
+            p.InjectHTTP(r.Context(), r.Header)
+            in, _ := metadata.FromIncomingContext(r.Context())
+            for _, k := range propagationKeys {
+                if r.Header.Get(k) != "" {
+                    continue
+                }
+                if vs := in.Get(k); len(vs) > 0 {
+                    r.Header.Set(k, vs[0])
+                }
+            }
+            return next.RoundTrip(r)
+        })
+    }
+}
+
+// retry 按指数退避重试传输错误与可重试状态码，请求体无法重放时不重试。
+func retry(attempts int, backoff time.Duration, statuses []int) MiddlewareFunc {
+    return func(next http.RoundTripper) http.RoundTripper {
+        return RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+            var (
+                resp *http.Response
+                err  error
+            )
+            for i := range attempts {
+                if i > 0 {
+                    if r.Body != nil && r.Body != http.NoBody {
+                        if r.GetBody == nil {
+                            break
+                        }
+                        body, berr := r.GetBody()
+                        if berr != nil {
+                            break
+                        }
+                        r = r.Clone(r.Context())
+                        r.Body = body
+                    }
+                    timer := time.NewTimer(backoff << (i - 1))
+                    select {
+                    case <-r.Context().Done():
+                        timer.Stop()
+                        return resp, err
+                    case <-timer.C:
+                    }
+                    if resp != nil {
+                        _ = resp.Body.Close()
+                    }
+                }
+                resp, err = next.RoundTrip(r)
+                if err == nil && !retryable(resp.StatusCode, statuses) {
+                    return resp, nil
+                }
+                if err != nil && r.Context().Err() != nil {
+                    return resp, err
+                }
+            }
+            return resp, err
+        })
+    }
+}
+
+// retryable 判断状态码是否可重试。
+func retryable(code int, statuses []int) bool {
+    for _, s := range statuses {
+        if s == code {
+            return true
+        }
+    }
+    return false
+}
+
+// ErrBreakerOpen 熔断器开启时返回的错误。
+var ErrBreakerOpen = errors.New("http client: circuit breaker is open")
+
+// breaker 按目标主机熔断，传输错误与 5xx 响应计为失败。
+func breaker(g *circuitbreaker.Group) MiddlewareFunc {
+    return func(next http.RoundTripper) http.RoundTripper {
+        return RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+            b := g.Get(r.URL.Host)
+            if err := b.Allow(); err != nil {
+                return nil, fmt.Errorf("%w: %s", ErrBreakerOpen, r.URL.Host)
+            }
+            resp, err := next.RoundTrip(r)
+            if err != nil || resp.StatusCode >= http.StatusInternalServerError {
+                b.MarkFailed()
+            } else {
+                b.MarkSuccess()
+            }
+            return resp, err
+        })
+    }
+}
@@ -0,0 +1,96 @@
+package http
+
+import (
+    "context"
+    "errors"
+    "sync"
+    "sync/atomic"
+    "time"
+
+    "github.com/camry/g/v2/glog"
+
+    "github.com/camry/dove/v2/registry"
+)
+
+// ErrNoEndpoint 服务没有可用地址。
+var ErrNoEndpoint = errors.New("http client: no available endpoint")
+
+// resolver 基于服务发现的地址解析器，按轮询选择地址。
+type resolver struct {
+    name    string
+    scheme  string
+    w       registry.Watcher
+    cancel  context.CancelFunc
+    mu      sync.RWMutex
+    hosts   []string
+    next    atomic.Uint64
+    ready   chan struct{}
+    readyMu sync.Once
+}
+
+// newResolver 新建服务发现地址解析器，scheme 为实例地址的协议（http 或 https）。
+func newResolver(d registry.Discovery, name, scheme string) (*resolver, error) {
+    ctx, cancel := context.WithCancel(context.Background())
+    w, err := d.Watch(ctx, name)
+    if err != nil {
+        cancel()
+        return nil, err
+    }
+    r := &resolver{name: name, scheme: scheme, w: w, cancel: cancel, ready: make(chan struct{})}
+    go r.watch(ctx)
+    return r, nil
+}
+
+// watch 持续监听服务实例变更。
+func (r *resolver) watch(ctx context.Context) {
+    for {
+        ins, err := r.w.Next()
+        if err != nil {
+            if ctx.Err() != nil {
+                return
+            }
+            glog.Warnf("[HTTP] client watch service %s failed: %v", r.name, err)
+            select {
+            case <-ctx.Done():
+                return
+            case <-time.After(time.Second):
+            }
+            continue
+        }
+        hosts := make([]string, 0, len(ins))
+        for _, in := range ins {
+            if ep := in.Endpoint(r.scheme); ep != "" {
+                hosts = append(hosts, ep)
+            }
+        }
+        if len(hosts) == 0 {
+            glog.Warnf("[HTTP] client service %s has no available %s endpoint", r.name, r.scheme)
+            continue
+        }
+        r.mu.Lock()
+        r.hosts = hosts
+        r.mu.Unlock()
+        r.readyMu.Do(func() { close(r.ready) })
+    }
+}
+
+// pick 按轮询返回一个地址，首次解析完成前阻塞等待直到上下文结束。
+func (r *resolver) pick(ctx context.Context) (string, error) {
+    select {
+    case <-r.ready:
+    case <-ctx.Done():
+        return "", ctx.Err()
+    }
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    if len(r.hosts) == 0 {
+        return "", ErrNoEndpoint
+    }
+    return r.hosts[r.next.Add(1)%uint64(len(r.hosts))], nil
+}
+
+// close 停止监听。
+func (r *resolver) close() error {
+    r.cancel()
+    return r.w.Stop()
+}
@@ -0,0 +1,98 @@
+package validate
+
+import (
+    "errors"
+    "strings"
+)
+
+// validator 单项校验接口，首个错误即返回。
+type validator interface {
+    Validate() error
+}
+
+// allValidator 全量校验接口，返回所有字段错误。
+type allValidator interface {
+    ValidateAll() error
+}
+
+// fieldError 字段错误接口，与 protoc-gen-validate 生成的错误类型兼容。
+type fieldError interface {
+    Field() string
+    Reason() string
+}
+
+// causer 嵌套字段错误接口，与 protoc-gen-validate 生成的错误类型兼容。
+type causer interface {
+    Cause() error
+}
+
+// multiError 多错误接口，与 protoc-gen-validate 生成的错误类型兼容。
+type multiError interface {
+    AllErrors() []error
+}
+
+// FieldViolation 字段校验错误。
+type FieldViolation struct {
+    Field       string `json:"field"`
+    Description string `json:"description"`
+}
+
+// Error 校验错误。
+type Error struct {
+    Violations []FieldViolation
+    err        error
+}
+
+func (e *Error) Error() string {
+    return "validation failed: " + e.err.Error()
+}
+
+func (e *Error) Unwrap() error {
+    return e.err
+}
+
+// Validate 校验 v，v 实现 ValidateAll 时优先使用，未实现任何校验接口时返回 nil。
+//
+// 校验失败时返回 *Error。
+func Validate(v any) error {
+    var err error
+    switch m := v.(type) {
+    case allValidator:
+        err = m.ValidateAll()
+    case validator:
+        err = m.Validate()
+    }
+    if err == nil {
+        return nil
+    }
+    return &Error{Violations: violations(err), err: err}
+}
+
+// violations 从错误中提取字段校验错误。
+func violations(err error) []FieldViolation {
+    var multi multiError
+    if errors.As(err, &multi) {
+        var vs []FieldViolation
+        for _, e := range multi.AllErrors() {
+            vs = append(vs, violations(e)...)
+        }
+        return vs
+    }
+    var fe fieldError
+    if errors.As(err, &fe) {
+        field := fe.Field()
+        // 嵌套消息的字段错误拼接为 a.b 形式。
+        if c, ok := fe.(causer); ok && c.Cause() != nil {
+            var inner fieldError
+            if errors.As(c.Cause(), &inner) {
+                vs := violations(c.Cause())
+                for i := range vs {
+                    vs[i].Field = strings.TrimSuffix(field+"."+vs[i].Field, ".")
+                }
+                return vs
+            }
+        }
+        return []FieldViolation{{Field: field, Description: fe.Reason()}}
+    }
+    return []FieldViolation{{Description: err.Error()}}
+}
@@ -0,0 +1,179 @@
+package validate
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "errors"
+    "io"
+    "net/http"
+    "strings"
+
+    "google.golang.org/genproto/googleapis/rpc/errdetails"
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/status"
+
+    "github.com/camry/dove/v2/server/ghttp"
+)
+
+// UnaryServerInterceptor 返回校验 gRPC 一元请求消息的拦截器，校验失败时返回 codes.InvalidArgument。
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+    return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+        if err := Validate(req); err != nil {
+            return nil, GRPCError(err)
+        }
+        return handler(ctx, req)
+    }
+}
+
+// StreamServerInterceptor 返回校验 gRPC 流接收消息的拦截器，校验失败时 RecvMsg 返回 codes.InvalidArgument。
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+    return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+        return handler(srv, &wrappedStream{ServerStream: ss})
+    }
+}
+
+// wrappedStream 校验流接收的消息。
+type wrappedStream struct {
+    grpc.ServerStream
+}
+
+func (w *wrappedStream) RecvMsg(m any) error {
+    if err := w.ServerStream.RecvMsg(m); err != nil {
+        return err
+    }
+    if err := Validate(m); err != nil {
+        return GRPCError(err)
+    }
+    return nil
+}
+
+// GRPCError 将校验错误转换为携带 errdetails.BadRequest 的 codes.InvalidArgument 状态错误。
+func GRPCError(err error) error {
+    st := status.New(codes.InvalidArgument, err.Error())
+    var ve *Error
+    if !errors.As(err, &ve) {
+        return st.Err()
+    }
+    br := &errdetails.BadRequest{}
+    for _, v := range ve.Violations {
+        br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+            Field:       v.Field,
+            Description: v.Description,
+        })
+    }
+    if ds, dErr := st.WithDetails(br); dErr == nil {
+        st = ds
+    }
+    return st.Err()
+}
+
+// Option 定义一个校验中间件选项类型。
+type Option func(o *option)
+
+// route JSON 请求体路由。
+type route struct {
+    method string
+    path   string
+    newFn  func() any
+}
+
+// option 校验中间件选项实体对象。
+type option struct {
+    routes  []route
+    maxBody int64
+}
+
+// MaxBodySize 配置请求体最大字节数，超出时响应 413，默认 4 MiB。
+func MaxBodySize(n int64) Option {
+    return func(o *option) { o.maxBody = n }
+}
+
+// Body 配置需要解码并校验 JSON 请求体的路由，pattern 形如 "POST /users" 或 "/users"，newFn 返回用于解码的新值。
+func Body(pattern string, newFn func() any) Option {
+    return func(o *option) {
+        method, path, ok := strings.Cut(pattern, " ")
+        if !ok {
+            method, path = "", pattern
+        }
+        o.routes = append(o.routes, route{method: method, path: strings.TrimSpace(path), newFn: newFn})
+    }
+}
+
+type bodyKey struct{}
+
+// FromContext 返回 Filter 解码并校验通过的请求体（如果有）。
+func FromContext(ctx context.Context) (v any, ok bool) {
+    v = ctx.Value(bodyKey{})
+    return v, v != nil
+}
+
+// Filter 返回解码并校验 JSON 请求体的 HTTP 过滤器，校验失败时响应 400 及字段错误列表，
+// 请求体超过 MaxBodySize 时响应 413。
+//
+// 校验通过后请求体可通过 FromContext 获取，原始请求体仍可再次读取。
+func Filter(opts ...Option) ghttp.FilterFunc {
+    o := &option{maxBody: 4 << 20}
+    for _, opt := range opts {
+        opt(o)
+    }
+    return func(next http.Handler) http.Handler {
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            rt := o.match(r)
+            if rt == nil {
+                next.ServeHTTP(w, r)
+                return
+            }
+            data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, o.maxBody))
+            _ = r.Body.Close()
+            if err != nil {
+                var me *http.MaxBytesError
+                if errors.As(err, &me) {
+                    writeError(w, http.StatusRequestEntityTooLarge, err)
+                    return
+                }
+                writeError(w, http.StatusBadRequest, err)
+                return
+            }
+            v := rt.newFn()
+            if err = json.Unmarshal(data, v); err != nil {
+                writeError(w, http.StatusBadRequest, err)
+                return
+            }
+            if err = Validate(v); err != nil {
+                writeError(w, http.StatusBadRequest, err)
+                return
+            }
+            r.Body = io.NopCloser(bytes.NewReader(data))
+            next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), bodyKey{}, v)))
+        })
+    }
+}
+
+// match 匹配请求路由。
+func (o *option) match(r *http.Request) *route {
+    for i := range o.routes {
+        rt := &o.routes[i]
+        if rt.path == r.URL.Path && (rt.method == "" || rt.method == r.Method) {
+            return rt
+        }
+    }
+    return nil
+}
+
+// writeError 以 JSON 格式响应错误。
+func writeError(w http.ResponseWriter, code int, err error) {
+    body := struct {
+        Code       int              `json:"code"`
+        Message    string           `json:"message"`
+        Violations []FieldViolation `json:"violations,omitempty"`
+    }{Code: code, Message: err.Error()}
+    var ve *Error
+    if errors.As(err, &ve) {
+        body.Violations = ve.Violations
+    }
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(code)
+    _ = json.NewEncoder(w).Encode(body)
+}
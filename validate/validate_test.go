@@ -0,0 +1,148 @@
+package validate
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+
+    "google.golang.org/genproto/googleapis/rpc/errdetails"
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/status"
+)
+
+// fieldErr 模拟 protoc-gen-validate 生成的字段错误。
+type fieldErr struct {
+    field, reason string
+    cause         error
+}
+
+func (e fieldErr) Error() string  { return e.field + ": " + e.reason }
+func (e fieldErr) Field() string  { return e.field }
+func (e fieldErr) Reason() string { return e.reason }
+func (e fieldErr) Cause() error   { return e.cause }
+
+// multiErr 模拟 protoc-gen-validate 生成的多错误。
+type multiErr []error
+
+func (m multiErr) Error() string      { return errors.Join(m...).Error() }
+func (m multiErr) AllErrors() []error { return m }
+
+type user struct {
+    Name  string `json:"name"`
+    Email string `json:"email"`
+}
+
+func (u *user) Validate() error {
+    if u.Name == "" {
+        return fieldErr{field: "Name", reason: "value length must be at least 1 runes"}
+    }
+    return nil
+}
+
+func (u *user) ValidateAll() error {
+    var errs multiErr
+    if u.Name == "" {
+        errs = append(errs, fieldErr{field: "Name", reason: "value length must be at least 1 runes"})
+    }
+    if !strings.Contains(u.Email, "@") {
+        errs = append(errs, fieldErr{field: "Profile", reason: "embedded message failed validation",
+            cause: fieldErr{field: "Email", reason: "value must be a valid email address"}})
+    }
+    if len(errs) > 0 {
+        return errs
+    }
+    return nil
+}
+
+func TestValidate(t *testing.T) {
+    err := Validate(&user{})
+    var ve *Error
+    if !errors.As(err, &ve) {
+        t.Fatalf("expect *Error, got %v", err)
+    }
+    want := []FieldViolation{
+        {Field: "Name", Description: "value length must be at least 1 runes"},
+        {Field: "Profile.Email", Description: "value must be a valid email address"},
+    }
+    if len(ve.Violations) != len(want) {
+        t.Fatalf("expect %v, got %v", want, ve.Violations)
+    }
+    for i := range want {
+        if ve.Violations[i] != want[i] {
+            t.Fatalf("expect %v, got %v", want, ve.Violations)
+        }
+    }
+    if err = Validate(&user{Name: "a", Email: "a@b"}); err != nil {
+        t.Fatal(err)
+    }
+    if err = Validate("not a validator"); err != nil {
+        t.Fatal(err)
+    }
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+    in := UnaryServerInterceptor()
+    _, err := in(context.Background(), &user{}, &grpc.UnaryServerInfo{}, func(ctx context.Context, req any) (any, error) {
+        t.Fatal("handler should not be called")
+        return nil, nil
+    })
+    st := status.Convert(err)
+    if st.Code() != codes.InvalidArgument {
+        t.Fatalf("expect InvalidArgument, got %v", err)
+    }
+    if len(st.Details()) != 1 {
+        t.Fatalf("expect 1 detail, got %v", st.Details())
+    }
+    br, ok := st.Details()[0].(*errdetails.BadRequest)
+    if !ok || len(br.FieldViolations) != 2 || br.FieldViolations[1].Field != "Profile.Email" {
+        t.Fatalf("unexpected detail %v", st.Details()[0])
+    }
+}
+
+func TestFilter(t *testing.T) {
+    h := Filter(Body("POST /users", func() any { return &user{} }))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        v, ok := FromContext(r.Context())
+        if !ok || v.(*user).Name != "alice" {
+            t.Errorf("unexpected body %v", v)
+        }
+        data, _ := io.ReadAll(r.Body)
+        _, _ = w.Write(data)
+    }))
+
+    w := httptest.NewRecorder()
+    h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name":"","email":"x"}`)))
+    if w.Code != http.StatusBadRequest {
+        t.Fatalf("expect 400, got %d", w.Code)
+    }
+    var body struct {
+        Violations []FieldViolation `json:"violations"`
+    }
+    if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || len(body.Violations) != 2 {
+        t.Fatalf("unexpected body %s", w.Body.String())
+    }
+
+    w = httptest.NewRecorder()
+    payload := `{"name":"alice","email":"a@b"}`
+    h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(payload)))
+    if w.Code != http.StatusOK || w.Body.String() != payload {
+        t.Fatalf("expect 200 with original body, got %d %s", w.Code, w.Body.String())
+    }
+}
+
+func TestFilter_MaxBodySize(t *testing.T) {
+    called := false
+    h := Filter(Body("POST /users", func() any { return &user{} }), MaxBodySize(16))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        called = true
+    }))
+    w := httptest.NewRecorder()
+    h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name":"alice","email":"a@b"}`)))
+    if w.Code != http.StatusRequestEntityTooLarge || called {
+        t.Fatalf("expect 413, got %d", w.Code)
+    }
+}